@@ -0,0 +1,172 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"errors"
+	"image"
+	"slices"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// Errors reported by NewPathfinderFromAreas and NewPathfinderFromAreasF in
+// addition to those of NewCheckedPathfinder. They are wrapped in a
+// *PolygonError.
+var (
+	ErrNotInside = errors.New("polygon is not inside its parent")
+	ErrOverlap   = errors.New("polygon overlaps a sibling")
+)
+
+// An Area is an accessible area, given by its outline and the holes in it.
+type Area struct {
+	Outline []image.Point
+	Holes   []Hole
+}
+
+// A Hole is an inaccessible part of an Area, given by its outline and the
+// islands in it, which are accessible areas again.
+type Hole struct {
+	Outline []image.Point
+	Islands []Area
+}
+
+// An AreaF is like an Area, but with floating-point coordinates.
+type AreaF struct {
+	Outline []geom.Vec2
+	Holes   []HoleF
+}
+
+// A HoleF is like a Hole, but with floating-point coordinates.
+type HoleF struct {
+	Outline []geom.Vec2
+	Islands []AreaF
+}
+
+// NewPathfinderFromAreas creates a Pathfinder for accessible areas with
+// explicitly given holes and islands. Unlike for NewPathfinder, the
+// nesting of the polygons is not derived from their containment, so a
+// hole may touch the outline of its area, and an island the outline of
+// its hole.
+//
+// The polygons are validated like by NewCheckedPathfinder. Additionally,
+// each hole must lie inside its area and each island inside its hole, and
+// the areas at the first level, the holes of an area, and the islands of
+// a hole must not overlap each other. They may touch, though. The Polygon
+// index of a *PolygonError counts the outlines in depth-first order: an
+// area, then each of its holes followed by the islands of the hole.
+//
+// Where polygons touch, the accessible area between them has zero width,
+// so it is interrupted there: paths don't pass through a point where a
+// hole touches the outline of its area or another hole, or an island the
+// outline of its hole, and don't run along an edge they share.
+func NewPathfinderFromAreas(areas []Area, opts ...Option) (*Pathfinder, error) {
+	var n int
+	var err error
+	// outline converts an outline and checks its coordinates, counting
+	// the outlines in the order of the Polygon index of a *PolygonError.
+	outline := func(p []image.Point) []geom.Vec2 {
+		for j, pt := range p {
+			if err == nil && max(pt.X, -pt.X, pt.Y, -pt.Y) > maxIntCoord {
+				err = &PolygonError{Polygon: n, Vertex: j, Err: ErrCoordinateRange}
+			}
+		}
+		n++
+		return ps2vs(p)
+	}
+	var convertAreas func(areas []Area) []AreaF
+	convertAreas = func(areas []Area) []AreaF {
+		res := make([]AreaF, len(areas))
+		for i, a := range areas {
+			res[i].Outline = outline(a.Outline)
+			for _, h := range a.Holes {
+				hole := HoleF{Outline: outline(h.Outline)}
+				hole.Islands = convertAreas(h.Islands)
+				res[i].Holes = append(res[i].Holes, hole)
+			}
+		}
+		return res
+	}
+	areasF := convertAreas(areas)
+	if err != nil {
+		return nil, err
+	}
+	return NewPathfinderFromAreasF(areasF, opts...)
+}
+
+// NewPathfinderFromAreasF is like NewPathfinderFromAreas, but the polygon
+// vertices are given as floating-point coordinates. Like
+// NewCheckedPathfinderF, it reports coordinates that are NaN or infinite.
+func NewPathfinderFromAreasF(areas []AreaF, opts ...Option) (*Pathfinder, error) {
+	var ps poly.PolygonSet
+	var nest nesting
+	var addAreas func(areas []AreaF, parent int)
+	addAreas = func(areas []AreaF, parent int) {
+		for _, a := range areas {
+			i := len(ps)
+			ps = append(ps, slices.Clone(a.Outline))
+			nest = append(nest, parent)
+			for _, h := range a.Holes {
+				j := len(ps)
+				ps = append(ps, slices.Clone(h.Outline))
+				nest = append(nest, i)
+				addAreas(h.Islands, j)
+			}
+		}
+	}
+	addAreas(areas, -1)
+	if err := validate(ps); err != nil {
+		return nil, err
+	}
+	if err := validateNesting(ps, nest); err != nil {
+		return nil, err
+	}
+	c := newConfig(opts)
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateOffset(ps, nest, c.radius, c.join); err != nil {
+		return nil, err
+	}
+	return newPathfinder(ps, nest, c), nil
+}
+
+// validateNesting checks that each polygon lies inside its parent and
+// doesn't overlap its siblings, the other polygons with the same parent.
+func validateNesting(ps poly.PolygonSet, nest nesting) error {
+	children := make(map[int][]int)
+	for i, parent := range nest {
+		if parent >= 0 && !ps[i].IsInside(ps[parent]) {
+			return &PolygonError{Polygon: i, Vertex: -1, Err: ErrNotInside}
+		}
+		for _, j := range children[parent] {
+			if overlap(ps[i], ps[j]) {
+				return &PolygonError{Polygon: i, Vertex: -1, Err: ErrOverlap}
+			}
+		}
+		children[parent] = append(children[parent], i)
+	}
+	return nil
+}
+
+// overlap reports whether the interiors of polygons p and q overlap.
+func overlap(p, q poly.Polygon) bool {
+	if !bounds(p...).overlaps(bounds(q...)) {
+		return false
+	}
+	return intrudes(p, q) || intrudes(q, p) || p.CrossesEdgeOf(q)
+}
+
+// intrudes reports whether a vertex or an edge middle of polygon p lies
+// strictly inside polygon q.
+func intrudes(p, q poly.Polygon) bool {
+	for i, v := range p {
+		if q.Contains(v, false) || q.ContainsMiddle(p.Edge(i), false) {
+			return true
+		}
+	}
+	return false
+}
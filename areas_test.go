@@ -0,0 +1,262 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+func TestNewPathfinderFromAreasF(t *testing.T) {
+	square := []geom.Vec2{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)}
+	// A hole touching the bottom side of the square, which NewPathfinder
+	// would take for a separate area, so that the path would cross it.
+	touching := []geom.Vec2{geom.V2(4, 0), geom.V2(6, 0), geom.V2(7, 3), geom.V2(3, 3)}
+	corridor := []geom.Vec2{geom.V2(0, 0), geom.V2(30, 0), geom.V2(30, 10), geom.V2(0, 10)}
+	tests := []struct {
+		name  string
+		areas []pathfind.AreaF
+		start geom.Vec2
+		dest  geom.Vec2
+		want  []geom.Vec2
+	}{
+		{
+			name: "Hole touching the outline",
+			areas: []pathfind.AreaF{
+				{Outline: square, Holes: []pathfind.HoleF{{Outline: touching}}},
+			},
+			start: geom.V2(1, 1),
+			dest:  geom.V2(9, 1),
+			want:  []geom.Vec2{geom.V2(1, 1), geom.V2(3, 3), geom.V2(7, 3), geom.V2(9, 1)},
+		},
+		{
+			name: "Hole blocking a corridor",
+			areas: []pathfind.AreaF{
+				{Outline: corridor, Holes: []pathfind.HoleF{{
+					Outline: []geom.Vec2{geom.V2(10, 0), geom.V2(20, 0), geom.V2(20, 10), geom.V2(10, 10)},
+				}}},
+			},
+			start: geom.V2(5, 5),
+			dest:  geom.V2(25, 5),
+			want:  nil,
+		},
+		{
+			name: "Holes touching at a corner",
+			areas: []pathfind.AreaF{
+				{Outline: corridor, Holes: []pathfind.HoleF{
+					{Outline: []geom.Vec2{geom.V2(10, 0), geom.V2(15, 0), geom.V2(15, 5), geom.V2(10, 5)}},
+					{Outline: []geom.Vec2{geom.V2(15, 5), geom.V2(20, 5), geom.V2(20, 10), geom.V2(15, 10)}},
+				}},
+			},
+			start: geom.V2(12, 8),
+			dest:  geom.V2(18, 2),
+			want:  nil,
+		},
+		{
+			name: "Path bending around touching holes",
+			areas: []pathfind.AreaF{
+				{Outline: corridor, Holes: []pathfind.HoleF{
+					{Outline: []geom.Vec2{geom.V2(5, 5), geom.V2(15, 5), geom.V2(5, 6)}},
+					{Outline: []geom.Vec2{geom.V2(15, 5), geom.V2(16, 10), geom.V2(14, 10)}},
+				}},
+			},
+			start: geom.V2(8, 4),
+			dest:  geom.V2(17, 8),
+			want:  []geom.Vec2{geom.V2(8, 4), geom.V2(15, 5), geom.V2(17, 8)},
+		},
+		{
+			name: "Island in a hole",
+			areas: []pathfind.AreaF{
+				{
+					Outline: square,
+					Holes: []pathfind.HoleF{{
+						Outline: []geom.Vec2{geom.V2(2, 2), geom.V2(8, 2), geom.V2(8, 8), geom.V2(2, 8)},
+						Islands: []pathfind.AreaF{
+							{Outline: []geom.Vec2{geom.V2(2, 3), geom.V2(5, 3), geom.V2(5, 5), geom.V2(2, 5)}},
+						},
+					}},
+				},
+			},
+			start: geom.V2(3, 4),
+			dest:  geom.V2(4, 4.5),
+			want:  []geom.Vec2{geom.V2(3, 4), geom.V2(4, 4.5)},
+		},
+		{
+			name: "Hole separating the island",
+			areas: []pathfind.AreaF{
+				{
+					Outline: square,
+					Holes: []pathfind.HoleF{{
+						Outline: []geom.Vec2{geom.V2(2, 2), geom.V2(8, 2), geom.V2(8, 8), geom.V2(2, 8)},
+						Islands: []pathfind.AreaF{
+							{Outline: []geom.Vec2{geom.V2(2, 3), geom.V2(5, 3), geom.V2(5, 5), geom.V2(2, 5)}},
+						},
+					}},
+				},
+			},
+			start: geom.V2(3, 4),
+			dest:  geom.V2(1, 4),
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathfinder, err := pathfind.NewPathfinderFromAreasF(tt.areas)
+			if err != nil {
+				t.Fatalf("NewPathfinderFromAreasF: unexpected error: %v", err)
+			}
+			got := pathfinder.PathF(tt.start, tt.dest)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PathF(%v, %v)\n got: %v\nwant: %v", tt.start, tt.dest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPathfinderFromAreas(t *testing.T) {
+	areas := []pathfind.Area{{
+		Outline: []image.Point{image.Pt(0, 0), image.Pt(10, 0), image.Pt(10, 10), image.Pt(0, 10)},
+		Holes: []pathfind.Hole{{
+			Outline: []image.Point{image.Pt(4, 0), image.Pt(6, 0), image.Pt(7, 3), image.Pt(3, 3)},
+			Islands: []pathfind.Area{{
+				Outline: []image.Point{image.Pt(4, 1), image.Pt(6, 1), image.Pt(6, 2), image.Pt(4, 2)},
+			}},
+		}},
+	}}
+	pathfinder, err := pathfind.NewPathfinderFromAreas(areas)
+	if err != nil {
+		t.Fatalf("NewPathfinderFromAreas: unexpected error: %v", err)
+	}
+	start, dest := image.Pt(1, 1), image.Pt(9, 1)
+	want := []image.Point{image.Pt(1, 1), image.Pt(3, 3), image.Pt(7, 3), image.Pt(9, 1)}
+	if got := pathfinder.Path(start, dest); !reflect.DeepEqual(got, want) {
+		t.Errorf("Path(%v, %v)\n got: %v\nwant: %v", start, dest, got, want)
+	}
+
+	areas[0].Holes[0].Islands[0].Outline[2] = image.Pt(6, 1<<25)
+	_, err = pathfind.NewPathfinderFromAreas(areas)
+	wantErr := &pathfind.PolygonError{Polygon: 2, Vertex: 2, Err: pathfind.ErrCoordinateRange}
+	if !reflect.DeepEqual(err, wantErr) {
+		t.Errorf("NewPathfinderFromAreas with large coordinate\n got error: %v\nwant error: %v", err, wantErr)
+	}
+}
+
+func TestNewPathfinderFromAreasFErrors(t *testing.T) {
+	square := func(x, y, size float32) []geom.Vec2 {
+		return []geom.Vec2{geom.V2(x, y), geom.V2(x+size, y), geom.V2(x+size, y+size), geom.V2(x, y+size)}
+	}
+	tests := []struct {
+		name    string
+		areas   []pathfind.AreaF
+		opts    []pathfind.Option
+		wantErr error
+	}{
+		{
+			name:    "No areas",
+			areas:   nil,
+			wantErr: pathfind.ErrNoPolygons,
+		},
+		{
+			name: "Invalid hole",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{{Outline: square(2, 2, 2)[:2]}}},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: -1, Err: pathfind.ErrTooFewVertices},
+		},
+		{
+			name: "Hole outside the area",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{{Outline: square(20, 0, 2)}}},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: -1, Err: pathfind.ErrNotInside},
+		},
+		{
+			name: "Hole crossing the outline",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{{Outline: square(8, 4, 4)}}},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: -1, Err: pathfind.ErrNotInside},
+		},
+		{
+			name: "Hole around the area",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{{Outline: square(-1, -1, 12)}}},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: -1, Err: pathfind.ErrNotInside},
+		},
+		{
+			name: "Island outside the hole",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{{
+					Outline: square(1, 1, 3),
+					Islands: []pathfind.AreaF{{Outline: square(6, 6, 1)}},
+				}}},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 2, Vertex: -1, Err: pathfind.ErrNotInside},
+		},
+		{
+			name: "Overlapping holes",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{
+					{Outline: square(1, 1, 4)},
+					{Outline: square(3, 3, 4)},
+				}},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 2, Vertex: -1, Err: pathfind.ErrOverlap},
+		},
+		{
+			name: "Overlapping areas",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10)},
+				{Outline: square(2, 2, 4)},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: -1, Err: pathfind.ErrOverlap},
+		},
+		{
+			name: "Touching holes",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{
+					{Outline: square(1, 1, 4)},
+					{Outline: square(5, 1, 4)},
+				}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Hole closer than twice the radius to the outline",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{{Outline: square(1, 4, 2)}}},
+			},
+			opts:    []pathfind.Option{pathfind.WithRadius(1, pathfind.RoundJoin)},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: -1, Err: pathfind.ErrOffsetOverlap},
+		},
+		{
+			name: "Island close to the outline of its hole",
+			areas: []pathfind.AreaF{
+				{Outline: square(0, 0, 20), Holes: []pathfind.HoleF{{
+					Outline: square(4, 4, 12),
+					Islands: []pathfind.AreaF{{Outline: square(5, 5, 10)}},
+				}}},
+			},
+			opts:    []pathfind.Option{pathfind.WithRadius(1, pathfind.RoundJoin)},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathfinder, err := pathfind.NewPathfinderFromAreasF(tt.areas, tt.opts...)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("NewPathfinderFromAreasF(%v)\n got error: %v\nwant error: %v", tt.areas, err, tt.wantErr)
+			}
+			if (pathfinder == nil) != (err != nil) {
+				t.Errorf("NewPathfinderFromAreasF(%v) = %v, %v; want either pathfinder or error", tt.areas, pathfinder, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// The binary format of a Pathfinder starts with a magic number and a
+// format version, followed by the start policy, the backend, the radius
+// and the join, the polygon set, the parent of each polygon in the nesting
+// of the polygon set, incremented by one, the vertices of the visibility
+// graph, the adjacency lists of the graph as vertex indices, the regions,
+// if there are regions, the costs of the graph edges, and the vertices of
+// the navigation mesh triangles. Counts and indices are unsigned varints,
+// coordinates and the radius float32 and costs float64 values, both
+// little-endian.
+const (
+	binaryMagic   = "PFND"
+	binaryVersion = 1
+)
+
+var errBinaryData = errors.New("pathfind: invalid binary data")
+
+// MarshalBinary encodes the preprocessed state of the Pathfinder, i.e. the
+// polygon set, the classified vertices and the visibility graph between
+// them or the navigation mesh, the start policy and the regions, in a
+// versioned binary format.
+// Decoding it with UnmarshalBinary restores a Pathfinder that is ready
+// to be queried without repeating the preprocessing.
+// The visibility graph of the last path query is not encoded. Obstacles
+// added with AddObstacle are encoded as part of the polygon set, so they
+// can't be removed from the decoded Pathfinder.
+// The search options, see WithSearch, are not encoded, and the Pathfinder
+// decoded by UnmarshalBinary uses the default A* search.
+func (p *Pathfinder) MarshalBinary() ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	b := []byte(binaryMagic)
+	b = binary.LittleEndian.AppendUint16(b, binaryVersion)
+	b = append(b, byte(p.startPolicy), byte(p.backend()))
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(p.radius))
+	b = append(b, byte(p.join))
+	b = binary.AppendUvarint(b, uint64(len(p.polygonSet)))
+	for _, q := range p.polygonSet {
+		b = appendVecs(b, q)
+	}
+	for _, parent := range p.nesting {
+		b = binary.AppendUvarint(b, uint64(parent+1))
+	}
+	b = appendVecs(b, p.vertices)
+	nodes, index := p.graphNodes()
+	for _, n := range nodes {
+		nbs := p.staticGraph[n]
+		b = binary.AppendUvarint(b, uint64(len(nbs)))
+		for _, m := range nbs {
+			b = binary.AppendUvarint(b, uint64(index[m]))
+		}
+	}
+	b = binary.AppendUvarint(b, uint64(len(p.regions)))
+	for _, r := range p.regions {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(r.cost))
+		b = appendVecs(b, r.polygon)
+	}
+	if len(p.regions) > 0 {
+		for _, n := range nodes {
+			for _, m := range p.staticGraph[n] {
+				c := p.edgeCosts[[2]geom.Vec2{n, m}]
+				b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c))
+			}
+		}
+	}
+	var tris []geom.Vec2
+	if p.mesh != nil {
+		for _, t := range p.mesh.tris {
+			tris = append(tris, t[:]...)
+		}
+	}
+	b = appendVecs(b, tris)
+	return b, nil
+}
+
+// UnmarshalBinary decodes data encoded by MarshalBinary into the
+// Pathfinder, replacing its previous state. Concurrent path queries see
+// either the previous or the decoded state.
+func (p *Pathfinder) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data}
+	if string(d.bytes(len(binaryMagic))) != binaryMagic {
+		return fmt.Errorf("%w: missing magic number", errBinaryData)
+	}
+	version := d.uint16()
+	if d.err == nil && version != binaryVersion {
+		return fmt.Errorf("pathfind: unsupported binary format version %d", version)
+	}
+	policy := StartPolicy(d.byte())
+	backend := Backend(d.byte())
+	radius := d.float32()
+	join := Join(d.byte())
+	ps := make(poly.PolygonSet, d.count(1))
+	for i := range ps {
+		ps[i] = d.vecs()
+	}
+	nest := make(nesting, len(ps))
+	for i := range nest {
+		parent := d.uvarint()
+		if parent > uint64(len(ps)) {
+			d.fail("parent index out of range")
+			break
+		}
+		nest[i] = int(parent) - 1
+	}
+	q := &Pathfinder{
+		polygonSet:  ps,
+		nesting:     nest,
+		vertices:    d.vecs(),
+		staticGraph: make(graph[geom.Vec2]),
+		startPolicy: policy,
+	}
+	nodes, _ := q.graphNodes()
+	for _, n := range nodes {
+		for range d.count(1) {
+			i := d.uvarint()
+			if i >= uint64(len(nodes)) {
+				d.fail("vertex index out of range")
+				break
+			}
+			q.staticGraph.link(n, nodes[i])
+		}
+	}
+	q.regions = make([]region, d.count(9))
+	for i := range q.regions {
+		q.regions[i].cost = d.float64()
+		q.regions[i].polygon = d.vecs()
+	}
+	if len(q.regions) > 0 {
+		q.edgeCosts = make(map[[2]geom.Vec2]float64)
+		for _, n := range nodes {
+			for _, m := range q.staticGraph[n] {
+				q.edgeCosts[[2]geom.Vec2{n, m}] = d.float64()
+			}
+		}
+	}
+	var tris []poly.Triangle
+	vs := d.vecs()
+	if len(vs)%3 != 0 {
+		d.fail("incomplete triangle")
+	}
+	for i := 0; i+2 < len(vs); i += 3 {
+		tris = append(tris, poly.Triangle{vs[i], vs[i+1], vs[i+2]})
+	}
+	if d.err == nil && len(d.data) > 0 {
+		d.fail("trailing data")
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if !q.nesting.isTree() {
+		return fmt.Errorf("%w: cyclic nesting", errBinaryData)
+	}
+	if policy < RejectStart || policy > ExitStart {
+		return fmt.Errorf("%w: start policy %d", errBinaryData, policy)
+	}
+	if radius < 0 || !isFinite(radius) {
+		return fmt.Errorf("%w: radius %v", errBinaryData, radius)
+	}
+	if join != RoundJoin && join != MiterJoin {
+		return fmt.Errorf("%w: join %d", errBinaryData, join)
+	}
+	switch {
+	case backend != VisibilityGraphBackend && backend != NavMeshBackend:
+		return fmt.Errorf("%w: backend %d", errBinaryData, backend)
+	case backend == VisibilityGraphBackend && len(tris) > 0:
+		return fmt.Errorf("%w: triangles without navigation mesh backend", errBinaryData)
+	}
+	q.index = poly.NewIndex(q.polygonSet)
+	if backend == NavMeshBackend {
+		q.mesh = newNavMesh(tris, q.index)
+	}
+	for i, r := range q.regions {
+		if !(r.cost > 0) || math.IsInf(r.cost, 0) {
+			return fmt.Errorf("%w: region %d: cost %v", errBinaryData, i, r.cost)
+		}
+	}
+	for _, c := range q.edgeCosts {
+		if !(c >= 0) || math.IsInf(c, 0) {
+			return fmt.Errorf("%w: edge cost %v", errBinaryData, c)
+		}
+	}
+	q.minCost = minCost(q.regions)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.polygonSet = q.polygonSet
+	p.nesting = q.nesting
+	p.index = q.index
+	p.vertices = q.vertices
+	p.staticGraph = q.staticGraph
+	p.startPolicy = q.startPolicy
+	p.radius = radius
+	p.join = join
+	p.regions = q.regions
+	p.edgeCosts = q.edgeCosts
+	p.minCost = q.minCost
+	p.mesh = q.mesh
+	p.obstacles = nil
+	p.search = searchConfig{}
+	p.lastGraph.Store(nil)
+	return nil
+}
+
+// backend returns the backend the Pathfinder was created with.
+func (p *Pathfinder) backend() Backend {
+	if p.mesh != nil {
+		return NavMeshBackend
+	}
+	return VisibilityGraphBackend
+}
+
+// graphNodes returns the distinct vertices of the visibility graph in the
+// order of their first occurrence, and the index of each vertex in this
+// list.
+func (p *Pathfinder) graphNodes() ([]geom.Vec2, map[geom.Vec2]int) {
+	var nodes []geom.Vec2
+	index := make(map[geom.Vec2]int, len(p.vertices))
+	for _, v := range p.vertices {
+		if _, ok := index[v]; !ok {
+			index[v] = len(nodes)
+			nodes = append(nodes, v)
+		}
+	}
+	return nodes, index
+}
+
+func appendVecs(b []byte, vs []geom.Vec2) []byte {
+	b = binary.AppendUvarint(b, uint64(len(vs)))
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(v.X))
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(v.Y))
+	}
+	return b
+}
+
+// A decoder reads the binary format of a Pathfinder. After the first
+// error all reads return zero values, and the error is kept in err.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) fail(msg string) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: %s", errBinaryData, msg)
+	}
+	d.data = nil
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if len(d.data) < n {
+		d.fail("unexpected end of data")
+		return make([]byte, n)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) byte() byte {
+	return d.bytes(1)[0]
+}
+
+func (d *decoder) uint16() uint16 {
+	return binary.LittleEndian.Uint16(d.bytes(2))
+}
+
+func (d *decoder) float32() float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(d.bytes(4)))
+}
+
+func (d *decoder) float64() float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(d.bytes(8)))
+}
+
+func (d *decoder) uvarint() uint64 {
+	x, n := binary.Uvarint(d.data)
+	if n == 0 {
+		d.fail("unexpected end of data")
+		return 0
+	}
+	if n < 0 {
+		d.fail("invalid varint")
+		return 0
+	}
+	d.data = d.data[n:]
+	return x
+}
+
+// count reads the number of following elements, each of which takes at
+// least size bytes. It fails if there is not enough data left for them,
+// so that corrupt data can't cause huge allocations.
+func (d *decoder) count(size int) int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)/size) {
+		d.fail("count exceeds data length")
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) vecs() []geom.Vec2 {
+	vs := make([]geom.Vec2, d.count(8))
+	for i := range vs {
+		vs[i] = geom.V2(d.float32(), d.float32())
+	}
+	return vs
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*pathfind.Pathfinder)(nil)
+	_ encoding.BinaryUnmarshaler = (*pathfind.Pathfinder)(nil)
+)
+
+func TestPathfinderMarshalBinary(t *testing.T) {
+	mud := pathfind.Region{
+		Polygon: []geom.Vec2{geom.V2(15, 8), geom.V2(25, 8), geom.V2(25, 30), geom.V2(15, 30)},
+		Cost:    3,
+	}
+	tests := []struct {
+		name       string
+		pathfinder *pathfind.Pathfinder
+		queries    [][2]geom.Vec2
+	}{
+		{
+			name:       "U",
+			pathfinder: pathfind.NewPathfinderF(polygonUF),
+			queries: [][2]geom.Vec2{
+				{geom.V2(5, 5), geom.V2(25, 5)},
+				{geom.V2(25, 5), geom.V2(5, 15)},
+				{geom.V2(15, 5), geom.V2(5, 5)},
+			},
+		},
+		{
+			name:       "O with radius and start policy",
+			pathfinder: pathfind.NewPathfinder(polygonO, pathfind.WithRadius(1, pathfind.RoundJoin), pathfind.WithStartPolicy(pathfind.SnapStart)),
+			queries: [][2]geom.Vec2{
+				{geom.V2(20, 5), geom.V2(20, 35)},
+				{geom.V2(-5, 20), geom.V2(45, 20)},
+			},
+		},
+		{
+			name:       "navigation mesh",
+			pathfinder: pathfind.NewPathfinderF(polygonUF, pathfind.WithBackend(pathfind.NavMeshBackend)),
+			queries: [][2]geom.Vec2{
+				{geom.V2(5, 5), geom.V2(25, 5)},
+				{geom.V2(25, 5), geom.V2(5, 15)},
+			},
+		},
+		{
+			name: "regions",
+			pathfinder: pathfind.NewPathfinderF([][]geom.Vec2{
+				{geom.V2(0, 0), geom.V2(40, 0), geom.V2(40, 40), geom.V2(0, 40)},
+			}, pathfind.WithRegions(mud)),
+			queries: [][2]geom.Vec2{
+				{geom.V2(5, 20), geom.V2(35, 20)},
+				{geom.V2(20, 5), geom.V2(20, 35)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		data, err := tt.pathfinder.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: MarshalBinary: unexpected error: %v", tt.name, err)
+		}
+		var got pathfind.Pathfinder
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s: UnmarshalBinary: unexpected error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got.Polygons(), tt.pathfinder.Polygons()) {
+			t.Errorf("%s: Polygons()\n got: %v\nwant: %v", tt.name, got.Polygons(), tt.pathfinder.Polygons())
+		}
+		for _, q := range tt.queries {
+			wantRes, wantErr := tt.pathfinder.Find(q[0], q[1])
+			gotRes, gotErr := got.Find(q[0], q[1])
+			if !reflect.DeepEqual(gotRes, wantRes) || gotErr != wantErr {
+				t.Errorf("%s: Find(%v, %v)\n got: %v, %v\nwant: %v, %v",
+					tt.name, q[0], q[1], gotRes, gotErr, wantRes, wantErr)
+			}
+			if g, w := got.VisibilityGraphF(), tt.pathfinder.VisibilityGraphF(); !reflect.DeepEqual(g, w) {
+				t.Errorf("%s: VisibilityGraphF() after Find(%v, %v)\n got: %v\nwant: %v",
+					tt.name, q[0], q[1], g, w)
+			}
+		}
+		// Encoding is deterministic.
+		again, err := got.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: MarshalBinary: unexpected error: %v", tt.name, err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Errorf("%s: MarshalBinary of decoded Pathfinder differs", tt.name)
+		}
+	}
+}
+
+func TestPathfinderUnmarshalBinaryDefaultSearch(t *testing.T) {
+	data, err := pathfind.NewPathfinderF(polygonUF).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: unexpected error: %v", err)
+	}
+	called := false
+	pathfinder := pathfind.NewPathfinderF(polygonUF, pathfind.WithEdgeCost(func(a, b geom.Vec2, cost float64) float64 {
+		called = true
+		return cost
+	}))
+	if err := pathfinder.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: unexpected error: %v", err)
+	}
+	if got := pathfinder.PathF(geom.V2(5, 5), geom.V2(25, 5)); len(got) != 4 {
+		t.Errorf("PathF after UnmarshalBinary = %v", got)
+	}
+	if called {
+		t.Errorf("UnmarshalBinary kept the edge cost of the search options")
+	}
+}
+
+func TestPathfinderUnmarshalBinaryErrors(t *testing.T) {
+	data, err := pathfind.NewPathfinderF(polygonUF).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: unexpected error: %v", err)
+	}
+	badVersion := bytes.Clone(data)
+	badVersion[4] = 99
+	badPolicy := bytes.Clone(data)
+	badPolicy[6] = 7
+	badBackend := bytes.Clone(data)
+	badBackend[7] = 9
+	mud := pathfind.Region{
+		Polygon: []geom.Vec2{geom.V2(10, 0), geom.V2(20, 0), geom.V2(20, 20), geom.V2(10, 20)},
+		Cost:    3,
+	}
+	regionData, err := pathfind.NewPathfinderF(polygonUF, pathfind.WithRegions(mud)).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: unexpected error: %v", err)
+	}
+	// The last edge cost precedes the empty list of triangles.
+	negativeCost := bytes.Clone(regionData)
+	binary.LittleEndian.PutUint64(negativeCost[len(negativeCost)-9:], math.Float64bits(-1))
+	nanCost := bytes.Clone(regionData)
+	binary.LittleEndian.PutUint64(nanCost[len(nanCost)-9:], math.Float64bits(math.NaN()))
+	infCost := bytes.Clone(regionData)
+	binary.LittleEndian.PutUint64(infCost[len(infCost)-9:], math.Float64bits(math.Inf(1)))
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"empty", nil, "pathfind: invalid binary data: missing magic number"},
+		{"magic", []byte("PNG\x00\x01\x00"), "pathfind: invalid binary data: missing magic number"},
+		{"version", badVersion, "pathfind: unsupported binary format version 99"},
+		{"policy", badPolicy, "pathfind: invalid binary data: start policy 7"},
+		{"backend", badBackend, "pathfind: invalid binary data: backend 9"},
+		{"truncated", data[:len(data)-1], "pathfind: invalid binary data: unexpected end of data"},
+		{"trailing", append(bytes.Clone(data), 0), "pathfind: invalid binary data: trailing data"},
+		{"negative edge cost", negativeCost, "pathfind: invalid binary data: edge cost -1"},
+		{"NaN edge cost", nanCost, "pathfind: invalid binary data: edge cost NaN"},
+		{"infinite edge cost", infCost, "pathfind: invalid binary data: edge cost +Inf"},
+		{"huge count", append([]byte("PFND\x01\x00\x00\x00\x00\x00\x00\x00\x00"), 0xff, 0xff, 0xff, 0xff, 0x0f), "pathfind: invalid binary data: count exceeds data length"},
+	}
+	for _, tt := range tests {
+		pathfinder := pathfind.NewPathfinderF(polygonUF)
+		err := pathfinder.UnmarshalBinary(tt.data)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s: UnmarshalBinary\n got error: %v\nwant error: %s", tt.name, err, tt.wantErr)
+			continue
+		}
+		// A failed UnmarshalBinary leaves the Pathfinder unchanged.
+		if got := pathfinder.PathF(geom.V2(5, 5), geom.V2(25, 5)); len(got) != 4 {
+			t.Errorf("%s: PathF after failed UnmarshalBinary = %v", tt.name, got)
+		}
+	}
+}
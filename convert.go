@@ -7,10 +7,28 @@ package pathfind
 import (
 	"image"
 	"math"
+	"slices"
 
 	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
 )
 
+// polygonSetFromPoints converts a slice of polygons with integer vertices to
+// a poly.PolygonSet.
+func polygonSetFromPoints(polygons [][]image.Point) poly.PolygonSet {
+	return convert(polygons, func(ps []image.Point) poly.Polygon {
+		return ps2vs(ps)
+	})
+}
+
+// polygonSetFromVecs converts a slice of polygons with floating-point
+// vertices to a poly.PolygonSet. The vertices are copied.
+func polygonSetFromVecs(polygons [][]geom.Vec2) poly.PolygonSet {
+	return convert(polygons, func(vs []geom.Vec2) poly.Polygon {
+		return slices.Clone(vs)
+	})
+}
+
 // ps2vs converts a []image.Point to a []geom.Vec2.
 func ps2vs(ps []image.Point) []geom.Vec2 {
 	return convert(ps, p2v)
@@ -0,0 +1,63 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dot writes visibility graphs of package pathfind in the DOT
+// language of Graphviz, e.g. for analysis with graphviz or networkx.
+package dot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/vgraph"
+)
+
+// WriteGraph writes a visibility graph, as returned by
+// pathfind.Pathfinder.VisibilityGraphF, as undirected DOT graph to w.
+//
+// The nodes are named n0, n1, ... in the order of their coordinates,
+// sorted by x and then by y. Their coordinates are written as "pos"
+// attribute with a "!" suffix, so that graphviz layout engines like
+// neato keep them in place, and additionally as "x" and "y" attributes.
+// The start and destination nodes of the path query, if they are part of
+// the graph, are marked with a "role" attribute of value "start" or
+// "dest" and drawn with a different shape.
+//
+// Each pair of linked nodes is written as one edge from the smaller to
+// the greater node with its Euclidean length as "weight" attribute. The
+// edges are sorted, so that the output is deterministic.
+func WriteGraph(w io.Writer, g map[geom.Vec2][]geom.Vec2, start, dest geom.Vec2) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "graph visibility {")
+	nodes := vgraph.Nodes(g)
+	ids := make(map[geom.Vec2]int, len(nodes))
+	for i, n := range nodes {
+		ids[n] = i
+		x, y := formatFloat(n.X), formatFloat(n.Y)
+		fmt.Fprintf(bw, "\tn%d [pos=\"%s,%s!\", x=%s, y=%s", i, x, y, x, y)
+		switch n {
+		case start:
+			fmt.Fprint(bw, ", role=start, shape=doublecircle")
+		case dest:
+			fmt.Fprint(bw, ", role=dest, shape=doublecircle")
+		}
+		fmt.Fprintln(bw, "];")
+	}
+	for _, e := range vgraph.Edges(g) {
+		fmt.Fprintf(bw, "\tn%d -- n%d [weight=%s];\n", ids[e[0]], ids[e[1]],
+			strconv.FormatFloat(vgraph.Length(e), 'f', -1, 64))
+	}
+	fmt.Fprintln(bw, "}")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("dot: %w", err)
+	}
+	return nil
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dot_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/dot"
+)
+
+// A U-shaped polygon.
+var polygonU = [][]geom.Vec2{{
+	geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10),
+	geom.V2(20, 10), geom.V2(20, 0), geom.V2(30, 0),
+	geom.V2(30, 20), geom.V2(0, 20),
+}}
+
+func TestWriteGraph(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF(polygonU)
+	start, dest := geom.V2(5, 5), geom.V2(25, 5)
+	pathfinder.PathF(start, dest)
+	var buf bytes.Buffer
+	if err := dot.WriteGraph(&buf, pathfinder.VisibilityGraphF(), start, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `graph visibility {
+	n0 [pos="5,5!", x=5, y=5, role=start, shape=doublecircle];
+	n1 [pos="10,10!", x=10, y=10];
+	n2 [pos="20,10!", x=20, y=10];
+	n3 [pos="25,5!", x=25, y=5, role=dest, shape=doublecircle];
+	n0 -- n1 [weight=7.0710678118654755];
+	n1 -- n2 [weight=10];
+	n2 -- n3 [weight=7.0710678118654755];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteGraph\n got: %s\nwant: %s", got, want)
+	}
+	// The output is deterministic.
+	var buf2 bytes.Buffer
+	if err := dot.WriteGraph(&buf2, pathfinder.VisibilityGraphF(), start, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != buf2.String() {
+		t.Errorf("WriteGraph output differs between calls")
+	}
+}
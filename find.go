@@ -0,0 +1,196 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"errors"
+	"math"
+
+	"github.com/fzipp/astar"
+	"github.com/fzipp/geom"
+)
+
+// Errors returned by Find.
+var (
+	// ErrUnreachable means that no path exists between the start point and
+	// the destination.
+	ErrUnreachable = errors.New("pathfind: destination unreachable")
+	// ErrStartOutside means that the start point is outside the polygon set
+	// and the Pathfinder was configured with the start policy RejectStart.
+	ErrStartOutside = errors.New("pathfind: start point outside polygon set")
+)
+
+// A StartPolicy determines how path queries handle a start point outside
+// the polygon set, e.g. after an agent was pushed into an obstacle.
+type StartPolicy int
+
+const (
+	// RejectStart makes path queries fail if the start point is outside.
+	RejectStart StartPolicy = iota
+	// SnapStart moves the start point to the closest point inside the
+	// polygon set. The path begins at the moved start point.
+	SnapStart
+	// ExitStart lets the path leave the obstacle by the shortest exit.
+	// The path begins at the original start point and leads straight to
+	// the closest point inside the polygon set, from where it continues.
+	ExitStart
+)
+
+// A Result describes the outcome of a path query by Find.
+type Result struct {
+	// Path is the shortest path from the start point to Dest, including
+	// both end points. If the start point equals Dest the path consists
+	// of this single point. Path is nil if no path exists.
+	// With the start policy ExitStart, a moved start point is preceded by
+	// the original start point.
+	Path []geom.Vec2
+	// Length is the total length of Path.
+	Length float64
+	// Cost is the total cost of Path. It equals Length unless the
+	// Pathfinder was configured with regions, see WithRegions, or with an
+	// edge cost or a turn cost, see WithEdgeCost and WithTurnCost.
+	Cost float64
+	// Start is the point inside the polygon set where the path search
+	// begins. It differs from the requested start point if StartMoved
+	// is true.
+	Start geom.Vec2
+	// StartMoved reports whether the requested start point was outside the
+	// polygon set and was moved to Start, the closest point inside,
+	// according to the start policy of the Pathfinder.
+	StartMoved bool
+	// Dest is the destination of the path. It differs from the requested
+	// destination if DestMoved is true.
+	Dest geom.Vec2
+	// DestMoved reports whether the requested destination was outside the
+	// polygon set and was moved to Dest, the closest point inside.
+	DestMoved bool
+}
+
+// Find finds the shortest path from start to dest like PathF, but
+// additionally reports the length of the path and whether the start point
+// or the destination had to be moved into the polygon set.
+// If the start point is outside the polygon set and the start policy of the
+// Pathfinder is RejectStart, it returns a Result without path and
+// ErrStartOutside. If no path exists, it returns a Result without path and
+// ErrUnreachable.
+func (p *Pathfinder) Find(start, dest geom.Vec2) (Result, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	res, err := p.startResult(start)
+	if err != nil {
+		res.Dest = dest
+		return res, err
+	}
+	res.Dest, res.DestMoved = p.destInside(dest)
+	path := p.path(res.Start, res.Dest)
+	if path == nil {
+		return res, ErrUnreachable
+	}
+	p.complete(&res, start, path)
+	return res, nil
+}
+
+// FindNearest finds the shortest path from start to whichever of the
+// destinations is closest by path length, in a single search. It returns
+// the Result for the path to the closest destination and the index of this
+// destination in dests. Destinations outside the polygon set are moved
+// inside like the destination of Find. If none of the destinations is
+// reachable, it returns a Result without path, -1 and ErrUnreachable.
+// The start point is handled like in Find.
+func (p *Pathfinder) FindNearest(start geom.Vec2, dests []geom.Vec2) (res Result, goal int, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	res, err = p.startResult(start)
+	if err != nil {
+		return res, -1, err
+	}
+	goals := make([]geom.Vec2, len(dests))
+	moved := make([]bool, len(dests))
+	for i, dest := range dests {
+		goals[i], moved[i] = p.destInside(dest)
+	}
+	path, goal := p.findNearest(res.Start, goals)
+	if path == nil {
+		return res, -1, ErrUnreachable
+	}
+	res.Dest, res.DestMoved = goals[goal], moved[goal]
+	p.complete(&res, start, path)
+	return res, goal, nil
+}
+
+// findNearest finds the path from start to the closest of the goals with
+// the backend of the Pathfinder and returns it with the index of the goal.
+func (p *Pathfinder) findNearest(start geom.Vec2, goals []geom.Vec2) ([]geom.Vec2, int) {
+	if p.mesh != nil {
+		return p.mesh.findNearest(start, goals)
+	}
+	vis := p.queryGraph(start, goals...)
+	p.lastGraph.Store(&vis)
+	h := func(n geom.Vec2) float64 {
+		closest := math.Inf(1)
+		for _, g := range goals {
+			closest = min(closest, p.heuristic(n, g))
+		}
+		return closest
+	}
+	return findNearest[geom.Vec2](vis, start, goals, p.costFunc(), p.search.turnCost, h)
+}
+
+// findAll finds the paths from start to each of the goals with the backend
+// of the Pathfinder in a single search. The path to an unreachable goal is
+// nil. The search options are not supported.
+func (p *Pathfinder) findAll(start geom.Vec2, goals []geom.Vec2) [][]geom.Vec2 {
+	if p.mesh != nil {
+		return p.mesh.findAll(start, goals)
+	}
+	vis := p.queryGraph(start, goals...)
+	return shortestPaths[geom.Vec2](vis, start, goals, p.costFunc())
+}
+
+// startResult returns a Result initialized with the start point of a path
+// query, which is moved into the polygon set according to the start policy.
+// It returns ErrStartOutside if the start policy rejects the start point.
+func (p *Pathfinder) startResult(start geom.Vec2) (Result, error) {
+	res := Result{Start: start}
+	if p.index.Contains(start) {
+		return res, nil
+	}
+	if p.startPolicy != SnapStart && p.startPolicy != ExitStart {
+		return res, ErrStartOutside
+	}
+	res.Start = p.moveInsideF(start)
+	res.StartMoved = true
+	return res, nil
+}
+
+// destInside returns dest, or the closest point inside the polygon set and
+// true if dest is outside.
+func (p *Pathfinder) destInside(dest geom.Vec2) (geom.Vec2, bool) {
+	if p.index.Contains(dest) {
+		return dest, false
+	}
+	return p.moveInsideF(dest), true
+}
+
+// complete sets the path of the Result, its length and its cost.
+// With the start policy ExitStart the original start point is prepended to
+// the path if it was moved.
+func (p *Pathfinder) complete(res *Result, start geom.Vec2, path []geom.Vec2) {
+	if res.StartMoved && p.startPolicy == ExitStart {
+		path = append([]geom.Vec2{start}, path...)
+	}
+	res.Path = path
+	res.Length = pathLength(path)
+	res.Cost = astar.Path[geom.Vec2](path).Cost(p.costFunc()) + p.turnCosts(path)
+}
+
+// pathLength returns the total Euclidean length of a path.
+func pathLength(path []geom.Vec2) float64 {
+	var l float64
+	for i := 1; i < len(path); i++ {
+		l += nodeDist(path[i-1], path[i])
+	}
+	return l
+}
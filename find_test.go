@@ -0,0 +1,286 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+func TestPathfinderFind(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   geom.Vec2
+		dest    geom.Vec2
+		want    pathfind.Result
+		wantErr error
+	}{
+		{
+			name:  "Two corners",
+			start: geom.V2(2.5, 2.5),
+			dest:  geom.V2(12.5, 2.5),
+			want: pathfind.Result{
+				Path: []geom.Vec2{
+					geom.V2(2.5, 2.5),
+					geom.V2(5.4375, 5.25),
+					geom.V2(10.4375, 5.25),
+					geom.V2(12.5, 2.5),
+				},
+				Length: math.Hypot(2.9375, 2.75) + 5 + 3.4375,
+				Cost:   math.Hypot(2.9375, 2.75) + 5 + 3.4375,
+				Start:  geom.V2(2.5, 2.5),
+				Dest:   geom.V2(12.5, 2.5),
+			},
+		},
+		{
+			name:  "Start equals destination",
+			start: geom.V2(2.5, 2.5),
+			dest:  geom.V2(2.5, 2.5),
+			want: pathfind.Result{
+				Path:  []geom.Vec2{geom.V2(2.5, 2.5)},
+				Start: geom.V2(2.5, 2.5),
+				Dest:  geom.V2(2.5, 2.5),
+			},
+		},
+		{
+			name:  "Destination moved",
+			start: geom.V2(2.5, 2.5),
+			dest:  geom.V2(7.5, 2.5),
+			want: pathfind.Result{
+				Path: []geom.Vec2{
+					geom.V2(2.5, 2.5),
+					geom.V2(5.4375, 2.5),
+				},
+				Length:    2.9375,
+				Cost:      2.9375,
+				Start:     geom.V2(2.5, 2.5),
+				Dest:      geom.V2(5.4375, 2.5),
+				DestMoved: true,
+			},
+		},
+		{
+			name:    "Start outside",
+			start:   geom.V2(7.5, 0),
+			dest:    geom.V2(7.5, 2.5),
+			want:    pathfind.Result{Start: geom.V2(7.5, 0), Dest: geom.V2(7.5, 2.5)},
+			wantErr: pathfind.ErrStartOutside,
+		},
+	}
+	pathfinder := pathfind.NewPathfinderF(polygonUF)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pathfinder.Find(tt.start, tt.dest)
+			if err != tt.wantErr {
+				t.Errorf("Find(%v, %v): got error %v, want %v", tt.start, tt.dest, err, tt.wantErr)
+			}
+			if !equalResults(got, tt.want) {
+				t.Errorf("Find(%v, %v)\n got: %+v\nwant: %+v", tt.start, tt.dest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathfinderFindUnreachable(t *testing.T) {
+	//  0,0 >---+ >---+
+	//      | s | | d |
+	// 0,10 +---+ +---+ 30,10
+	pathfinder := pathfind.NewPathfinderF([][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)},
+		{geom.V2(20, 0), geom.V2(30, 0), geom.V2(30, 10), geom.V2(20, 10)},
+	})
+	start, dest := geom.V2(5, 5), geom.V2(25, 5)
+	got, err := pathfinder.Find(start, dest)
+	if err != pathfind.ErrUnreachable {
+		t.Errorf("Find(%v, %v): got error %v, want %v", start, dest, err, pathfind.ErrUnreachable)
+	}
+	if got.Path != nil {
+		t.Errorf("Find(%v, %v): got path %v, want nil", start, dest, got.Path)
+	}
+}
+
+func TestPathfinderFindStartPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  pathfind.StartPolicy
+		want    pathfind.Result
+		wantErr error
+	}{
+		{
+			name:    "Reject",
+			policy:  pathfind.RejectStart,
+			want:    pathfind.Result{Start: geom.V2(7.5, 2.5), Dest: geom.V2(2.5, 7.5)},
+			wantErr: pathfind.ErrStartOutside,
+		},
+		{
+			name:   "Snap",
+			policy: pathfind.SnapStart,
+			want: pathfind.Result{
+				Path: []geom.Vec2{
+					geom.V2(5.4375, 2.5),
+					geom.V2(2.5, 7.5),
+				},
+				Length:     math.Hypot(2.9375, 5),
+				Cost:       math.Hypot(2.9375, 5),
+				Start:      geom.V2(5.4375, 2.5),
+				StartMoved: true,
+				Dest:       geom.V2(2.5, 7.5),
+			},
+		},
+		{
+			name:   "Exit",
+			policy: pathfind.ExitStart,
+			want: pathfind.Result{
+				Path: []geom.Vec2{
+					geom.V2(7.5, 2.5),
+					geom.V2(5.4375, 2.5),
+					geom.V2(2.5, 7.5),
+				},
+				Length:     2.0625 + math.Hypot(2.9375, 5),
+				Cost:       2.0625 + math.Hypot(2.9375, 5),
+				Start:      geom.V2(5.4375, 2.5),
+				StartMoved: true,
+				Dest:       geom.V2(2.5, 7.5),
+			},
+		},
+	}
+	start, dest := geom.V2(7.5, 2.5), geom.V2(2.5, 7.5)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathfinder := pathfind.NewPathfinderF(polygonUF, pathfind.WithStartPolicy(tt.policy))
+			got, err := pathfinder.Find(start, dest)
+			if err != tt.wantErr {
+				t.Errorf("Find(%v, %v): got error %v, want %v", start, dest, err, tt.wantErr)
+			}
+			if !equalResults(got, tt.want) {
+				t.Errorf("Find(%v, %v)\n got: %+v\nwant: %+v", start, dest, got, tt.want)
+			}
+		})
+	}
+}
+
+func equalResults(r1, r2 pathfind.Result) bool {
+	return reflect.DeepEqual(r1.Path, r2.Path) &&
+		r1.Start == r2.Start && r1.StartMoved == r2.StartMoved &&
+		r1.Dest == r2.Dest && r1.DestMoved == r2.DestMoved &&
+		nearEqFloat(r1.Length, r2.Length) && nearEqFloat(r1.Cost, r2.Cost)
+}
+
+func nearEqFloat(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+func TestPathfinderFindNearest(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF(polygonUF)
+	start := geom.V2(2.5, 2.5)
+	dests := []geom.Vec2{
+		// closer by Euclidean distance, but farther by path length
+		geom.V2(12.5, 2.5),
+		geom.V2(12.5, 9.5),
+		// outside and unreachable
+		geom.V2(40, 40),
+	}
+	got, goal, err := pathfinder.FindNearest(start, dests)
+	if err != nil {
+		t.Fatalf("FindNearest(%v, %v): unexpected error: %v", start, dests, err)
+	}
+	if goal != 1 {
+		t.Errorf("FindNearest(%v, %v): got goal %d, want 1", start, dests, goal)
+	}
+	want := pathfind.Result{
+		Path: []geom.Vec2{
+			geom.V2(2.5, 2.5),
+			geom.V2(5.4375, 5.25),
+			geom.V2(12.5, 9.5),
+		},
+		Length: math.Hypot(2.9375, 2.75) + math.Hypot(7.0625, 4.25),
+		Cost:   math.Hypot(2.9375, 2.75) + math.Hypot(7.0625, 4.25),
+		Start:  geom.V2(2.5, 2.5),
+		Dest:   geom.V2(12.5, 9.5),
+	}
+	if !equalResults(got, want) {
+		t.Errorf("FindNearest(%v, %v)\n got: %+v\nwant: %+v", start, dests, got, want)
+	}
+}
+
+func TestPathfinderFindNearestUnreachable(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF([][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)},
+		{geom.V2(20, 0), geom.V2(30, 0), geom.V2(30, 10), geom.V2(20, 10)},
+	})
+	start := geom.V2(5, 5)
+	dests := []geom.Vec2{geom.V2(25, 5), geom.V2(22, 8)}
+	got, goal, err := pathfinder.FindNearest(start, dests)
+	if err != pathfind.ErrUnreachable || goal != -1 || got.Path != nil {
+		t.Errorf("FindNearest(%v, %v) = %+v, %d, %v; want no path, -1, %v", start, dests, got, goal, err, pathfind.ErrUnreachable)
+	}
+}
+
+func TestPathfinderFindWithRegions(t *testing.T) {
+	//  0,0 >-------------------+ 40,0
+	//      |                   |
+	//      |    15,8 >---+     |
+	//      | s       |mud|   d |
+	//      |   15,30 +---+     |
+	// 0,40 +-------------------+ 40,40
+	square := [][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(40, 0), geom.V2(40, 40), geom.V2(0, 40)},
+	}
+	mud := pathfind.Region{
+		Polygon: []geom.Vec2{geom.V2(15, 8), geom.V2(25, 8), geom.V2(25, 30), geom.V2(15, 30)},
+		Cost:    3,
+	}
+	tests := []struct {
+		name   string
+		region pathfind.Region
+		want   pathfind.Result
+	}{
+		{
+			name:   "Around expensive region",
+			region: mud,
+			want: pathfind.Result{
+				Path: []geom.Vec2{
+					geom.V2(5, 20),
+					geom.V2(15, 30),
+					geom.V2(25, 30),
+					geom.V2(35, 20),
+				},
+				Length: 2*math.Hypot(10, 10) + 10,
+				Cost:   2*math.Hypot(10, 10) + 10,
+				Start:  geom.V2(5, 20),
+				Dest:   geom.V2(35, 20),
+			},
+		},
+		{
+			name:   "Through cheap region",
+			region: pathfind.Region{Polygon: mud.Polygon, Cost: 0.5},
+			want: pathfind.Result{
+				Path: []geom.Vec2{
+					geom.V2(5, 20),
+					geom.V2(35, 20),
+				},
+				Length: 30,
+				Cost:   10 + 5 + 10,
+				Start:  geom.V2(5, 20),
+				Dest:   geom.V2(35, 20),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathfinder := pathfind.NewPathfinderF(square, pathfind.WithRegions(tt.region))
+			got, err := pathfinder.Find(tt.want.Start, tt.want.Dest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equalResults(got, tt.want) {
+				t.Errorf("Find(%v, %v)\n got: %+v\nwant: %+v", tt.want.Start, tt.want.Dest, got, tt.want)
+			}
+		})
+	}
+}
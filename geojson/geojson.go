@@ -0,0 +1,220 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package geojson reads polygon sets for package pathfind from GeoJSON
+// (RFC 7946) and writes paths and visibility graphs as GeoJSON, so they can
+// be inspected with GIS tools.
+//
+// The x and y coordinates of pathfind correspond to the first and second
+// element of a GeoJSON position, e.g. longitude and latitude. Further
+// elements, like the altitude, are ignored. Like all coordinates in
+// pathfind, they are stored as float32 values.
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/internal/nest"
+	"github.com/fzipp/pathfind/internal/poly"
+	"github.com/fzipp/pathfind/internal/vgraph"
+)
+
+// ReadPolygons reads a GeoJSON object from r and returns the areas it
+// describes. The object can be a FeatureCollection, a Feature or a
+// geometry. Polygon and MultiPolygon geometries, also inside of
+// GeometryCollections, contribute areas; other geometries, like points or
+// line strings, are ignored.
+//
+// The exterior ring of a GeoJSON polygon becomes the outline of an area,
+// its interior rings become the holes of this area, so a hole may touch
+// the exterior ring. A polygon inside of a hole of another polygon, e.g.
+// as part of a MultiPolygon, becomes an island of the innermost such hole.
+// The closing position of each ring is removed, and rings are reoriented
+// to the winding order expected by pathfind.
+func ReadPolygons(r io.Reader) ([]pathfind.AreaF, error) {
+	var obj object
+	if err := json.NewDecoder(r).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("geojson: %w", err)
+	}
+	var areas []pathfind.AreaF
+	if err := obj.appendAreas(&areas); err != nil {
+		return nil, err
+	}
+	return nest.Islands(areas), nil
+}
+
+// NewPathfinder reads areas from GeoJSON via ReadPolygons and creates
+// a validated Pathfinder for them with pathfind.NewPathfinderFromAreasF.
+func NewPathfinder(r io.Reader, opts ...pathfind.Option) (*pathfind.Pathfinder, error) {
+	areas, err := ReadPolygons(r)
+	if err != nil {
+		return nil, err
+	}
+	return pathfind.NewPathfinderFromAreasF(areas, opts...)
+}
+
+// WritePath writes a path as a GeoJSON Feature with a LineString geometry
+// to w. The "length" property of the feature is the length of the path.
+func WritePath(w io.Writer, path []geom.Vec2) error {
+	var length float64
+	for i := 1; i < len(path); i++ {
+		length += vgraph.Length([2]geom.Vec2{path[i-1], path[i]})
+	}
+	return write(w, feature{
+		Type:       "Feature",
+		Geometry:   lineString(path),
+		Properties: map[string]any{"length": length},
+	})
+}
+
+// WriteGraph writes a visibility graph, as returned by
+// pathfind.Pathfinder.VisibilityGraphF, as a GeoJSON FeatureCollection to w.
+// Each pair of linked nodes is written as one Feature with a LineString
+// geometry from the smaller to the greater node, ordered by x and then
+// by y coordinate. The "length" property of a feature is the length of
+// the edge. The features are sorted, so that the output is deterministic.
+func WriteGraph(w io.Writer, g map[geom.Vec2][]geom.Vec2) error {
+	edges := vgraph.Edges(g)
+	fc := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	for _, e := range edges {
+		fc.Features = append(fc.Features, feature{
+			Type:       "Feature",
+			Geometry:   lineString(e[:]),
+			Properties: map[string]any{"length": vgraph.Length(e)},
+		})
+	}
+	return write(w, fc)
+}
+
+func write(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("geojson: %w", err)
+	}
+	return nil
+}
+
+// object is a GeoJSON object of any type. Only the members needed for
+// reading polygons are decoded.
+type object struct {
+	Type        string          `json:"type"`
+	Features    []object        `json:"features"`
+	Geometry    *object         `json:"geometry"`
+	Geometries  []object        `json:"geometries"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func (o *object) appendAreas(areas *[]pathfind.AreaF) error {
+	switch o.Type {
+	case "FeatureCollection":
+		for _, f := range o.Features {
+			if err := f.appendAreas(areas); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if o.Geometry != nil {
+			return o.Geometry.appendAreas(areas)
+		}
+	case "GeometryCollection":
+		for _, g := range o.Geometries {
+			if err := g.appendAreas(areas); err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(o.Coordinates, &rings); err != nil {
+			return fmt.Errorf("geojson: invalid Polygon coordinates: %w", err)
+		}
+		return appendArea(areas, rings)
+	case "MultiPolygon":
+		var multi [][][][]float64
+		if err := json.Unmarshal(o.Coordinates, &multi); err != nil {
+			return fmt.Errorf("geojson: invalid MultiPolygon coordinates: %w", err)
+		}
+		for _, rings := range multi {
+			if err := appendArea(areas, rings); err != nil {
+				return err
+			}
+		}
+	case "Point", "MultiPoint", "LineString", "MultiLineString":
+		// ignored
+	default:
+		return fmt.Errorf("geojson: unknown type %q", o.Type)
+	}
+	return nil
+}
+
+// appendArea appends a GeoJSON polygon as an area with the exterior ring
+// as outline and the interior rings as holes. A polygon without rings is
+// empty and ignored.
+func appendArea(areas *[]pathfind.AreaF, rings [][][]float64) error {
+	if len(rings) == 0 {
+		return nil
+	}
+	outline, err := ringPolygon(rings[0])
+	if err != nil {
+		return err
+	}
+	a := pathfind.AreaF{Outline: outline}
+	for _, ring := range rings[1:] {
+		p, err := ringPolygon(ring)
+		if err != nil {
+			return err
+		}
+		a.Holes = append(a.Holes, pathfind.HoleF{Outline: p})
+	}
+	*areas = append(*areas, a)
+	return nil
+}
+
+// ringPolygon converts a closed GeoJSON linear ring to a polygon with
+// positive signed area.
+func ringPolygon(ring [][]float64) ([]geom.Vec2, error) {
+	if len(ring) < 4 {
+		return nil, fmt.Errorf("geojson: linear ring with %d positions, want at least 4", len(ring))
+	}
+	p := make(poly.Polygon, len(ring))
+	for i, pos := range ring {
+		if len(pos) < 2 {
+			return nil, fmt.Errorf("geojson: position with %d elements, want at least 2", len(pos))
+		}
+		p[i] = geom.V2(float32(pos[0]), float32(pos[1]))
+	}
+	if p[0] != p[len(p)-1] {
+		return nil, fmt.Errorf("geojson: linear ring is not closed: first position %v, last position %v", p[0], p[len(p)-1])
+	}
+	p = p[:len(p)-1]
+	p.Normalize()
+	return p, nil
+}
+
+type feature struct {
+	Type       string         `json:"type"`
+	Geometry   geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type geometry struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+func lineString(vs []geom.Vec2) geometry {
+	coords := make([][]float64, len(vs))
+	for i, v := range vs {
+		coords[i] = []float64{float64(v.X), float64(v.Y)}
+	}
+	return geometry{Type: "LineString", Coordinates: coords}
+}
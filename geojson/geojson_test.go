@@ -0,0 +1,179 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geojson_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/geojson"
+)
+
+// A square with a square hole, with a counterclockwise exterior ring and
+// a clockwise interior ring as recommended by RFC 7946, and a point
+// feature that is ignored.
+const squareWithHole = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "properties": {"name": "hall"},
+      "geometry": {
+        "type": "Polygon",
+        "coordinates": [
+          [[0, 0], [40, 0], [40, 40], [0, 40], [0, 0]],
+          [[10, 10], [10, 30], [30, 30], [30, 10], [10, 10]]
+        ]
+      }
+    },
+    {
+      "type": "Feature",
+      "properties": {"name": "label"},
+      "geometry": {"type": "Point", "coordinates": [5, 5]}
+    }
+  ]
+}`
+
+func TestReadPolygons(t *testing.T) {
+	got, err := geojson.ReadPolygons(strings.NewReader(squareWithHole))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []pathfind.AreaF{{
+		Outline: []geom.Vec2{geom.V2(0, 0), geom.V2(40, 0), geom.V2(40, 40), geom.V2(0, 40)},
+		Holes: []pathfind.HoleF{{
+			Outline: []geom.Vec2{geom.V2(30, 10), geom.V2(30, 30), geom.V2(10, 30), geom.V2(10, 10)},
+		}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadPolygons\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestReadPolygonsMultiPolygon(t *testing.T) {
+	const input = `{"type": "MultiPolygon", "coordinates": [
+		[[[0, 0], [10, 0], [10, 10], [0, 0]]],
+		[[[20, 0], [30, 0], [30, 10], [20, 0]]],
+		[[[40, 0], [60, 0], [60, 20], [40, 20], [40, 0]], [[45, 5], [55, 5], [55, 15], [45, 15], [45, 5]]],
+		[[[47, 7], [53, 7], [53, 13], [47, 13], [47, 7]]]
+	]}`
+	got, err := geojson.ReadPolygons(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []pathfind.AreaF{
+		{Outline: []geom.Vec2{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10)}},
+		{Outline: []geom.Vec2{geom.V2(20, 0), geom.V2(30, 0), geom.V2(30, 10)}},
+		{
+			Outline: []geom.Vec2{geom.V2(40, 0), geom.V2(60, 0), geom.V2(60, 20), geom.V2(40, 20)},
+			Holes: []pathfind.HoleF{{
+				Outline: []geom.Vec2{geom.V2(45, 5), geom.V2(55, 5), geom.V2(55, 15), geom.V2(45, 15)},
+				Islands: []pathfind.AreaF{
+					{Outline: []geom.Vec2{geom.V2(47, 7), geom.V2(53, 7), geom.V2(53, 13), geom.V2(47, 13)}},
+				},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadPolygons\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestReadPolygonsErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{`{"type": "Polygon", "coordinates": [[[0, 0], [10, 0], [0, 0]]]}`, "geojson: linear ring with 3 positions, want at least 4"},
+		{`{"type": "Polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10]]]}`, "geojson: linear ring is not closed: first position (0, 0), last position (0, 10)"},
+		{`{"type": "Polygon", "coordinates": [[[0, 0], [10], [10, 10], [0, 0]]]}`, "geojson: position with 1 elements, want at least 2"},
+		{`{"type": "Circle"}`, `geojson: unknown type "Circle"`},
+		{`{"type": `, "geojson: unexpected EOF"},
+	}
+	for _, tt := range tests {
+		_, err := geojson.ReadPolygons(strings.NewReader(tt.input))
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("ReadPolygons(%s)\n got error: %v\nwant error: %s", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewPathfinder(t *testing.T) {
+	pathfinder, err := geojson.NewPathfinder(strings.NewReader(squareWithHole))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := pathfinder.PathF(geom.V2(5, 15), geom.V2(35, 15))
+	want := []geom.Vec2{geom.V2(5, 15), geom.V2(10, 10), geom.V2(30, 10), geom.V2(35, 15)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathF\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestNewPathfinderTouchingHole(t *testing.T) {
+	// The first vertex of the hole lies on the exterior ring.
+	const input = `{"type": "Polygon", "coordinates": [
+		[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+		[[0, 5], [6, 4], [6, 6], [0, 5]]
+	]}`
+	pathfinder, err := geojson.NewPathfinder(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantHierarchy := []pathfind.PolygonNode{
+		{Parent: -1, Children: []int{1}, Depth: 0},
+		{Parent: 0, Children: nil, Depth: 1},
+	}
+	if got := pathfinder.Hierarchy(); !reflect.DeepEqual(got, wantHierarchy) {
+		t.Errorf("Hierarchy()\n got: %+v\nwant: %+v", got, wantHierarchy)
+	}
+	got := pathfinder.PathF(geom.V2(4, 2), geom.V2(4, 8))
+	want := []geom.Vec2{geom.V2(4, 2), geom.V2(6, 4), geom.V2(6, 6), geom.V2(4, 8)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathF\n got: %v\nwant: %v", got, want)
+	}
+	// The path doesn't pass through the point where the hole touches the
+	// exterior ring.
+	got = pathfinder.PathF(geom.V2(1, 2), geom.V2(1, 8))
+	want = []geom.Vec2{geom.V2(1, 2), geom.V2(6, 4), geom.V2(6, 6), geom.V2(1, 8)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathF\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestWritePath(t *testing.T) {
+	var buf bytes.Buffer
+	err := geojson.WritePath(&buf, []geom.Vec2{geom.V2(0, 0), geom.V2(3, 4), geom.V2(3, 5.5)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"type":"Feature","geometry":{"type":"LineString","coordinates":[[0,0],[3,4],[3,5.5]]},"properties":{"length":6.5}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WritePath\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestWriteGraph(t *testing.T) {
+	g := map[geom.Vec2][]geom.Vec2{
+		geom.V2(3, 4): {geom.V2(0, 0), geom.V2(6, 0)},
+		geom.V2(0, 0): {geom.V2(3, 4)},
+		geom.V2(6, 0): {geom.V2(3, 4)},
+	}
+	var buf bytes.Buffer
+	if err := geojson.WriteGraph(&buf, g); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","geometry":{"type":"LineString","coordinates":[[0,0],[3,4]]},"properties":{"length":5}},` +
+		`{"type":"Feature","geometry":{"type":"LineString","coordinates":[[3,4],[6,0]]},"properties":{"length":5}}` +
+		`]}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteGraph\n got: %s\nwant: %s", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"slices"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// The geometric queries of the Pathfinder use the same polygon operations
+// as the path search, so they agree with it on all edge cases. If the
+// Pathfinder was configured with a radius, they refer to the polygon set
+// offset by this radius, i.e. to the possible positions of the center of
+// an agent with this radius.
+
+// Polygons returns a copy of the polygon set the Pathfinder searches paths
+// in. If the Pathfinder was configured with a radius, these are the offset
+// polygons.
+func (p *Pathfinder) Polygons() [][]geom.Vec2 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return convert(p.polygonSet, func(q poly.Polygon) []geom.Vec2 {
+		return slices.Clone(q)
+	})
+}
+
+// ConcaveVertices returns the concave vertices of the accessible area of
+// the polygon set, i.e. the concave vertices of the area polygons and the
+// convex vertices of the holes. Shortest paths can only bend at these
+// vertices, so they are the nodes of the visibility graph.
+func (p *Pathfinder) ConcaveVertices() []geom.Vec2 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return concaveVertices(p.polygonSet, p.nesting)
+}
+
+// Contains reports whether point pt is inside the accessible area of the
+// polygon set. Points on a polygon edge count as inside.
+func (p *Pathfinder) Contains(pt geom.Vec2) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.index.Contains(pt)
+}
+
+// ClosestPt returns the point inside the accessible area of the polygon set
+// that is closest to point pt. This is pt itself if it is inside, otherwise
+// a point on the closest polygon edge. It is the same point path queries
+// move a destination outside the polygon set to.
+func (p *Pathfinder) ClosestPt(pt geom.Vec2) geom.Vec2 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	pt, _ = p.destInside(pt)
+	return pt
+}
+
+// InLineOfSight reports whether point b is visible from point a, i.e.
+// whether the straight line segment between them lies entirely inside the
+// accessible area of the polygon set. A line segment that only touches a
+// polygon vertex or runs along a polygon edge does not block the sight.
+func (p *Pathfinder) InLineOfSight(a, b geom.Vec2) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return inLineOfSight(p.index, a, b)
+}
+
+// NormalizeWinding returns a copy of the polygon set in which all polygons
+// have the same winding order, the one with positive signed area. The
+// Pathfinder accepts polygons in either winding order, because holes are
+// determined by nesting, but a canonical orientation simplifies comparing
+// and exporting polygon sets.
+func NormalizeWinding(polygons [][]geom.Vec2) [][]geom.Vec2 {
+	return convert(polygons, func(p []geom.Vec2) []geom.Vec2 {
+		q := poly.Polygon(slices.Clone(p))
+		q.Normalize()
+		return q
+	})
+}
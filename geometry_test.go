@@ -0,0 +1,128 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"image"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+func TestPathfinderPolygons(t *testing.T) {
+	got := pathfind.NewPathfinderF(polygonUF).Polygons()
+	if !reflect.DeepEqual(got, polygonUF) {
+		t.Errorf("Polygons()\n got: %v\nwant: %v", got, polygonUF)
+	}
+	got[0][0] = geom.V2(-1, -1)
+	if polygonUF[0][0] == got[0][0] {
+		t.Errorf("Polygons() returned polygons sharing memory with the input")
+	}
+}
+
+func TestPathfinderConcaveVertices(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygons [][]image.Point
+		want     []geom.Vec2
+	}{
+		{"U", polygonU, []geom.Vec2{geom.V2(10, 10), geom.V2(20, 10)}},
+		{"O", polygonO, []geom.Vec2{geom.V2(20, 10), geom.V2(30, 20), geom.V2(20, 30), geom.V2(10, 20)}},
+		{"U reversed", reversed(polygonU, 0), []geom.Vec2{geom.V2(20, 10), geom.V2(10, 10)}},
+		{"O hole reversed", reversed(polygonO, 1), []geom.Vec2{geom.V2(10, 20), geom.V2(20, 30), geom.V2(30, 20), geom.V2(20, 10)}},
+		{"O both reversed", reversed(polygonO, 0, 1), []geom.Vec2{geom.V2(10, 20), geom.V2(20, 30), geom.V2(30, 20), geom.V2(20, 10)}},
+	}
+	for _, tt := range tests {
+		got := pathfind.NewPathfinder(tt.polygons).ConcaveVertices()
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ConcaveVertices()\n got: %v\nwant: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// reversed returns a copy of the polygons with the polygons at the given
+// indices in reversed winding order.
+func reversed(polygons [][]image.Point, indices ...int) [][]image.Point {
+	res := make([][]image.Point, len(polygons))
+	for i, p := range polygons {
+		res[i] = slices.Clone(p)
+		if slices.Contains(indices, i) {
+			slices.Reverse(res[i])
+		}
+	}
+	return res
+}
+
+func TestNormalizeWinding(t *testing.T) {
+	polygons := vecPolygons(reversed(polygonO, 1))
+	got := pathfind.NormalizeWinding(polygons)
+	want := vecPolygons(polygonO)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeWinding(%v)\n got: %v\nwant: %v", polygons, got, want)
+	}
+	if !reflect.DeepEqual(polygons, vecPolygons(reversed(polygonO, 1))) {
+		t.Errorf("NormalizeWinding modified its input")
+	}
+}
+
+func TestPathfinderContains(t *testing.T) {
+	pathfinder := pathfind.NewPathfinder(polygonO)
+	tests := []struct {
+		pt   geom.Vec2
+		want bool
+	}{
+		{geom.V2(5, 5), true},
+		{geom.V2(0, 20), true},
+		{geom.V2(20, 20), false},
+		{geom.V2(30, 20), true},
+		{geom.V2(45, 20), false},
+	}
+	for _, tt := range tests {
+		if got := pathfinder.Contains(tt.pt); got != tt.want {
+			t.Errorf("Contains(%v) = %v, want %v", tt.pt, got, tt.want)
+		}
+	}
+}
+
+func TestPathfinderClosestPt(t *testing.T) {
+	pathfinder := pathfind.NewPathfinder(polygonO)
+	tests := []struct {
+		pt   geom.Vec2
+		want geom.Vec2
+	}{
+		{geom.V2(5, 5), geom.V2(5, 5)},
+		{geom.V2(45, 20), geom.V2(40, 20)},
+		{geom.V2(-5, -5), geom.V2(0, 0)},
+		{geom.V2(20, 12), geom.V2(21, 11)},
+	}
+	for _, tt := range tests {
+		if got := pathfinder.ClosestPt(tt.pt); got != tt.want {
+			t.Errorf("ClosestPt(%v) = %v, want %v", tt.pt, got, tt.want)
+		}
+	}
+}
+
+func TestPathfinderInLineOfSight(t *testing.T) {
+	pathfinder := pathfind.NewPathfinder(polygonO)
+	tests := []struct {
+		a, b geom.Vec2
+		want bool
+	}{
+		{geom.V2(5, 5), geom.V2(35, 5), true},
+		{geom.V2(5, 20), geom.V2(35, 20), false},
+		{geom.V2(10, 10), geom.V2(30, 10), true},
+		{geom.V2(20, 10), geom.V2(30, 20), true},
+		{geom.V2(5, 5), geom.V2(45, 5), false},
+		{geom.V2(5, 5), geom.V2(5, 5), true},
+	}
+	for _, tt := range tests {
+		if got := pathfinder.InLineOfSight(tt.a, tt.b); got != tt.want {
+			t.Errorf("InLineOfSight(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
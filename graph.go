@@ -4,6 +4,8 @@
 
 package pathfind
 
+import "slices"
+
 // graph is represented by an adjacency list.
 type graph[Node comparable] map[Node][]Node
 
@@ -18,3 +20,34 @@ func (g graph[Node]) link(a, b Node) graph[Node] {
 func (g graph[Node]) Neighbours(n Node) []Node {
 	return g[n]
 }
+
+// overlay is a graph that extends a base graph with additional edges
+// without modifying the base graph.
+type overlay[Node comparable] struct {
+	base  graph[Node]
+	extra graph[Node]
+}
+
+// Neighbours returns the neighbour nodes of node n in the base graph,
+// followed by the neighbour nodes of n in the extra graph.
+// This method makes overlay[Node] implement the astar.Graph[Node] interface.
+func (g overlay[Node]) Neighbours(n Node) []Node {
+	extra := g.extra[n]
+	if len(extra) == 0 {
+		return g.base[n]
+	}
+	return append(slices.Clip(g.base[n]), extra...)
+}
+
+// merged returns a new graph containing the edges of both the base graph
+// and the extra graph.
+func (g overlay[Node]) merged() graph[Node] {
+	m := make(graph[Node], len(g.base)+len(g.extra))
+	for n := range g.base {
+		m[n] = slices.Clone(g.Neighbours(n))
+	}
+	for n := range g.extra {
+		m[n] = slices.Clone(g.Neighbours(n))
+	}
+	return m
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graphml writes visibility graphs of package pathfind in the
+// GraphML format, e.g. for analysis with Gephi or networkx.
+package graphml
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/vgraph"
+)
+
+const header = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns"
+    xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+    xsi:schemaLocation="http://graphml.graphdrawing.org/xmlns http://graphml.graphdrawing.org/xmlns/1.0/graphml.xsd">
+  <key id="x" for="node" attr.name="x" attr.type="float"/>
+  <key id="y" for="node" attr.name="y" attr.type="float"/>
+  <key id="role" for="node" attr.name="role" attr.type="string"/>
+  <key id="weight" for="edge" attr.name="weight" attr.type="double"/>
+  <graph id="visibility" edgedefault="undirected">
+`
+
+const footer = `  </graph>
+</graphml>
+`
+
+// WriteGraph writes a visibility graph, as returned by
+// pathfind.Pathfinder.VisibilityGraphF, as undirected GraphML graph to w.
+//
+// The nodes have the ids n0, n1, ... in the order of their coordinates,
+// sorted by x and then by y. Their coordinates are the "x" and "y" data
+// of a node, which Gephi uses as node position. The start and
+// destination nodes of the path query, if they are part of the graph,
+// have the "role" data "start" or "dest".
+//
+// Each pair of linked nodes is written as one edge from the smaller to
+// the greater node with its Euclidean length as "weight" data. The edges
+// are sorted, so that the output is deterministic.
+func WriteGraph(w io.Writer, g map[geom.Vec2][]geom.Vec2, start, dest geom.Vec2) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(header)
+	nodes := vgraph.Nodes(g)
+	ids := make(map[geom.Vec2]int, len(nodes))
+	for i, n := range nodes {
+		ids[n] = i
+		fmt.Fprintf(bw, "    <node id=\"n%d\">\n", i)
+		fmt.Fprintf(bw, "      <data key=\"x\">%s</data>\n", formatFloat(n.X))
+		fmt.Fprintf(bw, "      <data key=\"y\">%s</data>\n", formatFloat(n.Y))
+		switch n {
+		case start:
+			fmt.Fprintln(bw, `      <data key="role">start</data>`)
+		case dest:
+			fmt.Fprintln(bw, `      <data key="role">dest</data>`)
+		}
+		fmt.Fprintln(bw, "    </node>")
+	}
+	for _, e := range vgraph.Edges(g) {
+		fmt.Fprintf(bw, "    <edge source=\"n%d\" target=\"n%d\">\n", ids[e[0]], ids[e[1]])
+		fmt.Fprintf(bw, "      <data key=\"weight\">%s</data>\n",
+			strconv.FormatFloat(vgraph.Length(e), 'f', -1, 64))
+		fmt.Fprintln(bw, "    </edge>")
+	}
+	bw.WriteString(footer)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("graphml: %w", err)
+	}
+	return nil
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphml_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/graphml"
+)
+
+func TestWriteGraph(t *testing.T) {
+	g := map[geom.Vec2][]geom.Vec2{
+		geom.V2(5, 5):     {geom.V2(10, 10)},
+		geom.V2(10, 10):   {geom.V2(5, 5), geom.V2(20, 10.5)},
+		geom.V2(20, 10.5): {geom.V2(10, 10)},
+	}
+	var buf bytes.Buffer
+	if err := graphml.WriteGraph(&buf, g, geom.V2(5, 5), geom.V2(20, 10.5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns"
+    xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+    xsi:schemaLocation="http://graphml.graphdrawing.org/xmlns http://graphml.graphdrawing.org/xmlns/1.0/graphml.xsd">
+  <key id="x" for="node" attr.name="x" attr.type="float"/>
+  <key id="y" for="node" attr.name="y" attr.type="float"/>
+  <key id="role" for="node" attr.name="role" attr.type="string"/>
+  <key id="weight" for="edge" attr.name="weight" attr.type="double"/>
+  <graph id="visibility" edgedefault="undirected">
+    <node id="n0">
+      <data key="x">5</data>
+      <data key="y">5</data>
+      <data key="role">start</data>
+    </node>
+    <node id="n1">
+      <data key="x">10</data>
+      <data key="y">10</data>
+    </node>
+    <node id="n2">
+      <data key="x">20</data>
+      <data key="y">10.5</data>
+      <data key="role">dest</data>
+    </node>
+    <edge source="n0" target="n1">
+      <data key="weight">7.0710678118654755</data>
+    </edge>
+    <edge source="n1" target="n2">
+      <data key="weight">10.012492197250394</data>
+    </edge>
+  </graph>
+</graphml>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteGraph\n got: %s\nwant: %s", got, want)
+	}
+	// The output is well-formed XML.
+	dec := xml.NewDecoder(&buf)
+	for {
+		_, err := dec.Token()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("invalid XML: %v", err)
+			}
+			break
+		}
+	}
+}
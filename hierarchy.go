@@ -0,0 +1,458 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"cmp"
+	"image"
+	"math"
+	"slices"
+
+	"github.com/fzipp/astar"
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// entranceDivisions is the number of parts into which the entrances divide
+// a cluster border of the length of the cluster size.
+const entranceDivisions = 8
+
+// A HierarchicalPathfinder finds paths in very large polygon sets, for which
+// the visibility graph of a Pathfinder becomes too expensive to calculate
+// and to search.
+//
+// It partitions the accessible area into clusters, the parts of the area
+// within the cells of a square grid, and uses a separate Pathfinder for
+// each cluster. Paths cross the borders between adjacent clusters at
+// entrances, which are placed at the ends of each shared border and at most
+// s = clusterSize/8 apart along it. The paths between the entrances of each
+// cluster are precomputed. A path query only searches paths within the
+// clusters of the start point and the destination, to connect them to the
+// entrances of their clusters, and then searches the graph of entrances.
+// Finally, waypoints are skipped where the line of sight allows it.
+//
+// Since the path crosses cluster borders only at entrances, it is not
+// necessarily the shortest path. If the shortest path crosses cluster
+// borders n times, the path found is at most n·s longer. With regions,
+// this bound applies to the cost, with s multiplied by the highest cost
+// factor.
+//
+// A HierarchicalPathfinder is safe for concurrent use by multiple
+// goroutines.
+type HierarchicalPathfinder struct {
+	base       *Pathfinder // the whole polygon set, without visibility graph
+	origin     geom.Vec2   // the lower corner of the grid
+	cellSize   float32
+	cols, rows int
+	clusters   []*cluster // by cell, row by row; nil for cells without accessible area
+	graph      graph[geom.Vec2]
+	routes     map[[2]geom.Vec2]route // the paths of the graph edges
+}
+
+// cluster is the part of the accessible area within a cell of the grid of
+// a HierarchicalPathfinder.
+type cluster struct {
+	pf        *Pathfinder
+	entrances []geom.Vec2
+}
+
+// route is a path within a cluster and its cost.
+type route struct {
+	path []geom.Vec2
+	cost float64
+}
+
+// NewHierarchicalPathfinder creates a HierarchicalPathfinder for a set of
+// polygons, which is interpreted like by NewPathfinder, with clusters of
+// clusterSize×clusterSize units. If clusterSize is not positive, the whole
+// polygon set forms a single cluster.
+//
+// The options are applied as follows: the radius is applied to the whole
+// polygon set, the start policy to the path queries, and the regions and
+// the backend to the Pathfinders of the clusters. The search options, see
+// WithSearch, are ignored.
+func NewHierarchicalPathfinder(polygons [][]image.Point, clusterSize int, opts ...Option) *HierarchicalPathfinder {
+	return newHierarchicalPathfinder(polygonSetFromPoints(polygons), float32(clusterSize), newConfig(opts))
+}
+
+// NewHierarchicalPathfinderF is like NewHierarchicalPathfinder, but the
+// polygon vertices and the cluster size are given as floating-point values.
+func NewHierarchicalPathfinderF(polygons [][]geom.Vec2, clusterSize float32, opts ...Option) *HierarchicalPathfinder {
+	return newHierarchicalPathfinder(polygonSetFromVecs(polygons), clusterSize, newConfig(opts))
+}
+
+func newHierarchicalPathfinder(polygonSet poly.PolygonSet, clusterSize float32, c config) *HierarchicalPathfinder {
+	nest := nestingOf(polygonSet)
+	if c.radius > 0 {
+		polygonSet, nest, _ = offsetPolygons(polygonSet, nest, c.radius, c.join)
+	}
+	regions := newRegions(c.regions)
+	h := &HierarchicalPathfinder{
+		base: &Pathfinder{
+			polygonSet:  polygonSet,
+			nesting:     nest,
+			index:       poly.NewIndex(polygonSet),
+			staticGraph: make(graph[geom.Vec2]),
+			startPolicy: c.startPolicy,
+			radius:      c.radius,
+			join:        c.join,
+			regions:     regions,
+			minCost:     minCost(regions),
+		},
+		graph:  make(graph[geom.Vec2]),
+		routes: make(map[[2]geom.Vec2]route),
+	}
+	tris := polygonSet.TriangulateNested(nest, nest.depths())
+	if len(tris) == 0 {
+		return h
+	}
+	var pts []geom.Vec2
+	for _, tri := range tris {
+		pts = append(pts, tri[:]...)
+	}
+	b := bounds(pts...)
+	if !(clusterSize > 0) || !isFinite(clusterSize) {
+		clusterSize = max(b.max.X-b.min.X, b.max.Y-b.min.Y, 1)
+	}
+	h.origin, h.cellSize = b.min, clusterSize
+	h.cols, h.rows = 1, 1
+	for h.border(0, h.cols) < b.max.X {
+		h.cols++
+	}
+	for h.border(1, h.rows) < b.max.Y {
+		h.rows++
+	}
+
+	pieces := make([][]poly.Polygon, h.cols*h.rows)
+	for _, tri := range tris {
+		tb := bounds(tri[:]...)
+		// Rounding can put a point on a cell border into either cell.
+		i0, j0 := h.cell(tb.min)
+		i1, j1 := h.cell(tb.max)
+		for j := max(j0-1, 0); j <= min(j1+1, h.rows-1); j++ {
+			for i := max(i0-1, 0); i <= min(i1+1, h.cols-1); i++ {
+				lo := geom.V2(h.border(0, i), h.border(1, j))
+				hi := geom.V2(h.border(0, i+1), h.border(1, j+1))
+				if piece := poly.Polygon(tri[:]).ClipRect(lo, hi); piece != nil {
+					pieces[j*h.cols+i] = append(pieces[j*h.cols+i], piece)
+				}
+			}
+		}
+	}
+	clusterConfig := config{startPolicy: SnapStart, regions: c.regions, backend: c.backend}
+	h.clusters = make([]*cluster, len(pieces))
+	for k, ps := range pieces {
+		if len(ps) > 0 {
+			h.clusters[k] = &cluster{pf: newPathfinder(poly.Outline(ps), nil, clusterConfig)}
+		}
+	}
+	h.addEntrances(pieces, clusterSize/entranceDivisions)
+	for _, cl := range h.clusters {
+		if cl != nil {
+			h.linkEntrances(cl)
+		}
+	}
+	return h
+}
+
+// border returns the coordinate of the cell border with index i along the
+// given axis (0 for x, 1 for y). Cell i lies between borders i and i+1.
+func (h *HierarchicalPathfinder) border(axis, i int) float32 {
+	return coord(h.origin, axis) + float32(i)*h.cellSize
+}
+
+// cell returns the column and row of the grid cell that contains point pt,
+// clamped to the grid.
+func (h *HierarchicalPathfinder) cell(pt geom.Vec2) (i, j int) {
+	i = int(math.Floor(float64((pt.X - h.origin.X) / h.cellSize)))
+	j = int(math.Floor(float64((pt.Y - h.origin.Y) / h.cellSize)))
+	return min(max(i, 0), h.cols-1), min(max(j, 0), h.rows-1)
+}
+
+// coord returns the coordinate of point pt along the given axis (0 for x,
+// 1 for y).
+func coord(pt geom.Vec2, axis int) float32 {
+	if axis == 0 {
+		return pt.X
+	}
+	return pt.Y
+}
+
+// addEntrances places the entrances on the borders between the clusters.
+// Two clusters share the edges of their pieces that occur in both
+// directions. Connected shared edges on the same border form a passage,
+// which gets entrances at both ends and at most spacing apart in between.
+func (h *HierarchicalPathfinder) addEntrances(pieces [][]poly.Polygon, spacing float32) {
+	owner := make(map[poly.LineSeg]int)
+	for k, ps := range pieces {
+		for _, p := range ps {
+			for i := range p {
+				owner[p.Edge(i)] = k
+			}
+		}
+	}
+	type border struct {
+		k1, k2 int // the clusters on both sides, k1 < k2
+		axis   int // the axis of the constant coordinate
+		value  float32
+	}
+	type span struct {
+		lo, hi float32
+	}
+	passages := make(map[border][]span)
+	var borders []border
+	for k, ps := range pieces {
+		for _, p := range ps {
+			for i := range p {
+				e := p.Edge(i)
+				other, ok := owner[poly.LineSeg{A: e.B, B: e.A}]
+				if !ok || other <= k {
+					continue
+				}
+				axis := 0
+				if e.A.Y == e.B.Y {
+					axis = 1
+				} else if e.A.X != e.B.X {
+					continue
+				}
+				bd := border{k1: k, k2: other, axis: axis, value: coord(e.A, axis)}
+				if passages[bd] == nil {
+					borders = append(borders, bd)
+				}
+				a, b := coord(e.A, 1-axis), coord(e.B, 1-axis)
+				passages[bd] = append(passages[bd], span{min(a, b), max(a, b)})
+			}
+		}
+	}
+	entrances := make([]map[geom.Vec2]bool, len(pieces))
+	add := func(k int, pt geom.Vec2) {
+		if entrances[k] == nil {
+			entrances[k] = make(map[geom.Vec2]bool)
+		}
+		if !entrances[k][pt] {
+			entrances[k][pt] = true
+			h.clusters[k].entrances = append(h.clusters[k].entrances, pt)
+		}
+	}
+	for _, bd := range borders {
+		spans := passages[bd]
+		slices.SortFunc(spans, func(s, t span) int { return cmp.Compare(s.lo, t.lo) })
+		merged := spans[:1]
+		for _, s := range spans[1:] {
+			if last := &merged[len(merged)-1]; s.lo <= last.hi {
+				last.hi = max(last.hi, s.hi)
+			} else {
+				merged = append(merged, s)
+			}
+		}
+		for _, s := range merged {
+			n := max(int(math.Ceil(float64((s.hi-s.lo)/spacing))), 1)
+			for i := range n + 1 {
+				var pt [2]float32
+				pt[bd.axis] = bd.value
+				pt[1-bd.axis] = s.lo + (s.hi-s.lo)*float32(i)/float32(n)
+				if i == n {
+					pt[1-bd.axis] = s.hi
+				}
+				add(bd.k1, geom.V2(pt[0], pt[1]))
+				add(bd.k2, geom.V2(pt[0], pt[1]))
+			}
+		}
+	}
+}
+
+// linkEntrances precomputes the paths between the entrances of a cluster
+// and adds them as edges to the graph of entrances. If two clusters connect
+// the same entrances, the cheaper path is kept.
+func (h *HierarchicalPathfinder) linkEntrances(cl *cluster) {
+	for i, a := range cl.entrances {
+		others := cl.entrances[i+1:]
+		for j, r := range cl.routes(a, others) {
+			if r.path == nil {
+				continue
+			}
+			b := others[j]
+			h.addRoute(a, b, r)
+			h.addRoute(b, a, r.reverse())
+		}
+	}
+}
+
+func (h *HierarchicalPathfinder) addRoute(a, b geom.Vec2, r route) {
+	e := [2]geom.Vec2{a, b}
+	if old, ok := h.routes[e]; ok {
+		if old.cost > r.cost {
+			h.routes[e] = r
+		}
+		return
+	}
+	h.routes[e] = r
+	h.graph.link(a, b)
+}
+
+// routes finds the paths from a to each of the points bs within the
+// cluster in a single search. The route to an unreachable point has no
+// path. The end points of the paths are exactly a and the points bs, even
+// if the Pathfinder of the cluster had to move them inside because of a
+// rounding error.
+func (cl *cluster) routes(a geom.Vec2, bs []geom.Vec2) []route {
+	pf := cl.pf
+	pf.mu.RLock()
+	defer pf.mu.RUnlock()
+	start, _ := pf.destInside(a)
+	goals := make([]geom.Vec2, len(bs))
+	for i, b := range bs {
+		goals[i], _ = pf.destInside(b)
+	}
+	rs := make([]route, len(bs))
+	for i, path := range pf.findAll(start, goals) {
+		if path == nil {
+			continue
+		}
+		cost := astar.Path[geom.Vec2](path).Cost(pf.costFunc())
+		path[0], path[len(path)-1] = a, bs[i]
+		rs[i] = route{path: path, cost: cost}
+	}
+	return rs
+}
+
+// reverse returns the route in the opposite direction, which has the same
+// cost.
+func (r route) reverse() route {
+	back := slices.Clone(r.path)
+	slices.Reverse(back)
+	return route{path: back, cost: r.cost}
+}
+
+// Path finds a path from start to dest like PathF, but with integer
+// coordinates. The waypoints of the path are rounded.
+func (h *HierarchicalPathfinder) Path(start, dest image.Point) []image.Point {
+	return convert(h.PathF(p2v(start), p2v(dest)), v2p)
+}
+
+// PathF finds a path from start to dest. It returns nil if no path exists.
+// See the type documentation for how close it is to the shortest path.
+func (h *HierarchicalPathfinder) PathF(start, dest geom.Vec2) []geom.Vec2 {
+	res, _ := h.Find(start, dest)
+	return res.Path
+}
+
+// Find finds a path from start to dest like PathF, and reports the details
+// of the path like Pathfinder.Find. It handles start points and
+// destinations outside the polygon set and the errors in the same way.
+func (h *HierarchicalPathfinder) Find(start, dest geom.Vec2) (Result, error) {
+	res, err := h.base.startResult(start)
+	if err != nil {
+		res.Dest = dest
+		return res, err
+	}
+	res.Dest, res.DestMoved = h.base.destInside(dest)
+	path := h.path(res.Start, res.Dest)
+	if path == nil {
+		return res, ErrUnreachable
+	}
+	h.base.complete(&res, start, path)
+	return res, nil
+}
+
+func (h *HierarchicalPathfinder) path(start, dest geom.Vec2) []geom.Vec2 {
+	from, to := h.clusterAt(start), h.clusterAt(dest)
+	if from == nil || to == nil {
+		return nil
+	}
+	extra := make(graph[geom.Vec2])
+	queryRoutes := make(map[[2]geom.Vec2]route)
+	connect := func(a, b geom.Vec2, r route) {
+		if r.path != nil {
+			extra.link(a, b)
+			queryRoutes[[2]geom.Vec2{a, b}] = r
+		}
+	}
+	// One search from each end point reaches all entrances of its cluster.
+	goals := from.entrances
+	if from == to {
+		goals = append(slices.Clip(goals), dest)
+	}
+	for i, r := range from.routes(start, goals) {
+		connect(start, goals[i], r)
+	}
+	for i, r := range to.routes(dest, to.entrances) {
+		connect(to.entrances[i], dest, r.reverse())
+	}
+	routeOf := func(a, b geom.Vec2) route {
+		if r, ok := queryRoutes[[2]geom.Vec2{a, b}]; ok {
+			return r
+		}
+		return h.routes[[2]geom.Vec2{a, b}]
+	}
+	cost := func(a, b geom.Vec2) float64 {
+		return routeOf(a, b).cost
+	}
+	vis := overlay[geom.Vec2]{base: h.graph, extra: extra}
+	nodes := astar.FindPath[geom.Vec2](vis, start, dest, cost, h.base.heuristic)
+	if nodes == nil {
+		return nil
+	}
+	path := []geom.Vec2{start}
+	for i := 1; i < len(nodes); i++ {
+		for _, pt := range routeOf(nodes[i-1], nodes[i]).path[1:] {
+			path = appendPoint(path, pt)
+		}
+	}
+	if len(h.base.regions) > 0 {
+		// Skipping waypoints could make the path more expensive.
+		return path
+	}
+	return h.shortcut(path)
+}
+
+// clusterAt returns the cluster that contains point pt. Since a point on a
+// cell border belongs to the clusters on both sides, and the pieces of the
+// clusters can deviate from the cell borders by rounding errors, the
+// adjacent cells are checked as well. If no cluster contains pt, it
+// returns the closest one. It returns nil if there are no clusters.
+func (h *HierarchicalPathfinder) clusterAt(pt geom.Vec2) *cluster {
+	if len(h.clusters) == 0 {
+		return nil
+	}
+	i, j := h.cell(pt)
+	var closest *cluster
+	closestDist := math.Inf(1)
+	for _, dj := range [...]int{0, -1, 1} {
+		for _, di := range [...]int{0, -1, 1} {
+			ci, cj := i+di, j+dj
+			if ci < 0 || ci >= h.cols || cj < 0 || cj >= h.rows {
+				continue
+			}
+			cl := h.clusters[cj*h.cols+ci]
+			if cl == nil {
+				continue
+			}
+			if cl.pf.Contains(pt) {
+				return cl
+			}
+			if d := nodeDist(cl.pf.ClosestPt(pt), pt); d < closestDist {
+				closest, closestDist = cl, d
+			}
+		}
+	}
+	return closest
+}
+
+// shortcut removes waypoints from the path by going straight from each
+// waypoint to the farthest waypoint in its line of sight, which never makes
+// the path longer.
+func (h *HierarchicalPathfinder) shortcut(path []geom.Vec2) []geom.Vec2 {
+	short := path[:1:1]
+	for i := 0; i < len(path)-1; {
+		j := len(path) - 1
+		for j > i+1 && !inLineOfSight(h.base.index, path[i], path[j]) {
+			j--
+		}
+		short = append(short, path[j])
+		i = j
+	}
+	return short
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"image"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+func TestHierarchicalPathfinder(t *testing.T) {
+	tests := []struct {
+		name        string
+		polygons    [][]geom.Vec2
+		clusterSize float32
+		start       geom.Vec2
+		dest        geom.Vec2
+	}{
+		{"U single cluster", polygonUF, 0, geom.V2(2.5, 2.5), geom.V2(12.5, 2.5)},
+		{"U two corners", polygonUF, 4, geom.V2(2.5, 2.5), geom.V2(12.5, 2.5)},
+		{"U one corner", polygonUF, 4, geom.V2(2.5, 2.5), geom.V2(12.5, 9.5)},
+		{"U straight", polygonUF, 4, geom.V2(1, 9), geom.V2(15, 9)},
+		{"U same point", polygonUF, 4, geom.V2(2.5, 2.5), geom.V2(2.5, 2.5)},
+		{"U destination moved", polygonUF, 4, geom.V2(2.5, 2.5), geom.V2(7.5, 2.5)},
+		{"O around hole", vecPolygons(polygonO), 8, geom.V2(20, 5), geom.V2(18, 35)},
+		{"O outside hole", vecPolygons(polygonO), 8, geom.V2(20, 5), geom.V2(20, 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vis := pathfind.NewPathfinderF(tt.polygons)
+			hpf := pathfind.NewHierarchicalPathfinderF(tt.polygons, tt.clusterSize)
+			want, wantErr := vis.Find(tt.start, tt.dest)
+			got, err := hpf.Find(tt.start, tt.dest)
+			if err != wantErr {
+				t.Errorf("Find(%v, %v): got error %v, want %v", tt.start, tt.dest, err, wantErr)
+			}
+			if !equalResults(got, want) {
+				t.Errorf("Find(%v, %v)\n got: %+v\nwant: %+v", tt.start, tt.dest, got, want)
+			}
+		})
+	}
+}
+
+func TestHierarchicalPathfinderNavMesh(t *testing.T) {
+	polygons := vecPolygons(polygonO)
+	vis := pathfind.NewPathfinderF(polygons)
+	hpf := pathfind.NewHierarchicalPathfinderF(polygons, 8, pathfind.WithBackend(pathfind.NavMeshBackend))
+	for _, q := range [][2]geom.Vec2{
+		{geom.V2(20, 5), geom.V2(18, 35)},
+		{geom.V2(20, 5), geom.V2(20, 20)},
+		{geom.V2(5, 5), geom.V2(35, 35)},
+	} {
+		want, wantErr := vis.Find(q[0], q[1])
+		got, err := hpf.Find(q[0], q[1])
+		if err != wantErr {
+			t.Errorf("Find(%v, %v): got error %v, want %v", q[0], q[1], err, wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if path := got.Path; path[0] != q[0] || path[len(path)-1] != want.Dest {
+			t.Errorf("Find(%v, %v): path %v does not connect start and destination %v", q[0], q[1], path, want.Dest)
+		}
+		if got.Length < want.Length-1e-4 || got.Length > want.Length*1.1 {
+			t.Errorf("Find(%v, %v): got path length %g, want about %g", q[0], q[1], got.Length, want.Length)
+		}
+	}
+}
+
+func TestHierarchicalPathfinderRandom(t *testing.T) {
+	// A square with 5×5 square holes, some of them crossing cluster borders.
+	polygons := [][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(60, 0), geom.V2(60, 60), geom.V2(0, 60)},
+	}
+	for i := range 5 {
+		for j := range 5 {
+			x, y := float32(10*i+8), float32(10*j+8)
+			polygons = append(polygons, []geom.Vec2{
+				geom.V2(x, y), geom.V2(x+4, y), geom.V2(x+4+float32(i%3), y+4), geom.V2(x, y+4+float32(j%2)),
+			})
+		}
+	}
+	const clusterSize = 15
+	vis := pathfind.NewPathfinderF(polygons)
+	hpf := pathfind.NewHierarchicalPathfinderF(polygons, clusterSize)
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		var start, dest geom.Vec2
+		for {
+			start = geom.V2(r.Float32()*60, r.Float32()*60)
+			dest = geom.V2(r.Float32()*60, r.Float32()*60)
+			if vis.Contains(start) && vis.Contains(dest) {
+				break
+			}
+		}
+		want, err := vis.Find(start, dest)
+		if err != nil {
+			t.Fatalf("Pathfinder: Find(%v, %v): unexpected error: %v", start, dest, err)
+		}
+		got, err := hpf.Find(start, dest)
+		if err != nil {
+			t.Fatalf("HierarchicalPathfinder: Find(%v, %v): unexpected error: %v", start, dest, err)
+		}
+		path := got.Path
+		if path[0] != start || path[len(path)-1] != dest {
+			t.Errorf("Find(%v, %v): path %v does not connect start and destination", start, dest, path)
+		}
+		for i := 1; i < len(path); i++ {
+			if !vis.InLineOfSight(path[i-1], path[i]) {
+				t.Errorf("Find(%v, %v): path %v leaves the polygon set between %v and %v", start, dest, path, path[i-1], path[i])
+			}
+		}
+		if got.Length < want.Length-1e-4 {
+			t.Errorf("Find(%v, %v): path length %g is shorter than the shortest path length %g", start, dest, got.Length, want.Length)
+		}
+		bound := want.Length + float64(borderCrossings(want.Path, clusterSize))*clusterSize/8
+		if got.Length > bound+1e-4 {
+			t.Errorf("Find(%v, %v): path length %g exceeds the bound %g", start, dest, got.Length, bound)
+		}
+	}
+}
+
+// borderCrossings returns the number of times a path crosses the borders
+// of a grid of square cells with the given size.
+func borderCrossings(path []geom.Vec2, size float32) int {
+	cell := func(v float32) int {
+		return int(math.Floor(float64(v / size)))
+	}
+	n := 0
+	for i := 1; i < len(path); i++ {
+		n += abs(cell(path[i].X)-cell(path[i-1].X)) + abs(cell(path[i].Y)-cell(path[i-1].Y))
+	}
+	return n
+}
+
+func abs(x int) int {
+	return max(x, -x)
+}
+
+func TestHierarchicalPathfinderPath(t *testing.T) {
+	hpf := pathfind.NewHierarchicalPathfinder(polygonU, 4)
+	got := hpf.Path(image.Pt(2, 2), image.Pt(13, 2))
+	want := pathfind.NewPathfinder(polygonU).Path(image.Pt(2, 2), image.Pt(13, 2))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Path(%v, %v)\n got: %v\nwant: %v", image.Pt(2, 2), image.Pt(13, 2), got, want)
+	}
+}
+
+func TestHierarchicalPathfinderUnreachable(t *testing.T) {
+	hpf := pathfind.NewHierarchicalPathfinderF([][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)},
+		{geom.V2(20, 0), geom.V2(30, 0), geom.V2(30, 10), geom.V2(20, 10)},
+	}, 5)
+	start, dest := geom.V2(5, 5), geom.V2(25, 5)
+	if _, err := hpf.Find(start, dest); err != pathfind.ErrUnreachable {
+		t.Errorf("Find(%v, %v): got error %v, want %v", start, dest, err, pathfind.ErrUnreachable)
+	}
+	start = geom.V2(15, 5)
+	if _, err := hpf.Find(start, dest); err != pathfind.ErrStartOutside {
+		t.Errorf("Find(%v, %v): got error %v, want %v", start, dest, err, pathfind.ErrStartOutside)
+	}
+}
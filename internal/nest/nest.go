@@ -0,0 +1,67 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nest nests the areas read from formats like GeoJSON and WKT,
+// whose polygons have an exterior ring and interior rings but no islands,
+// for pathfind.NewPathfinderFromAreasF.
+package nest
+
+import (
+	"math"
+
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// Islands returns the areas with each area that lies inside a hole of
+// another area moved to the islands of the innermost such hole. The
+// remaining areas stay at the first level. The order of the areas is
+// preserved. An area may touch the outline of its hole.
+func Islands(areas []pathfind.AreaF) []pathfind.AreaF {
+	type hole struct{ area, index int }
+	size := func(p poly.Polygon) float32 {
+		return float32(math.Abs(float64(p.SignedArea())))
+	}
+	islands := make(map[hole][]int)
+	var roots []int
+	for i, a := range areas {
+		parent := hole{-1, -1}
+		var parentSize float32
+		for j, b := range areas {
+			// Only a larger area can have a hole around a, so that an
+			// area never ends up as an island of itself.
+			if j == i || size(b.Outline) <= size(a.Outline) {
+				continue
+			}
+			for k, h := range b.Holes {
+				s := size(h.Outline)
+				if (parent.area < 0 || s < parentSize) && poly.Polygon(a.Outline).IsInside(h.Outline) {
+					parent, parentSize = hole{j, k}, s
+				}
+			}
+		}
+		if parent.area < 0 {
+			roots = append(roots, i)
+			continue
+		}
+		islands[parent] = append(islands[parent], i)
+	}
+	var nested func(i int) pathfind.AreaF
+	nested = func(i int) pathfind.AreaF {
+		a := pathfind.AreaF{Outline: areas[i].Outline}
+		for k, h := range areas[i].Holes {
+			h.Islands = append([]pathfind.AreaF(nil), h.Islands...)
+			for _, j := range islands[hole{i, k}] {
+				h.Islands = append(h.Islands, nested(j))
+			}
+			a.Holes = append(a.Holes, h)
+		}
+		return a
+	}
+	res := make([]pathfind.AreaF, len(roots))
+	for n, i := range roots {
+		res[n] = nested(i)
+	}
+	return res
+}
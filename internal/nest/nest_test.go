@@ -0,0 +1,45 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nest_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/internal/nest"
+)
+
+func square(x, y, size float32) []geom.Vec2 {
+	return []geom.Vec2{geom.V2(x, y), geom.V2(x+size, y), geom.V2(x+size, y+size), geom.V2(x, y+size)}
+}
+
+func TestIslands(t *testing.T) {
+	areas := []pathfind.AreaF{
+		{Outline: square(3, 3, 4), Holes: []pathfind.HoleF{{Outline: square(4, 4, 2)}}},
+		{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{{Outline: square(2, 2, 6)}}},
+		{Outline: square(4.5, 4.5, 1)},
+		{Outline: square(20, 0, 10)},
+		{Outline: square(2, 2, 1)}, // touches the outline of its hole
+	}
+	got := nest.Islands(areas)
+	want := []pathfind.AreaF{
+		{Outline: square(0, 0, 10), Holes: []pathfind.HoleF{{
+			Outline: square(2, 2, 6),
+			Islands: []pathfind.AreaF{
+				{Outline: square(3, 3, 4), Holes: []pathfind.HoleF{{
+					Outline: square(4, 4, 2),
+					Islands: []pathfind.AreaF{{Outline: square(4.5, 4.5, 1)}},
+				}}},
+				{Outline: square(2, 2, 1)},
+			},
+		}}},
+		{Outline: square(20, 0, 10)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Islands\n got: %v\nwant: %v", got, want)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly
+
+import (
+	"math"
+	"slices"
+
+	"github.com/fzipp/geom"
+)
+
+// ClipRect clips the convex polygon p to the axis-aligned rectangle with the
+// corners lo and hi with the Sutherland–Hodgman algorithm. It returns nil if
+// nothing with a positive area remains.
+//
+// The point where an edge of p crosses a side of the rectangle is always
+// calculated from the whole edge, independent of its direction and of the
+// order of the clipping steps. Therefore, polygons that share an edge are
+// clipped to pieces that share the clipped part of the edge exactly, and
+// the pieces of a polygon clipped to adjacent rectangles share the part of
+// the common side exactly.
+func (p Polygon) ClipRect(lo, hi geom.Vec2) Polygon {
+	vs := make([]clipVertex, len(p))
+	for i, v := range p {
+		vs[i] = clipVertex{pt: v, src: p.Edge(i)}
+	}
+	for _, h := range [...]halfPlane{
+		{axis: 0, value: lo.X, sign: 1},
+		{axis: 0, value: hi.X, sign: -1},
+		{axis: 1, value: lo.Y, sign: 1},
+		{axis: 1, value: hi.Y, sign: -1},
+	} {
+		vs = h.clip(vs)
+	}
+	var clipped Polygon
+	for _, v := range vs {
+		if len(clipped) == 0 || clipped[len(clipped)-1] != v.pt {
+			clipped = append(clipped, v.pt)
+		}
+	}
+	for len(clipped) > 1 && clipped[0] == clipped[len(clipped)-1] {
+		clipped = clipped[:len(clipped)-1]
+	}
+	if len(clipped) < 3 || clipped.SignedArea() == 0 {
+		return nil
+	}
+	return clipped
+}
+
+// clipVertex is a vertex of a polygon during clipping, together with the
+// origin of the edge that starts at the vertex: either an edge of the
+// input polygon, or the line of a previous clipping step.
+type clipVertex struct {
+	pt     geom.Vec2
+	src    LineSeg
+	onClip bool
+	clip   halfPlane
+}
+
+// halfPlane is the part of the plane where the coordinate with the given
+// axis (0 for x, 1 for y) is at least the value if sign is positive, or at
+// most the value if sign is negative.
+type halfPlane struct {
+	axis  int
+	value float32
+	sign  float32
+}
+
+func (h halfPlane) contains(pt geom.Vec2) bool {
+	return (coord(pt, h.axis)-h.value)*h.sign >= 0
+}
+
+// clip removes the part of the polygon outside the half-plane.
+func (h halfPlane) clip(vs []clipVertex) []clipVertex {
+	var out []clipVertex
+	for i, v := range vs {
+		w := vs[(i+1)%len(vs)]
+		vIn, wIn := h.contains(v.pt), h.contains(w.pt)
+		if vIn {
+			out = append(out, v)
+		}
+		if vIn == wIn {
+			continue
+		}
+		x := h.intersect(v)
+		if vIn {
+			out = append(out, clipVertex{pt: x, onClip: true, clip: h})
+		} else {
+			out = append(out, clipVertex{pt: x, src: v.src, onClip: v.onClip, clip: v.clip})
+		}
+	}
+	return out
+}
+
+// intersect returns the point where the edge starting at vertex v crosses
+// the line of the half-plane.
+func (h halfPlane) intersect(v clipVertex) geom.Vec2 {
+	var pt [2]float32
+	pt[h.axis] = h.value
+	if v.onClip {
+		// The edge lies on the line of a perpendicular half-plane.
+		pt[v.clip.axis] = v.clip.value
+		return geom.V2(pt[0], pt[1])
+	}
+	a, b := v.src.A, v.src.B
+	if coord(b, 0) < coord(a, 0) || (coord(b, 0) == coord(a, 0) && coord(b, 1) < coord(a, 1)) {
+		a, b = b, a
+	}
+	other := 1 - h.axis
+	t := (float64(h.value) - float64(coord(a, h.axis))) /
+		(float64(coord(b, h.axis)) - float64(coord(a, h.axis)))
+	pt[other] = float32(float64(coord(a, other)) + t*(float64(coord(b, other))-float64(coord(a, other))))
+	return geom.V2(pt[0], pt[1])
+}
+
+func coord(pt geom.Vec2, axis int) float32 {
+	if axis == 0 {
+		return pt.X
+	}
+	return pt.Y
+}
+
+// Outline returns the boundary of the union of polygons that have positive
+// signed area and meet only along common edges, such as the pieces of a
+// triangulation clipped by ClipRect. An edge is a common edge if another
+// polygon has the same edge in the opposite direction.
+//
+// The boundary consists of the outer rings of the union and the rings
+// around its holes. All of the rings have positive signed area, so that
+// holes are determined by nesting like in any other polygon set. Where
+// the union touches itself at a single vertex, the rings are separated at
+// this vertex, and each ring starts at a vertex that it doesn't share with
+// other rings, if it has one.
+func Outline(polygons []Polygon) PolygonSet {
+	inner := make(map[LineSeg]bool)
+	for _, p := range polygons {
+		for i := range p {
+			inner[p.Edge(i)] = true
+		}
+	}
+	out := make(map[geom.Vec2][]LineSeg)
+	var edges []LineSeg
+	for _, p := range polygons {
+		for i := range p {
+			e := p.Edge(i)
+			if !inner[LineSeg{e.B, e.A}] {
+				out[e.A] = append(out[e.A], e)
+				edges = append(edges, e)
+			}
+		}
+	}
+	used := make(map[LineSeg]bool, len(edges))
+	var rings PolygonSet
+	for _, first := range edges {
+		if used[first] {
+			continue
+		}
+		var ring Polygon
+		for e := first; !used[e]; e = nextBoundaryEdge(e, out[e.B]) {
+			used[e] = true
+			ring = append(ring, e.A)
+		}
+		if len(ring) < 3 {
+			continue
+		}
+		ring.Normalize()
+		// Start the ring at a vertex that it doesn't share with other rings,
+		// because the nesting is tested with the first vertex.
+		if i := slices.IndexFunc(ring, func(v geom.Vec2) bool { return len(out[v]) == 1 }); i > 0 {
+			ring = slices.Concat(ring[i:], ring[:i])
+		}
+		rings = append(rings, ring)
+	}
+	return rings
+}
+
+// nextBoundaryEdge selects the boundary edge that follows edge e among the
+// candidates starting at the end of e. It is the first candidate in
+// clockwise direction from the reverse of e, so that the region to the
+// left of e stays to the left of the selected edge. Without candidates,
+// it returns e itself.
+func nextBoundaryEdge(e LineSeg, candidates []LineSeg) LineSeg {
+	back := math.Atan2(float64(e.A.Y)-float64(e.B.Y), float64(e.A.X)-float64(e.B.X))
+	next := e
+	best := math.Inf(1)
+	for _, c := range candidates {
+		a := math.Atan2(float64(c.B.Y)-float64(c.A.Y), float64(c.B.X)-float64(c.A.X))
+		turn := back - a
+		for turn <= 0 {
+			turn += 2 * math.Pi
+		}
+		if turn < best {
+			best, next = turn, c
+		}
+	}
+	return next
+}
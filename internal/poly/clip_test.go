@@ -0,0 +1,183 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly_test
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+func TestPolygonClipRect(t *testing.T) {
+	lo, hi := geom.V2(0, 0), geom.V2(10, 10)
+	tests := []struct {
+		name    string
+		polygon poly.Polygon
+		want    poly.Polygon
+	}{
+		{
+			"Inside",
+			poly.Polygon{geom.V2(1, 1), geom.V2(9, 1), geom.V2(5, 9)},
+			poly.Polygon{geom.V2(1, 1), geom.V2(9, 1), geom.V2(5, 9)},
+		},
+		{
+			"Outside",
+			poly.Polygon{geom.V2(11, 1), geom.V2(19, 1), geom.V2(15, 9)},
+			nil,
+		},
+		{
+			"Touching",
+			poly.Polygon{geom.V2(10, 1), geom.V2(19, 1), geom.V2(15, 9)},
+			nil,
+		},
+		{
+			"Crossing a side",
+			poly.Polygon{geom.V2(5, 2), geom.V2(15, 2), geom.V2(15, 6), geom.V2(5, 6)},
+			poly.Polygon{geom.V2(5, 2), geom.V2(10, 2), geom.V2(10, 6), geom.V2(5, 6)},
+		},
+		{
+			"Covering a corner",
+			poly.Polygon{geom.V2(5, 5), geom.V2(15, 5), geom.V2(15, 15), geom.V2(5, 15)},
+			poly.Polygon{geom.V2(5, 5), geom.V2(10, 5), geom.V2(10, 10), geom.V2(5, 10)},
+		},
+		{
+			"Covering the rectangle",
+			poly.Polygon{geom.V2(-10, -10), geom.V2(30, -10), geom.V2(-10, 30)},
+			poly.Polygon{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.polygon.ClipRect(lo, hi)
+			if !equalRings(got, tt.want) {
+				t.Errorf("%v.ClipRect(%v, %v)\n got: %v\nwant: %v", tt.polygon, lo, hi, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonClipRectSharedEdges(t *testing.T) {
+	// Two triangles sharing an oblique edge, clipped to four adjacent
+	// rectangles. The pieces must share the clipped parts of the common
+	// edge and of the rectangle sides exactly.
+	tris := []poly.Polygon{
+		{geom.V2(0.3, 0.1), geom.V2(19.7, 3.3), geom.V2(2.9, 17.3)},
+		{geom.V2(19.7, 3.3), geom.V2(17.1, 19.9), geom.V2(2.9, 17.3)},
+	}
+	var pieces []poly.Polygon
+	var area float64
+	for _, tri := range tris {
+		for _, lo := range []geom.Vec2{geom.V2(0, 0), geom.V2(7, 0), geom.V2(0, 7), geom.V2(7, 7)} {
+			hi := lo.Add(geom.V2(7, 7))
+			if lo.X == 7 {
+				hi.X = 20
+			}
+			if lo.Y == 7 {
+				hi.Y = 20
+			}
+			if piece := tri.ClipRect(lo, hi); piece != nil {
+				pieces = append(pieces, piece)
+				area += float64(piece.SignedArea())
+			}
+		}
+	}
+	wantArea := float64(tris[0].SignedArea()) + float64(tris[1].SignedArea())
+	if math.Abs(area-wantArea) > 1e-3 {
+		t.Errorf("got total area %g of the pieces, want %g", area, wantArea)
+	}
+	outline := poly.Outline(pieces)
+	want := poly.Outline(tris)
+	if len(outline) != 1 || len(want) != 1 {
+		t.Fatalf("got outlines %v and %v, want one ring each", outline, want)
+	}
+	// The outline of the pieces has additional collinear vertices where
+	// the rectangle sides cross the boundary.
+	for _, v := range want[0] {
+		if !slices.Contains(outline[0], v) {
+			t.Errorf("outline %v of the pieces lacks vertex %v", outline[0], v)
+		}
+	}
+	if len(outline[0]) != 4+4 {
+		t.Errorf("got %d vertices in outline %v, want %d", len(outline[0]), outline[0], 4+4)
+	}
+}
+
+func TestOutline(t *testing.T) {
+	square := func(x, y float32) poly.Polygon {
+		return poly.Polygon{geom.V2(x, y), geom.V2(x+1, y), geom.V2(x+1, y+1), geom.V2(x, y+1)}
+	}
+	tests := []struct {
+		name     string
+		polygons []poly.Polygon
+		want     poly.PolygonSet
+	}{
+		{"Empty", nil, nil},
+		{
+			"Two squares",
+			[]poly.Polygon{square(0, 0), square(1, 0)},
+			poly.PolygonSet{
+				{geom.V2(0, 0), geom.V2(1, 0), geom.V2(2, 0), geom.V2(2, 1), geom.V2(1, 1), geom.V2(0, 1)},
+			},
+		},
+		{
+			"Ring of squares",
+			[]poly.Polygon{
+				square(0, 0), square(1, 0), square(2, 0), square(2, 1),
+				square(2, 2), square(1, 2), square(0, 2), square(0, 1),
+			},
+			poly.PolygonSet{
+				{
+					geom.V2(0, 0), geom.V2(1, 0), geom.V2(2, 0), geom.V2(3, 0),
+					geom.V2(3, 1), geom.V2(3, 2), geom.V2(3, 3), geom.V2(2, 3),
+					geom.V2(1, 3), geom.V2(0, 3), geom.V2(0, 2), geom.V2(0, 1),
+				},
+				{geom.V2(1, 1), geom.V2(2, 1), geom.V2(2, 2), geom.V2(1, 2)},
+			},
+		},
+		{
+			"Touching at a vertex",
+			[]poly.Polygon{square(0, 0), square(1, 1)},
+			poly.PolygonSet{square(0, 0), square(1, 1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := poly.Outline(tt.polygons)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Outline(%v)\n got: %v\nwant: %v", tt.polygons, got, tt.want)
+			}
+			for i := range got {
+				if !equalRings(got[i], tt.want[i]) {
+					t.Errorf("Outline(%v)\n got: %v\nwant: %v", tt.polygons, got, tt.want)
+				}
+				if got[i].SignedArea() <= 0 {
+					t.Errorf("ring %v: got signed area %g, want > 0", got[i], got[i].SignedArea())
+				}
+			}
+		})
+	}
+}
+
+// equalRings reports whether two polygons have the same vertices in the
+// same cyclic order, independent of the start vertex.
+func equalRings(p, q poly.Polygon) bool {
+	if len(p) != len(q) {
+		return false
+	}
+	if len(p) == 0 {
+		return true
+	}
+	for start := range q {
+		rotated := append(append(poly.Polygon{}, q[start:]...), q[:start]...)
+		if reflect.DeepEqual(p, rotated) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/fzipp/geom"
+)
+
+// A wedge is the part of the surroundings of a point on the outline of a
+// polygon that lies inside the polygon: the angle counterclockwise from
+// the direction towards point from to the direction towards point to.
+type wedge struct {
+	from, to point
+}
+
+// wedgeAt returns the wedge of polygon p at point v and true, if v lies on
+// the edge with index i, but not on its end vertex, which belongs to the
+// next edge. The parameter positive tells the winding order of p, see
+// IsReflexAt.
+func (p Polygon) wedgeAt(v point, i int, positive bool) (wedge, bool) {
+	edge := p.Edge(i)
+	if !onSegment(v, edge) || v == vecPoint(edge.B) {
+		return wedge{}, false
+	}
+	// The interior lies to the left of the edges in the winding order of
+	// a polygon with positive signed area.
+	back, ahead := vecPoint(edge.A), vecPoint(edge.B)
+	if v == back {
+		back = vecPoint(p[p.WrapIndex(i-1)])
+	}
+	if positive {
+		return wedge{from: ahead, to: back}, true
+	}
+	return wedge{from: back, to: ahead}, true
+}
+
+// A contact is a point where the outlines of two or more polygons touch.
+// The directions towards the vertices of the outlines divide its
+// surroundings into sectors, which are either accessible or not.
+type contact struct {
+	v    point
+	dirs []point // points in distinct directions from v, counterclockwise
+	free []bool  // whether the sector from dirs[k] to dirs[k+1] is accessible
+}
+
+// newContact returns the contact at point v with the given wedges of the
+// touching polygons. The parameter base tells whether v is inside of the
+// boundaries of the other polygons, i.e. whether a sector that lies in
+// none of the wedges is accessible.
+func newContact(v point, wedges []wedge, base bool) *contact {
+	c := &contact{v: v}
+	for _, w := range wedges {
+		c.dirs = append(c.dirs, w.from, w.to)
+	}
+	slices.SortFunc(c.dirs, c.compareDirs)
+	c.dirs = slices.CompactFunc(c.dirs, func(a, b point) bool {
+		return c.compareDirs(a, b) == 0
+	})
+	c.free = make([]bool, len(c.dirs))
+	for k := range c.free {
+		c.free[k] = base
+	}
+	for _, w := range wedges {
+		k, _ := c.find(w.from)
+		end, _ := c.find(w.to)
+		for ; k != end; k = (k + 1) % len(c.dirs) {
+			c.free[k] = !c.free[k]
+		}
+	}
+	return c
+}
+
+// compareDirs compares the directions from the contact towards points a
+// and b by their angle to the positive x axis in the range [0°, 360°).
+func (c *contact) compareDirs(a, b point) int {
+	upper := func(q point) bool {
+		return q.y > c.v.y || q.y == c.v.y && q.x > c.v.x
+	}
+	if ua, ub := upper(a), upper(b); ua != ub {
+		if ua {
+			return -1
+		}
+		return +1
+	}
+	return -orient(c.v, a, b)
+}
+
+// find returns the index of the direction towards point q in dirs and
+// true, or the index of the sector that contains the direction and false.
+func (c *contact) find(q point) (int, bool) {
+	k, found := slices.BinarySearchFunc(c.dirs, q, c.compareDirs)
+	if found {
+		return k, true
+	}
+	return (k + len(c.dirs) - 1) % len(c.dirs), false
+}
+
+// sectors returns the indices of the sectors whose closure contains the
+// direction towards point q.
+func (c *contact) sectors(q point) []int {
+	k, found := c.find(q)
+	if found {
+		return []int{k, (k + len(c.dirs) - 1) % len(c.dirs)}
+	}
+	return []int{k}
+}
+
+// isWide checks whether sector k has an angle of at least 180°.
+func (c *contact) isWide(k int) bool {
+	return len(c.dirs) == 1 || orient(c.v, c.dirs[k], c.dirs[(k+1)%len(c.dirs)]) <= 0
+}
+
+// freeCount returns the number of accessible sectors.
+func (c *contact) freeCount() int {
+	n := 0
+	for _, free := range c.free {
+		if free {
+			n++
+		}
+	}
+	return n
+}
+
+// passes checks if line segment ls, which has the contact on it, stays
+// in one accessible sector. If the contact is an end point of ls, the
+// other end point must lie in the sector returned by bend, so that paths
+// joined at the contact don't pass from one sector to another.
+func (c *contact) passes(ls LineSeg) bool {
+	a, b := vecPoint(ls.A), vecPoint(ls.B)
+	switch c.v {
+	case a:
+		return slices.Contains(c.sectors(b), c.bend())
+	case b:
+		return slices.Contains(c.sectors(a), c.bend())
+	}
+	for _, k := range c.sectors(a) {
+		if c.free[k] && slices.Contains(c.sectors(b), k) {
+			return true
+		}
+	}
+	return false
+}
+
+// bend returns the index of the accessible sector in which a path can bend
+// at the contact: the only accessible sector, or else the only one with an
+// angle of at least 180°, since a shortest path doesn't bend in a smaller
+// one. It returns -1 if there is no such sector.
+func (c *contact) bend() int {
+	single := c.freeCount() == 1
+	bend := -1
+	for k, free := range c.free {
+		if free && (single || c.isWide(k)) {
+			if bend >= 0 {
+				return -1
+			}
+			bend = k
+		}
+	}
+	return bend
+}
+
+// closestAccessiblePt returns the closest point to point pt on the parts
+// of edge e that border on the accessible area, and true, or false if the
+// edge is shared with other polygons along its whole length, so that there
+// is no accessible area on either side of it. The function splits returns
+// the vertices of the other polygons on e, and contactAt the contact at a
+// point, see Index.contactAt.
+func closestAccessiblePt(e LineSeg, pt geom.Vec2, splits func() []geom.Vec2, contactAt func(v point) *contact) (geom.Vec2, bool) {
+	pts := splits()
+	if len(pts) == 0 && contactAt(vecPoint(e.A)) == nil && contactAt(vecPoint(e.B)) == nil {
+		return e.ClosestPt(pt), true
+	}
+	slices.SortFunc(pts, func(u, w geom.Vec2) int {
+		return cmp.Compare(l1Dist(e.A, u), l1Dist(e.A, w))
+	})
+	best := match{dist: float32(math.Inf(1))}
+	found := false
+	a := e.A
+	for _, b := range append(slices.Compact(pts), e.B) {
+		piece := LineSeg{A: a, B: b}
+		a = b
+		if piece.A == piece.B {
+			continue
+		}
+		if c := contactAt(middle(piece)); c != nil && c.freeCount() == 0 {
+			continue
+		}
+		var current match
+		current.pt = piece.ClosestPt(pt)
+		current.dist = current.pt.SqDist(pt)
+		if !found || current.dist < best.dist {
+			best, found = current, true
+		}
+	}
+	return best.pt, found
+}
@@ -0,0 +1,441 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/fzipp/geom"
+)
+
+// An Index is a spatial index over the edges of a polygon set. It answers
+// the same queries as the PolygonSet methods Contains, PassesContact and
+// ClosestPt and the Polygon method IsCrossedBy, with the same results, but
+// only tests the edges near the queried point or line segment.
+//
+// The index is a uniform grid of square cells. Each edge is registered in
+// all cells that are within a padding distance of it, and queries visit
+// all cells within the same padding distance of the queried geometry.
+// The padding covers rounding errors of the edge tests, so that an edge
+// that touches or crosses the queried geometry is always tested.
+//
+// The queries of an Index are safe for concurrent use, but not
+// concurrently with Insert and Remove, which modify it.
+type Index struct {
+	ps       PolygonSet
+	minX     float64
+	minY     float64
+	cellSize float64
+	pad      float64
+	nx, ny   int
+	cells    [][]edgeRef
+}
+
+// An edgeRef refers to the edge with index vertex of polygon polygon.
+type edgeRef struct {
+	polygon, vertex int32
+}
+
+func compareEdgeRefs(a, b edgeRef) int {
+	return cmp.Or(cmp.Compare(a.polygon, b.polygon), cmp.Compare(a.vertex, b.vertex))
+}
+
+// minPad is the smallest padding distance. It covers the rounding errors
+// of the cell calculation for edges that are small compared to their
+// coordinates.
+const minPad = 1e-4
+
+// NewIndex builds an index over the edges of polygon set ps. The grid is
+// sized so that there is about one edge per cell. The index refers to the
+// polygons of ps, which must not be modified afterwards.
+func NewIndex(ps PolygonSet) *Index {
+	x := &Index{ps: ps, cellSize: 1, nx: 1, ny: 1}
+	n := 0
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range ps {
+		n += len(p)
+		for _, v := range p {
+			minX, minY = min(minX, float64(v.X)), min(minY, float64(v.Y))
+			maxX, maxY = max(maxX, float64(v.X)), max(maxY, float64(v.Y))
+		}
+	}
+	if n > 0 {
+		w, h := maxX-minX, maxY-minY
+		cs := math.Sqrt(w * h / float64(n))
+		if cs == 0 {
+			cs = max(w, h) / float64(n)
+		}
+		if cs > 0 {
+			x.cellSize = cs
+		}
+		x.minX, x.minY = minX, minY
+		x.nx = int(w/x.cellSize) + 1
+		x.ny = int(h/x.cellSize) + 1
+	}
+	x.pad = max(x.cellSize/16, minPad)
+	x.cells = make([][]edgeRef, x.nx*x.ny)
+	for i := range ps {
+		x.register(i)
+	}
+	return x
+}
+
+// Insert appends polygon p to the indexed polygon set and registers its
+// edges in the cells of the grid, which keeps its size. Edges outside of
+// the grid are registered in the outermost cells, so the queries remain
+// correct, but slower if there are many of them.
+func (x *Index) Insert(p Polygon) {
+	x.ps = append(slices.Clip(x.ps), p)
+	x.register(len(x.ps) - 1)
+}
+
+// Remove removes the polygon with index i from the indexed polygon set.
+// The polygons after it move down by one index, like in slices.Delete.
+// Only the cells of the edges of these polygons are updated, so removing
+// the last polygon, e.g. the one added by the last Insert, is the fastest.
+func (x *Index) Remove(i int) {
+	affected := make(map[int]bool)
+	for _, p := range x.ps[i:] {
+		for j := range p {
+			e := p.Edge(j)
+			x.segmentCells(e.A, e.B, func(c int) bool {
+				affected[c] = true
+				return false
+			})
+		}
+	}
+	for c := range affected {
+		refs := slices.DeleteFunc(x.cells[c], func(ref edgeRef) bool {
+			return ref.polygon == int32(i)
+		})
+		for k := range refs {
+			if refs[k].polygon > int32(i) {
+				refs[k].polygon--
+			}
+		}
+		x.cells[c] = refs
+	}
+	x.ps = slices.Delete(slices.Clone(x.ps), i, i+1)
+}
+
+// register registers the edges of the polygon with index i in the cells
+// within the padding distance of them.
+func (x *Index) register(i int) {
+	p := x.ps[i]
+	for j := range p {
+		ref := edgeRef{polygon: int32(i), vertex: int32(j)}
+		e := p.Edge(j)
+		x.segmentCells(e.A, e.B, func(c int) bool {
+			x.cells[c] = append(x.cells[c], ref)
+			return false
+		})
+	}
+}
+
+// PolygonSet returns the indexed polygon set.
+func (x *Index) PolygonSet() PolygonSet {
+	return x.ps
+}
+
+// col returns the column of the cell containing x coordinate px. Points
+// outside of the grid are assigned to the outermost cells.
+func (x *Index) col(px float64) int {
+	return clampInt(int(math.Floor((px-x.minX)/x.cellSize)), 0, x.nx-1)
+}
+
+// row returns the row of the cell containing y coordinate py.
+func (x *Index) row(py float64) int {
+	return clampInt(int(math.Floor((py-x.minY)/x.cellSize)), 0, x.ny-1)
+}
+
+func clampInt(i, lo, hi int) int {
+	return min(max(i, lo), hi)
+}
+
+// segmentCells calls visit with the index of each cell within the padding
+// distance of the line segment from a to b, until visit returns true.
+// It reports whether visit returned true.
+func (x *Index) segmentCells(a, b geom.Vec2, visit func(c int) bool) bool {
+	ax, ay, bx, by := float64(a.X), float64(a.Y), float64(b.X), float64(b.Y)
+	for c := x.col(min(ax, bx) - x.pad); c <= x.col(max(ax, bx)+x.pad); c++ {
+		// The y range of the segment within the padded column.
+		y0, y1 := min(ay, by), max(ay, by)
+		if ax != bx {
+			left := x.minX + float64(c)*x.cellSize - x.pad
+			right := left + x.cellSize + 2*x.pad
+			if c == 0 {
+				left = math.Inf(-1)
+			}
+			if c == x.nx-1 {
+				right = math.Inf(1)
+			}
+			t0 := (left - ax) / (bx - ax)
+			t1 := (right - ax) / (bx - ax)
+			t0, t1 = max(min(t0, t1), 0), min(max(t0, t1), 1)
+			if t0 > t1 {
+				continue
+			}
+			y0, y1 = ay+t0*(by-ay), ay+t1*(by-ay)
+			y0, y1 = min(y0, y1), max(y0, y1)
+		}
+		for r := x.row(y0 - x.pad); r <= x.row(y1+x.pad); r++ {
+			if visit(r*x.nx + c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsCrossedBy checks if any side of any polygon of the polygon set is
+// crossed by line segment ls, see Polygon.IsCrossedBy.
+func (x *Index) IsCrossedBy(ls LineSeg) bool {
+	return x.segmentCells(ls.A, ls.B, func(c int) bool {
+		for _, ref := range x.cells[c] {
+			if x.ps[ref.polygon].isCrossedAt(ls, int(ref.vertex)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Contains checks if point pt lies inside the boundaries of the polygon
+// set, see PolygonSet.Contains.
+func (x *Index) Contains(pt geom.Vec2) bool {
+	return x.contains(vecPoint(pt), func(c *contact) bool {
+		return c.freeCount() == 1
+	})
+}
+
+// ContainsMiddle checks if the middle of line segment ls lies inside the
+// boundaries of the polygon set, see Polygon.ContainsMiddle. If the
+// outlines of two or more polygons touch at the middle, it checks if ls
+// stays on one side of the contact, see PolygonSet.PassesContact.
+func (x *Index) ContainsMiddle(ls LineSeg) bool {
+	return x.contains(middle(ls), func(c *contact) bool {
+		return c.passes(ls)
+	})
+}
+
+// contains checks if point pt lies inside the boundaries of the polygon
+// set. If pt lies on the outlines of two or more polygons, the result is
+// that of atContact for the contact at pt.
+func (x *Index) contains(pt point, atContact func(c *contact) bool) bool {
+	in := false
+	outlines := 0
+	x.rayCast(pt, func(_ int, inside, onOutline bool) {
+		if onOutline {
+			inside = !in
+			outlines++
+		}
+		if inside {
+			in = !in
+		}
+	})
+	if outlines > 1 {
+		if c := x.contactAt(pt); c != nil {
+			return atContact(c)
+		}
+	}
+	return in
+}
+
+// PassesContact checks if line segment ls passes through a point where the
+// outlines of two or more polygons of the polygon set touch, from one side
+// of the contact to another, see PolygonSet.PassesContact.
+func (x *Index) PassesContact(ls LineSeg) bool {
+	var seen []geom.Vec2
+	return x.segmentCells(ls.A, ls.B, func(c int) bool {
+		for _, ref := range x.cells[c] {
+			v := x.ps[ref.polygon][ref.vertex]
+			if slices.Contains(seen, v) || !onSegment(vecPoint(v), ls) {
+				continue
+			}
+			seen = append(seen, v)
+			if ct := x.contactAt(vecPoint(v)); ct != nil && !ct.passes(ls) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// contactAt returns the contact at point v, or nil if v doesn't lie on the
+// outlines of at least two polygons.
+func (x *Index) contactAt(v point) *contact {
+	var refs []edgeRef
+	var polygons []int32
+	for _, ref := range x.cells[x.row(v.y)*x.nx+x.col(v.x)] {
+		edge := x.ps[ref.polygon].Edge(int(ref.vertex))
+		if onSegment(v, edge) {
+			refs = append(refs, ref)
+			if !slices.Contains(polygons, ref.polygon) {
+				polygons = append(polygons, ref.polygon)
+			}
+		}
+	}
+	if len(polygons) < 2 {
+		return nil
+	}
+	var wedges []wedge
+	for _, i := range polygons {
+		p := x.ps[i]
+		positive := p.SignedArea() > 0
+		for _, ref := range refs {
+			if ref.polygon != i {
+				continue
+			}
+			if w, ok := p.wedgeAt(v, int(ref.vertex), positive); ok {
+				wedges = append(wedges, w)
+			}
+		}
+	}
+	base := false
+	x.rayCast(v, func(_ int, inside, onOutline bool) {
+		if inside && !onOutline {
+			base = !base
+		}
+	})
+	return newContact(v, wedges, base)
+}
+
+// Containing returns the indices of the polygons of the polygon set whose
+// boundary contains point pt, in ascending order, see Polygon.Contains.
+// If pt lies on the outline of the polygon with index i, the result for it
+// is toleranceOnOutside(i).
+func (x *Index) Containing(pt geom.Vec2, toleranceOnOutside func(i int) bool) []int {
+	var polygons []int
+	x.rayCast(vecPoint(pt), func(i int, inside, onOutline bool) {
+		if onOutline {
+			inside = toleranceOnOutside(i)
+		}
+		if inside {
+			polygons = append(polygons, i)
+		}
+	})
+	return polygons
+}
+
+// rayCast evaluates the ray casting of Polygon.Contains for point pt
+// polygon by polygon in ascending order, and calls f with the index of
+// each polygon and whether pt lies inside or on the outline of it. Only
+// the edges near pt and the edges that can be hit by a horizontal ray from
+// pt to the right are candidates. Polygons without candidate edges neither
+// contain pt nor have pt on their outline, so f is not called for them.
+func (x *Index) rayCast(pt point, f func(i int, inside, onOutline bool)) {
+	px, py := pt.x, pt.y
+	var refs []edgeRef
+	for r := x.row(py - x.pad); r <= x.row(py+x.pad); r++ {
+		for c := x.col(px - x.pad); c < x.nx; c++ {
+			refs = append(refs, x.cells[r*x.nx+c]...)
+		}
+	}
+	slices.SortFunc(refs, compareEdgeRefs)
+	refs = slices.Compact(refs)
+	for len(refs) > 0 {
+		i := refs[0].polygon
+		n := 1
+		for n < len(refs) && refs[n].polygon == i {
+			n++
+		}
+		p := x.ps[i]
+		inside, onOutline := false, false
+		for _, ref := range refs[:n] {
+			edge := p.Edge(int(ref.vertex))
+			if onSegment(pt, edge) {
+				onOutline = true
+				break
+			}
+			if hRayIntersects(pt, edge) {
+				inside = !inside
+			}
+		}
+		f(int(i), inside, onOutline)
+		refs = refs[n:]
+	}
+}
+
+// verticesOn returns the vertices of the polygons other than the polygon
+// with index i that lie on edge e, but not on its end points.
+func (x *Index) verticesOn(e LineSeg, i int32) []geom.Vec2 {
+	var vs []geom.Vec2
+	x.segmentCells(e.A, e.B, func(c int) bool {
+		for _, ref := range x.cells[c] {
+			v := x.ps[ref.polygon][ref.vertex]
+			if ref.polygon != i && v != e.A && v != e.B && onSegment(vecPoint(v), e) {
+				vs = append(vs, v)
+			}
+		}
+		return false
+	})
+	return vs
+}
+
+// ClosestPt returns the closest point to point pt on any of the outlines of
+// the polygon set, see PolygonSet.ClosestPt. Like there, an edge of a
+// polygon with a lower index wins over an edge with the same distance of
+// a polygon with a higher index.
+func (x *Index) ClosestPt(pt geom.Vec2) geom.Vec2 {
+	best := match{pt: pt, dist: float32(math.Inf(1))}
+	bestRef := edgeRef{polygon: math.MaxInt32}
+	px, py := float64(pt.X), float64(pt.Y)
+	cx, cy := x.col(px), x.row(py)
+	// Search the cells in rings of growing distance around the cell
+	// of pt, until the unsearched cells are farther away than the best
+	// match.
+	for r := 0; ; r++ {
+		for j := cy - r; j <= cy+r; j++ {
+			if j < 0 || j >= x.ny {
+				continue
+			}
+			step := 2 * r
+			if j == cy-r || j == cy+r || r == 0 {
+				step = 1
+			}
+			for i := cx - r; i <= cx+r; i += step {
+				if i < 0 || i >= x.nx {
+					continue
+				}
+				for _, ref := range x.cells[j*x.nx+i] {
+					e := x.ps[ref.polygon].Edge(int(ref.vertex))
+					if e.ClosestPt(pt).SqDist(pt) > best.dist {
+						continue
+					}
+					var current match
+					var ok bool
+					current.pt, ok = closestAccessiblePt(e, pt, func() []geom.Vec2 {
+						return x.verticesOn(e, ref.polygon)
+					}, x.contactAt)
+					current.dist = current.pt.SqDist(pt)
+					if ok && (current.dist < best.dist ||
+						(current.dist == best.dist && compareEdgeRefs(ref, bestRef) < 0)) {
+						best, bestRef = current, ref
+					}
+				}
+			}
+		}
+		// The distance from pt to the nearest unsearched cell.
+		bound := math.Inf(1)
+		if cx-r > 0 {
+			bound = min(bound, px-(x.minX+float64(cx-r)*x.cellSize))
+		}
+		if cx+r < x.nx-1 {
+			bound = min(bound, x.minX+float64(cx+r+1)*x.cellSize-px)
+		}
+		if cy-r > 0 {
+			bound = min(bound, py-(x.minY+float64(cy-r)*x.cellSize))
+		}
+		if cy+r < x.ny-1 {
+			bound = min(bound, x.minY+float64(cy+r+1)*x.cellSize-py)
+		}
+		if math.IsInf(bound, 1) || math.Sqrt(float64(best.dist)) < bound {
+			return best.pt
+		}
+	}
+}
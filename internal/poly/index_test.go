@@ -0,0 +1,139 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly_test
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// gridPolygonSet returns a square area with n×n square holes on integer
+// coordinates, so that many queries hit vertices and edges exactly.
+func gridPolygonSet(n int) poly.PolygonSet {
+	size := float32(10*n + 10)
+	ps := poly.PolygonSet{{
+		geom.V2(0, 0), geom.V2(size, 0), geom.V2(size, size), geom.V2(0, size),
+	}}
+	for i := range n {
+		for j := range n {
+			x, y := float32(10*i+10), float32(10*j+10)
+			ps = append(ps, poly.Polygon{
+				geom.V2(x, y), geom.V2(x, y+5), geom.V2(x+5, y+5), geom.V2(x+5, y),
+			})
+		}
+	}
+	return ps
+}
+
+func randomPt(r *rand.Rand, size float32) geom.Vec2 {
+	if r.IntN(2) == 0 {
+		// on the integer grid
+		return geom.V2(float32(r.IntN(int(size)+20)-10), float32(r.IntN(int(size)+20)-10))
+	}
+	return geom.V2(r.Float32()*(size+20)-10, r.Float32()*(size+20)-10)
+}
+
+func TestIndexMatchesPolygonSet(t *testing.T) {
+	sets := []poly.PolygonSet{
+		{},
+		{{}},
+		twoSquaresNested,
+		threeSquaresNested,
+		twoDisjointSquares,
+		touchingSquares,
+		gridPolygonSet(1),
+		gridPolygonSet(6),
+	}
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, ps := range sets {
+		testIndexQueries(t, r, poly.NewIndex(ps), ps)
+	}
+}
+
+func TestIndexInsertRemove(t *testing.T) {
+	grid := gridPolygonSet(6)
+	idx := poly.NewIndex(grid[:20])
+	for _, p := range grid[20:] {
+		idx.Insert(p)
+	}
+	// An obstacle partially outside of the grid.
+	obstacle := poly.Polygon{geom.V2(60, 60), geom.V2(80, 60), geom.V2(80, 80), geom.V2(60, 80)}
+	idx.Insert(obstacle)
+	idx.Remove(36)
+	idx.Remove(3)
+	idx.Remove(0)
+	want := slices.Concat(grid[1:3], grid[4:36], poly.PolygonSet{obstacle})
+	if got := idx.PolygonSet(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("PolygonSet()\n got: %v\nwant: %v", got, want)
+	}
+	testIndexQueries(t, rand.New(rand.NewPCG(1, 2)), idx, want)
+}
+
+// testIndexQueries compares the results of the queries of index idx with
+// those of the methods of polygon set ps for random points and line
+// segments.
+func testIndexQueries(t *testing.T, r *rand.Rand, idx *poly.Index, ps poly.PolygonSet) {
+	t.Helper()
+	for range 2000 {
+		a, b := randomPt(r, 80), randomPt(r, 80)
+		if got, want := idx.Contains(a), ps.Contains(a); got != want {
+			t.Errorf("PolygonSet: %v\nIndex.Contains(%v) = %v, want: %v", ps, a, got, want)
+		}
+		even := func(i int) bool { return i%2 == 0 }
+		var containing []int
+		for i, p := range ps {
+			if len(p) > 0 && p.Contains(a, even(i)) {
+				containing = append(containing, i)
+			}
+		}
+		if got := idx.Containing(a, even); !reflect.DeepEqual(got, containing) {
+			t.Errorf("PolygonSet: %v\nIndex.Containing(%v) = %v, want: %v", ps, a, got, containing)
+		}
+		if got, want := idx.ClosestPt(a), ps.ClosestPt(a); got != want {
+			t.Errorf("PolygonSet: %v\nIndex.ClosestPt(%v) = %v, want: %v", ps, a, got, want)
+		}
+		ls := poly.LineSeg{A: a, B: b}
+		want := false
+		for _, p := range ps {
+			if p.IsCrossedBy(ls) {
+				want = true
+			}
+		}
+		if got := idx.IsCrossedBy(ls); got != want {
+			t.Errorf("PolygonSet: %v\nIndex.IsCrossedBy(%v) = %v, want: %v", ps, ls, got, want)
+		}
+	}
+	// Line segments between vertices, which may pass through contacts.
+	var vertices []geom.Vec2
+	for _, p := range ps {
+		vertices = append(vertices, p...)
+	}
+	for range 2000 {
+		if len(vertices) == 0 {
+			break
+		}
+		a := vertices[r.IntN(len(vertices))]
+		b := vertices[r.IntN(len(vertices))]
+		ls := poly.LineSeg{A: a, B: b}
+		if got, want := idx.PassesContact(ls), ps.PassesContact(ls); got != want {
+			t.Errorf("PolygonSet: %v\nIndex.PassesContact(%v) = %v, want: %v", ps, ls, got, want)
+		}
+		if got, want := idx.Contains(a), ps.Contains(a); got != want {
+			t.Errorf("PolygonSet: %v\nIndex.Contains(%v) = %v, want: %v", ps, a, got, want)
+		}
+	}
+}
+
+func TestIndexPolygonSet(t *testing.T) {
+	idx := poly.NewIndex(twoSquaresNested)
+	if got := idx.PolygonSet(); len(got) != 2 || &got[0][0] != &twoSquaresNested[0][0] {
+		t.Errorf("PolygonSet() = %v, want the indexed polygon set", got)
+	}
+}
@@ -34,24 +34,35 @@ func (l LineSeg) ClosestPt(p geom.Vec2) geom.Vec2 {
 }
 
 // Crosses returns true if line segments l and m cross each other,
-// otherwise false.
+// otherwise false. Line segments that only touch each other, or that are
+// collinear, don't cross. The result is exact, see orientation.
 func (l LineSeg) Crosses(m LineSeg) bool {
-	u := l.A.Sub(l.B)
-	v := m.A.Sub(m.B)
-	D := u.CrossLen(v)
-	if D == 0 {
-		// The line segments are parallel.
-		return false
-	}
-	w := l.B.Sub(m.B)
-	n1 := u.CrossLen(w)
-	n2 := v.CrossLen(w)
-	if n1 == 0 || n2 == 0 {
-		return false
+	return orientation(l.A, l.B, m.A)*orientation(l.A, l.B, m.B) < 0 &&
+		orientation(m.A, m.B, l.A)*orientation(m.A, m.B, l.B) < 0
+}
+
+// Intersects returns true if line segments l and m have at least one point
+// in common, otherwise false. Unlike Crosses, it also returns true for line
+// segments that only touch each other or that overlap.
+func (l LineSeg) Intersects(m LineSeg) bool {
+	o1 := orientation(l.A, l.B, m.A)
+	o2 := orientation(l.A, l.B, m.B)
+	o3 := orientation(m.A, m.B, l.A)
+	o4 := orientation(m.A, m.B, l.B)
+	if o1 != o2 && o3 != o4 {
+		return true
 	}
-	r := n1 / D
-	s := n2 / D
-	return (0 < r && r < 1) && (0 < s && s < 1)
+	return (o1 == 0 && inBounds(vecPoint(m.A), l)) ||
+		(o2 == 0 && inBounds(vecPoint(m.B), l)) ||
+		(o3 == 0 && inBounds(vecPoint(l.A), m)) ||
+		(o4 == 0 && inBounds(vecPoint(l.B), m))
+}
+
+// inBounds checks if point p lies within the bounding box of line segment l.
+func inBounds(p point, l LineSeg) bool {
+	a, b := vecPoint(l.A), vecPoint(l.B)
+	return min(a.x, b.x) <= p.x && p.x <= max(a.x, b.x) &&
+		min(a.y, b.y) <= p.y && p.y <= max(a.y, b.y)
 }
 
 // Middle returns the middle of the line segment.
@@ -94,20 +105,7 @@ func (l Line) Intersect(m Line) (p geom.Vec2, exists bool) {
 
 // Side reports on which side of the line point p is.
 // It is +1 on one side, -1 on the other side, and 0 on the line.
+// The result is exact, see orientation.
 func (l Line) Side(p geom.Vec2) int {
-	ap := p.Sub(l.Seg.A)
-	ab := l.Seg.B.Sub(l.Seg.A)
-	return sgn(ap.CrossLen(ab))
-}
-
-// sgn returns -1 if x is negative, +1 if x is positive, and 0 otherwise.
-func sgn(x float32) int {
-	switch {
-	case x < 0:
-		return -1
-	case x > 0:
-		return +1
-	default:
-		return 0
-	}
+	return -orientation(l.Seg.A, l.Seg.B, p)
 }
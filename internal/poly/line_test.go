@@ -121,6 +121,18 @@ func TestLineSegCrosses(t *testing.T) {
 			poly.LineSeg{A: geom.V2(3, 2), B: geom.V2(3, -2)},
 			true,
 		},
+		{
+			"nearly collinear line segments with large coordinates do cross",
+			poly.LineSeg{A: geom.V2(0, 0), B: geom.V2(16777215, 16777213)},
+			poly.LineSeg{A: geom.V2(16777214, 16777212), B: geom.V2(16777212, 16777213)},
+			true,
+		},
+		{
+			"line segment touching another one with large coordinates doesn't cross",
+			poly.LineSeg{A: geom.V2(0, 0), B: geom.V2(16777214, 16777212)},
+			poly.LineSeg{A: geom.V2(8388607, 8388606), B: geom.V2(8388600, 8388610)},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -131,6 +143,71 @@ func TestLineSegCrosses(t *testing.T) {
 	}
 }
 
+func TestLineSegIntersects(t *testing.T) {
+	tests := []struct {
+		name string
+		l1   poly.LineSeg
+		l2   poly.LineSeg
+		want bool
+	}{
+		{
+			"line segments on top of each other intersect",
+			poly.LineSeg{A: geom.V2(-3, 2), B: geom.V2(3, 2)},
+			poly.LineSeg{A: geom.V2(-3, 2), B: geom.V2(3, 2)},
+			true,
+		},
+		{
+			"overlapping collinear line segments intersect",
+			poly.LineSeg{A: geom.V2(0, 0), B: geom.V2(4, 4)},
+			poly.LineSeg{A: geom.V2(2, 2), B: geom.V2(6, 6)},
+			true,
+		},
+		{
+			"collinear line segments with gap don't intersect",
+			poly.LineSeg{A: geom.V2(0, 0), B: geom.V2(4, 4)},
+			poly.LineSeg{A: geom.V2(5, 5), B: geom.V2(6, 6)},
+			false,
+		},
+		{
+			"parallel line segments don't intersect",
+			poly.LineSeg{A: geom.V2(1, 3), B: geom.V2(5, 7)},
+			poly.LineSeg{A: geom.V2(1, 4), B: geom.V2(5, 8)},
+			false,
+		},
+		{
+			"perpendicular line segments with gap don't intersect",
+			poly.LineSeg{A: geom.V2(0, 0), B: geom.V2(4, 0)},
+			poly.LineSeg{A: geom.V2(5, 2), B: geom.V2(5, -2)},
+			false,
+		},
+		{
+			"perpendicular line segments touching each other intersect",
+			poly.LineSeg{A: geom.V2(0, 0), B: geom.V2(4, 0)},
+			poly.LineSeg{A: geom.V2(4, 2), B: geom.V2(4, -2)},
+			true,
+		},
+		{
+			"line segments with common end point intersect",
+			poly.LineSeg{A: geom.V2(3, 2), B: geom.V2(5, 3)},
+			poly.LineSeg{A: geom.V2(3, 2), B: geom.V2(5, 7)},
+			true,
+		},
+		{
+			"X-shaped line segments intersect",
+			poly.LineSeg{A: geom.V2(-2, -1), B: geom.V2(2, 1)},
+			poly.LineSeg{A: geom.V2(-2, 1), B: geom.V2(2, -1)},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if b := tt.l1.Intersects(tt.l2); b != tt.want {
+				t.Errorf("line segment (%v).Intersects(%v) = %v, want %v", tt.l1, tt.l2, b, tt.want)
+			}
+		})
+	}
+}
+
 func TestLineSegMiddle(t *testing.T) {
 	tests := []struct {
 		lineSeg poly.LineSeg
@@ -287,6 +364,24 @@ func TestLineSide(t *testing.T) {
 			p:    geom.V2(2, 2),
 			want: 0,
 		},
+		{
+			name: "point close to line with large coordinates",
+			l: poly.Line{Seg: poly.LineSeg{
+				A: geom.V2(0, 0),
+				B: geom.V2(16777215, 16777213),
+			}},
+			p:    geom.V2(16777214, 16777212),
+			want: +1,
+		},
+		{
+			name: "point on line with large coordinates",
+			l: poly.Line{Seg: poly.LineSeg{
+				A: geom.V2(0, 0),
+				B: geom.V2(16777214, 16777212),
+			}},
+			p:    geom.V2(8388607, 8388606),
+			want: 0,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
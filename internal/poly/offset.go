@@ -0,0 +1,119 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly
+
+import (
+	"math"
+
+	"github.com/fzipp/geom"
+)
+
+// Offset returns a new polygon whose edges are moved by distance d along
+// their outward normals, i.e. the polygon is inflated for positive d and
+// shrunk for negative d.
+//
+// At vertices where the moved edges meet, the new vertex is the
+// intersection point of the moved edges. At vertices where the moved edges
+// move apart, the gap is closed by the tangents of a circular arc of
+// radius |d| around the original vertex, each of which spans an angle of at
+// most maxAngle radians. Thus the new polygon keeps a distance of at
+// least |d| from the original polygon. A maxAngle of π/8 gives a smooth
+// round corner, a maxAngle of 2π/3 gives a mitred corner, which is
+// bevelled if the miter would be longer than 2|d|.
+//
+// Consecutive duplicate vertices are ignored. Offset does not resolve
+// self-intersections of the resulting polygon, which can occur if the
+// polygon has features narrower than 2|d|.
+func (p Polygon) Offset(d, maxAngle float32) Polygon {
+	vs := withoutDuplicates(p)
+	n := len(vs)
+	if n < 3 || d == 0 {
+		return vs
+	}
+	orientation := 1.0
+	if vs.SignedArea() < 0 {
+		orientation = -1
+	}
+	dist := float64(d)
+	side := math.Copysign(1, dist)
+	res := make(Polygon, 0, n)
+	for i, v := range vs {
+		prev := vs[vs.WrapIndex(i-1)]
+		next := vs[vs.WrapIndex(i+1)]
+		n1 := outwardNormal(prev, v, orientation)
+		n2 := outwardNormal(v, next, orientation)
+		turn := orientation * cross(sub(v, prev), sub(next, v))
+		dot := n1[0]*n2[0] + n1[1]*n2[1]
+		if turn*side > 0 {
+			// The moved edges move apart: close the gap with an arc.
+			res = append(res, arc(v, n1, n2, dist, float64(maxAngle))...)
+			continue
+		}
+		if 1+dot < 1e-9 {
+			// The edges fold back onto each other.
+			res = append(res, add(v, scale(n1, dist)), add(v, scale(n2, dist)))
+			continue
+		}
+		// The moved edges meet: use their intersection point.
+		m := scale([2]float64{n1[0] + n2[0], n1[1] + n2[1]}, dist/(1+dot))
+		res = append(res, add(v, m))
+	}
+	return res
+}
+
+// arc returns the vertices of the tangents of a circular arc of radius |d|
+// around v from normal n1 to normal n2. The arc is divided into segments
+// that span at most maxAngle radians.
+func arc(v geom.Vec2, n1, n2 [2]float64, d, maxAngle float64) []geom.Vec2 {
+	side := math.Copysign(1, d)
+	a1 := math.Atan2(side*n1[1], side*n1[0])
+	a2 := math.Atan2(side*n2[1], side*n2[0])
+	theta := math.Remainder(a2-a1, 2*math.Pi)
+	k := max(1, int(math.Ceil(math.Abs(theta)/maxAngle)))
+	step := theta / float64(k)
+	r := math.Abs(d) / math.Cos(step/2)
+	vs := make([]geom.Vec2, k)
+	for j := range vs {
+		a := a1 + (float64(j)+0.5)*step
+		vs[j] = add(v, [2]float64{r * math.Cos(a), r * math.Sin(a)})
+	}
+	return vs
+}
+
+// outwardNormal returns the unit normal vector of the edge from a to b
+// that points to the outside of a polygon with the given orientation.
+func outwardNormal(a, b geom.Vec2, orientation float64) [2]float64 {
+	e := sub(b, a)
+	l := math.Hypot(e[0], e[1])
+	return [2]float64{orientation * e[1] / l, -orientation * e[0] / l}
+}
+
+// withoutDuplicates returns a copy of polygon p without consecutive
+// duplicate vertices.
+func withoutDuplicates(p Polygon) Polygon {
+	res := make(Polygon, 0, len(p))
+	for i, v := range p {
+		if v != p[p.WrapIndex(i+1)] {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func sub(a, b geom.Vec2) [2]float64 {
+	return [2]float64{float64(a.X) - float64(b.X), float64(a.Y) - float64(b.Y)}
+}
+
+func add(v geom.Vec2, w [2]float64) geom.Vec2 {
+	return geom.V2(float32(float64(v.X)+w[0]), float32(float64(v.Y)+w[1]))
+}
+
+func scale(v [2]float64, s float64) [2]float64 {
+	return [2]float64{v[0] * s, v[1] * s}
+}
+
+func cross(v, w [2]float64) float64 {
+	return v[0]*w[1] - v[1]*w[0]
+}
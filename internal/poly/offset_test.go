@@ -0,0 +1,146 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+func TestPolygonOffset(t *testing.T) {
+	square := poly.Polygon{
+		geom.V2(0, 0),
+		geom.V2(40, 0),
+		geom.V2(40, 40),
+		geom.V2(0, 40),
+	}
+	tests := []struct {
+		name     string
+		polygon  poly.Polygon
+		d        float32
+		maxAngle float32
+		want     poly.Polygon
+	}{
+		{
+			name:     "Shrink square",
+			polygon:  square,
+			d:        -2,
+			maxAngle: 2 * math.Pi / 3,
+			want: poly.Polygon{
+				geom.V2(2, 2),
+				geom.V2(38, 2),
+				geom.V2(38, 38),
+				geom.V2(2, 38),
+			},
+		},
+		{
+			name:     "Inflate square with miter",
+			polygon:  square,
+			d:        2,
+			maxAngle: 2 * math.Pi / 3,
+			want: poly.Polygon{
+				geom.V2(-2, -2),
+				geom.V2(42, -2),
+				geom.V2(42, 42),
+				geom.V2(-2, 42),
+			},
+		},
+		{
+			name: "Inflate square with opposite winding order and duplicate vertex",
+			polygon: poly.Polygon{
+				geom.V2(0, 0),
+				geom.V2(0, 40),
+				geom.V2(40, 40),
+				geom.V2(40, 40),
+				geom.V2(40, 0),
+			},
+			d:        2,
+			maxAngle: 2 * math.Pi / 3,
+			want: poly.Polygon{
+				geom.V2(-2, -2),
+				geom.V2(-2, 42),
+				geom.V2(42, 42),
+				geom.V2(42, -2),
+			},
+		},
+		{
+			name: "Shrink U shape with bevelled concave corner",
+			polygon: poly.Polygon{
+				geom.V2(0, 0),
+				geom.V2(10, 0),
+				geom.V2(10, 10),
+				geom.V2(20, 10),
+				geom.V2(20, 0),
+				geom.V2(30, 0),
+				geom.V2(30, 20),
+				geom.V2(0, 20),
+			},
+			d:        -1,
+			maxAngle: math.Pi / 4,
+			want: poly.Polygon{
+				geom.V2(1, 1),
+				geom.V2(9, 1),
+				geom.V2(9, 10+math.Sqrt2-1),
+				geom.V2(11-math.Sqrt2, 11),
+				geom.V2(19+math.Sqrt2, 11),
+				geom.V2(21, 10+math.Sqrt2-1),
+				geom.V2(21, 1),
+				geom.V2(29, 1),
+				geom.V2(29, 19),
+				geom.V2(1, 19),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.polygon.Offset(tt.d, tt.maxAngle)
+			if !nearEqPolygon(got, tt.want) {
+				t.Errorf("%v.Offset(%v, %v)\n got: %v\nwant: %v", tt.polygon, tt.d, tt.maxAngle, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonOffsetRoundKeepsDistance(t *testing.T) {
+	diamond := poly.Polygon{
+		geom.V2(20, 10),
+		geom.V2(30, 20),
+		geom.V2(20, 30),
+		geom.V2(10, 20),
+	}
+	const d = 3
+	got := diamond.Offset(d, math.Pi/8)
+	if len(got) <= len(diamond) {
+		t.Fatalf("expected rounded corners with additional vertices, got %v", got)
+	}
+	for i := range got {
+		edge := got.Edge(i)
+		for s := float32(0); s <= 1; s += 0.05 {
+			pt := edge.A.Lerp(edge.B, s)
+			if dist := diamond.ClosestPt(pt).Dist(pt); dist < d-1e-4 {
+				t.Errorf("point %v of offset polygon has distance %v to original polygon, want at least %v", pt, dist, d)
+			}
+		}
+	}
+}
+
+func nearEqPolygon(p, q poly.Polygon) bool {
+	if len(p) != len(q) {
+		return false
+	}
+	for i := range p {
+		if !nearEq(p[i], q[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func nearEq(v, w geom.Vec2) bool {
+	return math.Abs(float64(v.X-w.X)) < 1e-4 && math.Abs(float64(v.Y-w.Y)) < 1e-4
+}
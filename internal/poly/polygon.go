@@ -6,7 +6,11 @@
 // in support of the pathfind package.
 package poly
 
-import "github.com/fzipp/geom"
+import (
+	"slices"
+
+	"github.com/fzipp/geom"
+)
 
 // A Polygon is a polygon in 2-dimensional space, represented as a slice
 // of its vertices.
@@ -33,7 +37,20 @@ func (p Polygon) Edge(i int) LineSeg {
 }
 
 // Contains checks if point pt lies inside the boundary of polygon p.
+// If pt lies on the boundary, the result is toleranceOnOutside.
 func (p Polygon) Contains(pt geom.Vec2, toleranceOnOutside bool) bool {
+	return p.contains(vecPoint(pt), toleranceOnOutside)
+}
+
+// ContainsMiddle checks if the middle of line segment ls lies inside the
+// boundary of polygon p, see Contains. Unlike Contains(ls.Middle(), ...)
+// it uses the exact middle, which is not always representable in float32,
+// so that it reliably recognizes the middle of an edge as on the boundary.
+func (p Polygon) ContainsMiddle(ls LineSeg, toleranceOnOutside bool) bool {
+	return p.contains(middle(ls), toleranceOnOutside)
+}
+
+func (p Polygon) contains(pt point, toleranceOnOutside bool) bool {
 	// Ray casting algorithm: if a ray from point pt in any direction
 	// (in our case horizontally to the east) crosses an odd number
 	// of polygon edges, then pt lies inside the polygon, otherwise
@@ -41,7 +58,7 @@ func (p Polygon) Contains(pt geom.Vec2, toleranceOnOutside bool) bool {
 	in := false
 	for i := range p {
 		edge := p.Edge(i)
-		if edge.ClosestPt(pt).NearEq(pt) {
+		if onSegment(pt, edge) {
 			return toleranceOnOutside
 		}
 		if hRayIntersects(pt, edge) {
@@ -53,18 +70,36 @@ func (p Polygon) Contains(pt geom.Vec2, toleranceOnOutside bool) bool {
 
 // IsCrossedBy checks if any side of polygon p is crossed by line segment ls.
 func (p Polygon) IsCrossedBy(ls LineSeg) bool {
+	for i := range p {
+		if p.isCrossedAt(ls, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInside checks if polygon p lies inside polygon q. The polygons may
+// touch, i.e. vertices and edges of p may lie on the boundary of q.
+func (p Polygon) IsInside(q Polygon) bool {
 	for i, v := range p {
-		if ls.A == v || ls.B == v {
-			continue
+		if !q.Contains(v, true) || !q.ContainsMiddle(p.Edge(i), true) {
+			return false
 		}
-		if ls.Crosses(p.Edge(i)) {
-			return true
+	}
+	for _, v := range q {
+		if p.Contains(v, false) {
+			return false
 		}
-		if ls.ClosestPt(v) == v {
-			prev := p[p.WrapIndex(i-1)]
-			next := p[p.WrapIndex(i+1)]
-			l := Line{ls}
-			if l.Side(prev) != l.Side(next) {
+	}
+	return !p.CrossesEdgeOf(q)
+}
+
+// CrossesEdgeOf checks if an edge of polygon p crosses an edge of polygon
+// q at a single point in the interior of both edges.
+func (p Polygon) CrossesEdgeOf(q Polygon) bool {
+	for i := range p {
+		for j := range q {
+			if p.Edge(i).Crosses(q.Edge(j)) {
 				return true
 			}
 		}
@@ -72,26 +107,49 @@ func (p Polygon) IsCrossedBy(ls LineSeg) bool {
 	return false
 }
 
+// isCrossedAt checks if line segment ls crosses the edge with index i of
+// polygon p, or passes through its start vertex from one side of the
+// polygon boundary to the other.
+func (p Polygon) isCrossedAt(ls LineSeg, i int) bool {
+	v := p[i]
+	if ls.A == v || ls.B == v {
+		return false
+	}
+	if ls.Crosses(p.Edge(i)) {
+		return true
+	}
+	if onSegment(vecPoint(v), ls) {
+		prev := p[p.WrapIndex(i-1)]
+		next := p[p.WrapIndex(i+1)]
+		l := Line{ls}
+		if l.Side(prev) != l.Side(next) {
+			return true
+		}
+	}
+	return false
+}
+
 // hRayIntersects checks if a horizontal ray from point p to the right
-// intersects a line segment.
-func hRayIntersects(p geom.Vec2, ls LineSeg) bool {
+// intersects a line segment. The result is exact, see orientation.
+func hRayIntersects(p point, ls LineSeg) bool {
 	if !hLineIntersects(p, ls) {
 		return false
 	}
-	hRay := Line{LineSeg{p, geom.V2(p.X+1, p.Y)}}
-	q, _ := hRay.Intersect(Line{ls})
-
 	// Checks whether p is on the left-hand side of the line segment
-	// by comparing p.X to the x coordinate of the intersection point q.
-	return p.X <= q.X
+	// or on the line segment, as seen in upward direction.
+	lower, upper := ls.A, ls.B
+	if lower.Y > upper.Y {
+		lower, upper = upper, lower
+	}
+	return orient(vecPoint(lower), vecPoint(upper), p) >= 0
 }
 
 // hLineIntersects checks if a horizontal line through point p intersects a
 // line segment.
-func hLineIntersects(p geom.Vec2, ls LineSeg) bool {
+func hLineIntersects(p point, ls LineSeg) bool {
 	// True, if each end point of the line segment lies on a
 	// different side of the horizontal line.
-	return (ls.A.Y >= p.Y) != (ls.B.Y >= p.Y)
+	return (float64(ls.A.Y) >= p.y) != (float64(ls.B.Y) >= p.y)
 }
 
 // match is a helper structure for closest point algorithms. Used to hold the
@@ -119,14 +177,85 @@ func (p Polygon) ClosestPt(pt geom.Vec2) geom.Vec2 {
 }
 
 // IsConcaveAt checks, whether the vertex with index i of polygon p is
-// concave or not.
+// concave or not. It assumes that p has positive signed area, see
+// IsReflexAt for polygons of either winding order.
 func (p Polygon) IsConcaveAt(i int) bool {
-	v := p[i]
+	return p.IsReflexAt(i, true)
+}
+
+// IsReflexAt checks whether the interior angle of polygon p at the vertex
+// with index i is greater than 180°. The parameter positive tells the
+// winding order of p, i.e. whether its signed area is positive, which the
+// caller determines once for all vertices. Collinear vertices are never
+// reflex.
+func (p Polygon) IsReflexAt(i int, positive bool) bool {
 	prev := p[p.WrapIndex(i-1)]
 	next := p[p.WrapIndex(i+1)]
-	left := v.Sub(prev)
-	right := next.Sub(v)
-	return left.CrossLen(right) < 0
+	o := orientation(prev, p[i], next)
+	if positive {
+		return o < 0
+	}
+	return o > 0
+}
+
+// SelfIntersection finds two non-adjacent edges of polygon p that
+// intersect each other, or two adjacent edges that overlap. It returns the
+// indices i < j of the edges and true if such edges exist, otherwise false.
+func (p Polygon) SelfIntersection() (i, j int, found bool) {
+	n := len(p)
+	for i = range n {
+		ei := p.Edge(i)
+		for j = i + 1; j < n; j++ {
+			ej := p.Edge(j)
+			if j == i+1 || (i == 0 && j == n-1) {
+				if overlapsAdjacent(ei, ej) {
+					return i, j, true
+				}
+				continue
+			}
+			if ei.Intersects(ej) {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// overlapsAdjacent checks if two adjacent edges, which share an end point,
+// lie on top of each other, i.e. the polygon boundary turns back on itself.
+func overlapsAdjacent(e1, e2 LineSeg) bool {
+	var shared, a, b geom.Vec2
+	switch {
+	case e1.B == e2.A:
+		shared, a, b = e1.B, e1.A, e2.B
+	case e2.B == e1.A:
+		shared, a, b = e1.A, e1.B, e2.A
+	default:
+		return false
+	}
+	u, v := a.Sub(shared), b.Sub(shared)
+	return orientation(shared, a, b) == 0 &&
+		float64(u.X)*float64(v.X)+float64(u.Y)*float64(v.Y) > 0
+}
+
+// Normalize reverses the vertices of polygon p in place if its signed area
+// is negative, so that it has the winding order that IsConcaveAt expects.
+func (p Polygon) Normalize() {
+	if p.SignedArea() < 0 {
+		slices.Reverse(p)
+	}
+}
+
+// SignedArea returns the area of polygon p. The sign of the area depends on
+// the winding order of the vertices. It is positive for the winding order
+// that IsConcaveAt expects.
+func (p Polygon) SignedArea() float32 {
+	var a float64
+	for i, v := range p {
+		w := p[p.WrapIndex(i+1)]
+		a += float64(v.X)*float64(w.Y) - float64(w.X)*float64(v.Y)
+	}
+	return float32(a / 2)
 }
 
 // WrapIndex returns an index based on i that can be safely used to access an
@@ -6,6 +6,7 @@ package poly_test
 
 import (
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/fzipp/geom"
@@ -134,6 +135,8 @@ func TestPolygonContains(t *testing.T) {
 		//   +   x
 		//   +---+
 		{polygonSquare, geom.V2(10, 5), true, true},
+		// A point very close to, but not on a long edge.
+		{poly.Polygon{geom.V2(0, 0), geom.V2(200001, 2), geom.V2(0, 100000)}, geom.V2(1, 0), true, false},
 	}
 	for _, tt := range tests {
 		got := tt.polygon.Contains(tt.point, tt.toleranceOnOutside)
@@ -144,6 +147,51 @@ func TestPolygonContains(t *testing.T) {
 	}
 }
 
+func TestPolygonContainsMiddle(t *testing.T) {
+	// The middle of the right side, x = 1+2^-24, is not representable as
+	// float32.
+	polygon := poly.Polygon{geom.V2(0, 1), geom.V2(1, 1), geom.V2(1.0000001, 3), geom.V2(0, 3)}
+	tests := []struct {
+		lineSeg            poly.LineSeg
+		toleranceOnOutside bool
+		want               bool
+	}{
+		{polygon.Edge(1), true, true},
+		{polygon.Edge(1), false, false},
+		{poly.LineSeg{A: geom.V2(0, 1), B: geom.V2(1.0000001, 3)}, false, true},
+		{poly.LineSeg{A: geom.V2(1, 1), B: geom.V2(3, 3)}, true, false},
+	}
+	for _, tt := range tests {
+		got := polygon.ContainsMiddle(tt.lineSeg, tt.toleranceOnOutside)
+		if got != tt.want {
+			t.Errorf("Polygon: %v\nContainsMiddle(ls: %v, toleranceOnOutside: %v) = %v, want: %v",
+				polygon, tt.lineSeg, tt.toleranceOnOutside, got, tt.want)
+		}
+	}
+}
+
+func TestPolygonIsInside(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon poly.Polygon
+		want    bool
+	}{
+		{"Inside", poly.ParsePolygon("2,2,4,2,4,4,2,4"), true},
+		{"Touching the outline", poly.ParsePolygon("4,0,6,0,7,3,3,3"), true},
+		{"Same outline", polygonSquare, true},
+		{"Outside", poly.ParsePolygon("12,2,14,2,14,4,12,4"), false},
+		{"Crossing the outline", poly.ParsePolygon("8,4,12,4,12,8,8,8"), false},
+		{"Around", poly.ParsePolygon("-1,-1,11,-1,11,11,-1,11"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.polygon.IsInside(polygonSquare); got != tt.want {
+				t.Errorf("Polygon: %v\nIsInside(%v) = %v, want: %v", tt.polygon, polygonSquare, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPolygonIsCrossedBy(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -467,6 +515,101 @@ func TestPolygonIsConcaveAt(t *testing.T) {
 	}
 }
 
+func TestPolygonIsReflexAt(t *testing.T) {
+	for _, p := range []poly.Polygon{polygonSquare, polygonSlopedU, polygonK} {
+		r := slices.Clone(p)
+		slices.Reverse(r)
+		for i := range p {
+			want := p.IsConcaveAt(i)
+			if got := p.IsReflexAt(i, true); got != want {
+				t.Errorf("Polygon: %v\nIsReflexAt(%d, true) = %v, want: %v", p, i, got, want)
+			}
+			j := len(p) - 1 - i
+			if got := r.IsReflexAt(j, false); got != want {
+				t.Errorf("Polygon: %v\nIsReflexAt(%d, false) = %v, want: %v", r, j, got, want)
+			}
+		}
+	}
+	// Collinear vertices are not reflex in either winding order.
+	collinear := poly.Polygon{geom.V2(0, 0), geom.V2(5, 0), geom.V2(10, 0), geom.V2(10, 10)}
+	for _, positive := range []bool{true, false} {
+		if collinear.IsReflexAt(1, positive) {
+			t.Errorf("Polygon: %v\nIsReflexAt(1, %v) = true, want: false", collinear, positive)
+		}
+	}
+}
+
+func TestPolygonNormalize(t *testing.T) {
+	r := slices.Clone(polygonSlopedU)
+	slices.Reverse(r)
+	r.Normalize()
+	if r.SignedArea() <= 0 {
+		t.Errorf("Normalize: got signed area %g, want > 0", r.SignedArea())
+	}
+	p := slices.Clone(polygonSlopedU)
+	p.Normalize()
+	if !slices.Equal(p, polygonSlopedU) {
+		t.Errorf("Normalize changed polygon %v with positive signed area to %v", polygonSlopedU, p)
+	}
+}
+
+func TestPolygonSelfIntersection(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon poly.Polygon
+		wantI   int
+		wantJ   int
+		want    bool
+	}{
+		{"square", polygonSquare, 0, 0, false},
+		{"sloped U", polygonSlopedU, 0, 0, false},
+		{"K", polygonK, 0, 0, false},
+		{
+			"bow tie",
+			poly.Polygon{geom.V2(0, 0), geom.V2(10, 10), geom.V2(10, 0), geom.V2(0, 10)},
+			0, 2, true,
+		},
+		{
+			"vertex touching another edge",
+			poly.Polygon{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(5, 0), geom.V2(0, 10)},
+			0, 2, true,
+		},
+		{
+			"boundary turning back on itself",
+			poly.Polygon{geom.V2(0, 0), geom.V2(10, 0), geom.V2(5, 0), geom.V2(5, 5)},
+			0, 1, true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, j, found := tt.polygon.SelfIntersection()
+			if found != tt.want || i != tt.wantI || j != tt.wantJ {
+				t.Errorf("Polygon: %v\nSelfIntersection() = %v, %v, %v, want: %v, %v, %v",
+					tt.polygon, i, j, found, tt.wantI, tt.wantJ, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonSignedArea(t *testing.T) {
+	tests := []struct {
+		polygon poly.Polygon
+		want    float32
+	}{
+		{polygonSquare, 100},
+		{polygonK, 300},
+		{poly.Polygon{geom.V2(0, 0), geom.V2(0, 10), geom.V2(10, 0)}, -50},
+		{poly.Polygon{geom.V2(0, 0), geom.V2(10, 0)}, 0},
+	}
+	for _, tt := range tests {
+		got := tt.polygon.SignedArea()
+		if got != tt.want {
+			t.Errorf("Polygon: %v\nSignedArea() = %v, want: %v",
+				tt.polygon, got, tt.want)
+		}
+	}
+}
+
 func TestPolygonWrapIndex(t *testing.T) {
 	tests := []struct {
 		n    int
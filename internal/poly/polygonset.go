@@ -4,7 +4,12 @@
 
 package poly
 
-import "github.com/fzipp/geom"
+import (
+	"math"
+	"slices"
+
+	"github.com/fzipp/geom"
+)
 
 // A PolygonSet represents multiple polygons.
 type PolygonSet []Polygon
@@ -20,9 +25,24 @@ func ParsePolygons(coords []string) PolygonSet {
 	return ps
 }
 
+// Normalize reverses the vertices of the polygons of polygon set ps in place
+// where necessary, so that all of them have positive signed area. Since
+// holes are determined by nesting, this doesn't change the accessible area.
+func (ps PolygonSet) Normalize() {
+	for _, p := range ps {
+		p.Normalize()
+	}
+}
+
 // Contains checks if point pt lies inside the boundaries of a polygon set.
-// Overlapping polygons can form holes and islands.
+// Overlapping polygons can form holes and islands. A point on an outline
+// is inside, unless the outlines of other polygons touch it there, so that
+// it is the meeting point of two or more separate parts of the area, or
+// doesn't border on any of it, like a point on an edge shared by two holes.
 func (ps PolygonSet) Contains(pt geom.Vec2) bool {
+	if c := ps.contactAt(vecPoint(pt)); c != nil {
+		return c.freeCount() == 1
+	}
 	in := false
 	for _, p := range ps {
 		if p.Contains(pt, !in) {
@@ -32,18 +52,86 @@ func (ps PolygonSet) Contains(pt geom.Vec2) bool {
 	return in
 }
 
+// PassesContact checks if line segment ls passes through a point where the
+// outlines of two or more polygons touch, from one side of the contact to
+// another. The sides are the sectors around the contact that are inside
+// the boundaries of the polygon set. If the contact is an end point of ls,
+// ls must come from the side where a path can bend around the contact:
+// the only side, or the only one with an angle of at least 180°, so that
+// paths joined at a contact don't pass from one side to another either.
+func (ps PolygonSet) PassesContact(ls LineSeg) bool {
+	var seen []geom.Vec2
+	for _, p := range ps {
+		for _, v := range p {
+			if slices.Contains(seen, v) || !onSegment(vecPoint(v), ls) {
+				continue
+			}
+			seen = append(seen, v)
+			if c := ps.contactAt(vecPoint(v)); c != nil && !c.passes(ls) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contactAt returns the contact at point v, or nil if v doesn't lie on the
+// outlines of at least two polygons.
+func (ps PolygonSet) contactAt(v point) *contact {
+	var wedges []wedge
+	polygons := 0
+	base := false
+	for _, p := range ps {
+		positive := p.SignedArea() > 0
+		n := len(wedges)
+		for i := range p {
+			if w, ok := p.wedgeAt(v, i, positive); ok {
+				wedges = append(wedges, w)
+			}
+		}
+		if len(wedges) > n {
+			polygons++
+		} else if p.contains(v, false) {
+			base = !base
+		}
+	}
+	if polygons < 2 {
+		return nil
+	}
+	return newContact(v, wedges, base)
+}
+
 // ClosestPt returns the closest point to point pt on any of the outlines of
-// polygon set ps.
+// polygon set ps. Empty polygons are ignored. Where the outlines of
+// polygons run along each other, so that neither side of them is inside
+// the boundaries of the polygon set, like on an edge shared by two holes,
+// they are ignored, too. If there is no polygon with at least one vertex,
+// pt itself is returned.
 func (ps PolygonSet) ClosestPt(pt geom.Vec2) geom.Vec2 {
-	var best match
-	best.pt = ps[0].ClosestPt(pt)
-	best.dist = best.pt.SqDist(pt)
-	for _, p := range ps {
-		var current match
-		current.pt = p.ClosestPt(pt)
-		current.dist = current.pt.SqDist(pt)
-		if current.dist < best.dist {
-			best = current
+	best := match{pt: pt, dist: float32(math.Inf(1))}
+	for i, p := range ps {
+		for j := range p {
+			e := p.Edge(j)
+			if e.ClosestPt(pt).SqDist(pt) >= best.dist {
+				continue
+			}
+			var current match
+			var ok bool
+			current.pt, ok = closestAccessiblePt(e, pt, func() []geom.Vec2 {
+				var vs []geom.Vec2
+				for k, q := range ps {
+					for _, v := range q {
+						if k != i && v != e.A && v != e.B && onSegment(vecPoint(v), e) {
+							vs = append(vs, v)
+						}
+					}
+				}
+				return vs
+			}, ps.contactAt)
+			current.dist = current.pt.SqDist(pt)
+			if ok && current.dist < best.dist {
+				best = current
+			}
 		}
 	}
 	return best.pt
@@ -111,6 +111,38 @@ var twoDisjointSquares = poly.PolygonSet{
 	},
 }
 
+// A square-shaped area (30x30) with two square-shaped holes (10x10), one
+// touching the top side of the area, the other touching the right side
+// and the first hole at a corner.
+//
+//	 0,0 >---+---+---+ 30,0
+//	     |   |   |   |
+//	     |   +---+---+
+//	     |       |   |
+//	     |       +---+
+//	     |           |
+//	0,30 +-----------+ 30,30
+var touchingSquares = poly.PolygonSet{
+	poly.Polygon{
+		geom.V2(0, 0),
+		geom.V2(30, 0),
+		geom.V2(30, 30),
+		geom.V2(0, 30),
+	},
+	poly.Polygon{
+		geom.V2(10, 0),
+		geom.V2(20, 0),
+		geom.V2(20, 10),
+		geom.V2(10, 10),
+	},
+	poly.Polygon{
+		geom.V2(20, 10),
+		geom.V2(30, 10),
+		geom.V2(30, 20),
+		geom.V2(20, 20),
+	},
+}
+
 func TestPolygonSetContains(t *testing.T) {
 	tests := []struct {
 		polygonSet poly.PolygonSet
@@ -128,6 +160,16 @@ func TestPolygonSetContains(t *testing.T) {
 		{twoDisjointSquares, geom.V2(5, 5), true},
 		{twoDisjointSquares, geom.V2(25, 5), true},
 		{twoDisjointSquares, geom.V2(15, 5), false},
+		{touchingSquares, geom.V2(5, 5), true},
+		{touchingSquares, geom.V2(25, 5), true},
+		{touchingSquares, geom.V2(15, 5), false},
+		{touchingSquares, geom.V2(0, 5), true},
+		{touchingSquares, geom.V2(10, 5), true},
+		{touchingSquares, geom.V2(10, 0), true},
+		{touchingSquares, geom.V2(15, 0), false},
+		{touchingSquares, geom.V2(20, 10), false},
+		{touchingSquares, geom.V2(30, 15), false},
+		{touchingSquares, geom.V2(30, 10), true},
 	}
 	for _, tt := range tests {
 		got := tt.polygonSet.Contains(tt.pt)
@@ -149,6 +191,8 @@ func TestPolygonSetClosestPt(t *testing.T) {
 		{twoSquaresNested, geom.V2(16, 0), geom.V2(20, 0)},
 		{twoSquaresNested, geom.V2(25, 25), geom.V2(20, 20)},
 		{twoSquaresNested, geom.V2(10, 25), geom.V2(10, 20)},
+		{poly.PolygonSet{}, geom.V2(10, 25), geom.V2(10, 25)},
+		{poly.PolygonSet{poly.Polygon{}}, geom.V2(10, 25), geom.V2(10, 25)},
 	}
 	for _, tt := range tests {
 		got := tt.polygonSet.ClosestPt(tt.pt)
@@ -158,3 +202,29 @@ func TestPolygonSetClosestPt(t *testing.T) {
 		}
 	}
 }
+
+func TestPolygonSetPassesContact(t *testing.T) {
+	tests := []struct {
+		polygonSet poly.PolygonSet
+		ls         poly.LineSeg
+		want       bool
+	}{
+		{twoSquaresNested, poly.LineSeg{A: geom.V2(15, 0), B: geom.V2(25, 0)}, false},
+		{touchingSquares, poly.LineSeg{A: geom.V2(5, 5), B: geom.V2(25, 5)}, false},
+		{touchingSquares, poly.LineSeg{A: geom.V2(15, 15), B: geom.V2(25, 5)}, true},
+		{touchingSquares, poly.LineSeg{A: geom.V2(15, 15), B: geom.V2(20, 10)}, true},
+		{touchingSquares, poly.LineSeg{A: geom.V2(25, 5), B: geom.V2(20, 10)}, true},
+		{touchingSquares, poly.LineSeg{A: geom.V2(5, 5), B: geom.V2(15, -5)}, true},
+		{touchingSquares, poly.LineSeg{A: geom.V2(5, 5), B: geom.V2(10, 0)}, false},
+		{touchingSquares, poly.LineSeg{A: geom.V2(10, 0), B: geom.V2(20, 0)}, true},
+		{touchingSquares, poly.LineSeg{A: geom.V2(30, 10), B: geom.V2(30, 0)}, false},
+		{touchingSquares, poly.LineSeg{A: geom.V2(30, 10), B: geom.V2(30, 30)}, true},
+	}
+	for _, tt := range tests {
+		got := tt.polygonSet.PassesContact(tt.ls)
+		if got != tt.want {
+			t.Errorf("PolygonSet: %v\nPassesContact(%v) = %v, want: %v",
+				tt.polygonSet, tt.ls, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/fzipp/geom"
+)
+
+// orientErrBound bounds the relative rounding error of the determinant
+// calculated by orientation in float64 arithmetic, see Shewchuk, "Adaptive
+// Precision Floating-Point Arithmetic and Fast Robust Geometric Predicates".
+const orientErrBound = (3 + 16*0x1p-53) * 0x1p-53
+
+// A point is a point with float64 coordinates. The predicates take points
+// instead of geom.Vec2 values, so that they also decide exactly for points
+// that are not representable in float32, like the middle of an edge.
+type point struct {
+	x, y float64
+}
+
+// vecPoint converts v to a point.
+func vecPoint(v geom.Vec2) point {
+	return point{float64(v.X), float64(v.Y)}
+}
+
+// middle returns the middle of line segment l. Unlike LineSeg.Middle it is
+// exact, because the sum of two float32 values and its half are exact in
+// float64 arithmetic.
+func middle(l LineSeg) point {
+	return point{
+		(float64(l.A.X) + float64(l.B.X)) / 2,
+		(float64(l.A.Y) + float64(l.B.Y)) / 2,
+	}
+}
+
+// orientation returns +1 if the points a, b, c are in counterclockwise order,
+// -1 if they are in clockwise order, and 0 if they are collinear.
+// Counterclockwise is the winding order of polygons with positive signed
+// area. The result is exact, see orient.
+func orientation(a, b, c geom.Vec2) int {
+	return orient(vecPoint(a), vecPoint(b), vecPoint(c))
+}
+
+// orient is orientation for points.
+//
+// The determinant is calculated in float64 arithmetic first, and only if
+// its sign is not certain because of rounding errors, it is calculated
+// again exactly. For integer coordinates up to 2^24, which is the case for
+// all coordinates converted from image.Point by the pathfind package,
+// float64 arithmetic is always exact.
+func orient(a, b, c point) int {
+	abx, aby := b.x-a.x, b.y-a.y
+	acx, acy := c.x-a.x, c.y-a.y
+	left, right := abx*acy, aby*acx
+	det := left - right
+	if math.Abs(det) > orientErrBound*(math.Abs(left)+math.Abs(right)) {
+		return sign(det)
+	}
+	if exactDiff(b.x, a.x, abx) && exactDiff(b.y, a.y, aby) &&
+		exactDiff(c.x, a.x, acx) && exactDiff(c.y, a.y, acy) &&
+		math.FMA(abx, acy, -left) == 0 && math.FMA(aby, acx, -right) == 0 {
+		// The products are exact, and the rounded difference of two exact
+		// values has the sign of the exact difference.
+		return sign(det)
+	}
+	return orientExact(a, b, c)
+}
+
+// exactDiff reports whether d, the float64 difference x-y, is exact.
+// It calculates the rounding error with Knuth's TwoSum algorithm.
+func exactDiff(x, y, d float64) bool {
+	b := -y
+	bb := d - x
+	return (x-(d-bb))+(b-bb) == 0
+}
+
+// orientExact is orient in exact rational arithmetic.
+func orientExact(a, b, c point) int {
+	ax, ay := rat(a.x), rat(a.y)
+	abx := new(big.Rat).Sub(rat(b.x), ax)
+	aby := new(big.Rat).Sub(rat(b.y), ay)
+	acx := new(big.Rat).Sub(rat(c.x), ax)
+	acy := new(big.Rat).Sub(rat(c.y), ay)
+	left := new(big.Rat).Mul(abx, acy)
+	right := new(big.Rat).Mul(aby, acx)
+	return left.Cmp(right)
+}
+
+// inCircleErrBound bounds the relative rounding error of the determinant
+// calculated by inCircle in float64 arithmetic, see Shewchuk.
+const inCircleErrBound = (10 + 96*0x1p-53) * 0x1p-53
+
+// inCircle returns +1 if point d lies inside the circle through the points
+// a, b, c, which must be in counterclockwise order, -1 if it lies outside,
+// and 0 if it lies on the circle. Like orientation, the result is exact.
+func inCircle(a, b, c, d geom.Vec2) int {
+	dx, dy := float64(d.X), float64(d.Y)
+	adx, ady := float64(a.X)-dx, float64(a.Y)-dy
+	bdx, bdy := float64(b.X)-dx, float64(b.Y)-dy
+	cdx, cdy := float64(c.X)-dx, float64(c.Y)-dy
+	bdxcdy, cdxbdy := bdx*cdy, cdx*bdy
+	cdxady, adxcdy := cdx*ady, adx*cdy
+	adxbdy, bdxady := adx*bdy, bdx*ady
+	alift := adx*adx + ady*ady
+	blift := bdx*bdx + bdy*bdy
+	clift := cdx*cdx + cdy*cdy
+	det := alift*(bdxcdy-cdxbdy) + blift*(cdxady-adxcdy) + clift*(adxbdy-bdxady)
+	permanent := (math.Abs(bdxcdy)+math.Abs(cdxbdy))*alift +
+		(math.Abs(cdxady)+math.Abs(adxcdy))*blift +
+		(math.Abs(adxbdy)+math.Abs(bdxady))*clift
+	if math.Abs(det) > inCircleErrBound*permanent {
+		return sign(det)
+	}
+	return inCircleExact(a, b, c, d)
+}
+
+// inCircleExact is inCircle in exact rational arithmetic.
+func inCircleExact(a, b, c, d geom.Vec2) int {
+	diff := func(u, v float32) *big.Rat {
+		return new(big.Rat).Sub(rat(float64(u)), rat(float64(v)))
+	}
+	adx, ady := diff(a.X, d.X), diff(a.Y, d.Y)
+	bdx, bdy := diff(b.X, d.X), diff(b.Y, d.Y)
+	cdx, cdy := diff(c.X, d.X), diff(c.Y, d.Y)
+	lift := func(x, y *big.Rat) *big.Rat {
+		l := new(big.Rat).Mul(x, x)
+		return l.Add(l, new(big.Rat).Mul(y, y))
+	}
+	cross := func(x1, y1, x2, y2 *big.Rat) *big.Rat {
+		c := new(big.Rat).Mul(x1, y2)
+		return c.Sub(c, new(big.Rat).Mul(y1, x2))
+	}
+	det := new(big.Rat).Mul(lift(adx, ady), cross(bdx, bdy, cdx, cdy))
+	det.Add(det, new(big.Rat).Mul(lift(bdx, bdy), cross(cdx, cdy, adx, ady)))
+	det.Add(det, new(big.Rat).Mul(lift(cdx, cdy), cross(adx, ady, bdx, bdy)))
+	return det.Sign()
+}
+
+// rat converts x to an exact rational number.
+func rat(x float64) *big.Rat {
+	return new(big.Rat).SetFloat64(x)
+}
+
+// sign returns -1 if x is negative, +1 if x is positive, and 0 otherwise.
+func sign(x float64) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return +1
+	default:
+		return 0
+	}
+}
+
+// onSegment reports whether point p lies exactly on line segment l,
+// including its end points.
+func onSegment(p point, l LineSeg) bool {
+	return orient(vecPoint(l.A), vecPoint(l.B), p) == 0 && inBounds(p, l)
+}
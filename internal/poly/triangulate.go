@@ -0,0 +1,498 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/fzipp/geom"
+)
+
+// A Triangle is a triangle with its vertices in the winding order of
+// polygons with positive signed area.
+type Triangle [3]geom.Vec2
+
+// TriangulateNested divides the accessible area of polygon set ps, i.e.
+// the area polygons minus their holes, into triangles. The vertices of the
+// triangles are vertices of the polygons. The nesting of the polygons is
+// given by the index of the parent polygon of each polygon, or -1 at the
+// first level, and the nesting depth of each polygon. Polygons with an
+// even depth are area polygons, and their children are their holes.
+//
+// Each area polygon is first separated into parts where its holes touch
+// its outline or each other. Each part is merged with its holes into a
+// single polygon by bridges from the holes to the outer boundary and then
+// triangulated by ear clipping. Finally, the edges between the triangles are flipped until
+// the triangulation is a constrained Delaunay triangulation, which avoids
+// long and thin triangles.
+func (ps PolygonSet) TriangulateNested(parent, depth []int) []Triangle {
+	var tris []Triangle
+	for i, outer := range ps {
+		if len(outer) < 3 || depth[i]%2 != 0 {
+			continue
+		}
+		var holes []Polygon
+		for j, h := range ps {
+			if len(h) >= 3 && parent[j] == i {
+				holes = append(holes, h)
+			}
+		}
+		for _, part := range separateParts(outer, holes) {
+			tris = append(tris, earClip(bridgeHoles(part.outer, part.holes))...)
+		}
+	}
+	return delaunayFlips(tris)
+}
+
+// An areaPart is a part of the accessible area of an area polygon, given by
+// its outer boundary and its holes.
+type areaPart struct {
+	outer Polygon
+	holes []Polygon
+}
+
+// separateParts divides the accessible area of polygon outer minus its
+// holes into the parts that are separated by holes touching the outer
+// boundary or each other. The edges that the polygons share are removed,
+// and the remaining edges are traced as the boundaries of the parts and
+// of the merged holes. Where a part is pinched, its boundary runs through
+// the touching point twice, like through the end points of a bridge.
+func separateParts(outer Polygon, holes []Polygon) []areaPart {
+	// The outer boundary has positive and the holes have negative signed
+	// area, so that the accessible area lies left of all edges.
+	rings := PolygonSet{slices.Clone(outer)}
+	rings[0].Normalize()
+	for _, h := range holes {
+		h = slices.Clone(h)
+		if h.SignedArea() > 0 {
+			slices.Reverse(h)
+		}
+		rings = append(rings, h)
+	}
+	idx := NewIndex(rings)
+	splits := make(map[edgeRef][]geom.Vec2)
+	touching := false
+	for i, p := range rings {
+		for _, v := range p {
+			for _, ref := range idx.cells[idx.row(float64(v.Y))*idx.nx+idx.col(float64(v.X))] {
+				e := rings[ref.polygon].Edge(int(ref.vertex))
+				if int(ref.polygon) == i || !onSegment(vecPoint(v), e) {
+					continue
+				}
+				touching = true
+				if v != e.A && v != e.B {
+					splits[ref] = append(splits[ref], v)
+				}
+			}
+		}
+	}
+	if !touching {
+		return []areaPart{{outer, holes}}
+	}
+
+	// Split the edges at the vertices of the other polygons on them, and
+	// remove the pieces that two polygons share in opposite directions.
+	type edge struct {
+		a, b geom.Vec2
+	}
+	var pieces []edge
+	count := make(map[edge]int)
+	for i, p := range rings {
+		for j := range p {
+			e := p.Edge(j)
+			pts := splits[edgeRef{polygon: int32(i), vertex: int32(j)}]
+			slices.SortFunc(pts, func(u, w geom.Vec2) int {
+				return cmp.Compare(l1Dist(e.A, u), l1Dist(e.A, w))
+			})
+			a := e.A
+			for _, b := range append(slices.Compact(pts), e.B) {
+				if a != b {
+					pieces = append(pieces, edge{a, b})
+					count[edge{a, b}]++
+				}
+				a = b
+			}
+		}
+	}
+	shared := make(map[edge]int)
+	for e, n := range count {
+		shared[e] = min(n, count[edge{e.b, e.a}])
+	}
+	var edges []edge
+	out := make(map[geom.Vec2][]int)
+	for _, e := range pieces {
+		if shared[e] > 0 {
+			shared[e]--
+			continue
+		}
+		out[e.a] = append(out[e.a], len(edges))
+		edges = append(edges, e)
+	}
+
+	// Continue each edge with the edge at its end point that bounds the
+	// same accessible sector, i.e. the first one in clockwise direction.
+	next := make([]int, len(edges))
+	used := make([]bool, len(edges))
+	for k, e := range edges {
+		c := &contact{v: vecPoint(e.b)}
+		back := vecPoint(e.a)
+		next[k] = -1
+		for _, j := range out[e.b] {
+			if used[j] {
+				continue
+			}
+			if next[k] < 0 || clockwiseBefore(c, back, vecPoint(edges[j].b), vecPoint(edges[next[k]].b)) {
+				next[k] = j
+			}
+		}
+		if next[k] >= 0 {
+			used[next[k]] = true
+		}
+	}
+	var parts []areaPart
+	var merged []Polygon
+	visited := make([]bool, len(edges))
+	for k := range edges {
+		var ring Polygon
+		j := k
+		for ; j >= 0 && !visited[j]; j = next[j] {
+			visited[j] = true
+			ring = append(ring, edges[j].a)
+		}
+		if j != k {
+			continue
+		}
+		switch a := ring.SignedArea(); {
+		case a > 0:
+			parts = append(parts, areaPart{outer: ring})
+		case a < 0:
+			merged = append(merged, ring)
+		}
+	}
+	// A hole belongs to the smallest part that contains it. It can't
+	// touch the boundary of the part, since it would have been traced as
+	// part of this boundary.
+	for _, h := range merged {
+		best := -1
+		for i, part := range parts {
+			if part.outer.Contains(h[0], false) &&
+				(best < 0 || part.outer.SignedArea() < parts[best].outer.SignedArea()) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			parts[best].holes = append(parts[best].holes, h)
+		}
+	}
+	return parts
+}
+
+// clockwiseBefore checks if the direction from contact c towards point p
+// comes before the direction towards point q when turning clockwise from
+// the direction towards point from.
+func clockwiseBefore(c *contact, from, p, q point) bool {
+	bp, bq := c.compareDirs(p, from) < 0, c.compareDirs(q, from) < 0
+	if bp != bq {
+		return bp
+	}
+	return c.compareDirs(p, q) > 0
+}
+
+// l1Dist returns the Manhattan distance between the points a and b, which
+// orders points on a line segment by their distance from one of its end
+// points.
+func l1Dist(a, b geom.Vec2) float64 {
+	return abs64(float64(a.X)-float64(b.X)) + abs64(float64(a.Y)-float64(b.Y))
+}
+
+// bridgeHoles merges the holes into polygon outer by connecting each hole
+// to the outer boundary with a bridge, a pair of coincident edges in
+// opposite directions. The result is a weakly simple polygon with positive
+// signed area.
+func bridgeHoles(outer Polygon, holes []Polygon) Polygon {
+	ring := slices.Clone(outer)
+	ring.Normalize()
+	type hole struct {
+		p         Polygon
+		rightmost int
+	}
+	hs := make([]hole, len(holes))
+	for i, h := range holes {
+		h = slices.Clone(h)
+		if h.SignedArea() > 0 {
+			slices.Reverse(h)
+		}
+		r := 0
+		for j, v := range h {
+			if v.X > h[r].X || (v.X == h[r].X && v.Y < h[r].Y) {
+				r = j
+			}
+		}
+		hs[i] = hole{h, r}
+	}
+	// Bridge the holes from right to left, so that the bridges of holes
+	// further to the right are already part of the ring.
+	slices.SortFunc(hs, func(a, b hole) int {
+		return cmp.Compare(b.p[b.rightmost].X, a.p[a.rightmost].X)
+	})
+	for _, h := range hs {
+		m := h.p[h.rightmost]
+		j := bridgeVertex(ring, m)
+		if j < 0 {
+			continue
+		}
+		merged := make(Polygon, 0, len(ring)+len(h.p)+2)
+		merged = append(merged, ring[:j+1]...)
+		for k := range h.p {
+			merged = append(merged, h.p[(h.rightmost+k)%len(h.p)])
+		}
+		merged = append(merged, m, ring[j])
+		merged = append(merged, ring[j+1:]...)
+		ring = merged
+	}
+	return ring
+}
+
+// bridgeVertex returns the index of a vertex of ring that is visible from
+// point m inside the ring, found by casting a ray from m to the right.
+// It returns -1 if the ray doesn't hit the ring.
+func bridgeVertex(ring Polygon, m geom.Vec2) int {
+	mx, my := float64(m.X), float64(m.Y)
+	hit, hitX := -1, 0.0
+	for i, a := range ring {
+		b := ring[(i+1)%len(ring)]
+		ay, by := float64(a.Y), float64(b.Y)
+		if (ay > my) == (by > my) && ay != my && by != my {
+			continue
+		}
+		var x float64
+		switch {
+		case ay == by:
+			// horizontal edge on the ray
+			x = max(min(float64(a.X), float64(b.X)), mx)
+			if x > max(float64(a.X), float64(b.X)) {
+				continue
+			}
+		default:
+			x = float64(a.X) + (my-ay)/(by-ay)*(float64(b.X)-float64(a.X))
+		}
+		if x < mx || (hit >= 0 && x >= hitX) {
+			continue
+		}
+		hit, hitX = i, x
+	}
+	if hit < 0 {
+		return -1
+	}
+	a, b := ring[hit], ring[(hit+1)%len(ring)]
+	hitPt := geom.V2(float32(hitX), m.Y)
+	var p int
+	switch {
+	case hitPt == a:
+		return visibleOccurrence(ring, hit, m)
+	case hitPt == b:
+		return visibleOccurrence(ring, (hit+1)%len(ring), m)
+	case a.X > b.X:
+		p = hit
+	default:
+		p = (hit + 1) % len(ring)
+	}
+	// A reflex vertex inside the triangle m, hit point, p can block the
+	// sight to p. In this case the reflex vertex with the smallest angle
+	// to the ray is visible.
+	best := p
+	bestTan, bestDist := 0.0, 0.0
+	for i, v := range ring {
+		if v == ring[p] || !pointInTriangle(v, m, hitPt, ring[p]) {
+			continue
+		}
+		prev, next := ring[(i+len(ring)-1)%len(ring)], ring[(i+1)%len(ring)]
+		if orientation(prev, v, next) >= 0 {
+			continue // not reflex
+		}
+		dx, dy := float64(v.X)-mx, float64(v.Y)-my
+		if dx <= 0 {
+			continue
+		}
+		tan := abs64(dy) / dx
+		dist := dx*dx + dy*dy
+		if best == p || tan < bestTan || (tan == bestTan && dist < bestDist) {
+			best, bestTan, bestDist = i, tan, dist
+		}
+	}
+	return visibleOccurrence(ring, best, m)
+}
+
+// visibleOccurrence returns the index of the occurrence of the vertex
+// ring[i] whose interior angle contains the direction to point m. After
+// bridging, a vertex can occur more than once in the ring.
+func visibleOccurrence(ring Polygon, i int, m geom.Vec2) int {
+	v := ring[i]
+	for j, w := range ring {
+		if w != v {
+			continue
+		}
+		prev, next := ring[(j+len(ring)-1)%len(ring)], ring[(j+1)%len(ring)]
+		if inWedge(prev, v, next, m) {
+			return j
+		}
+	}
+	return i
+}
+
+// inWedge checks if point m lies within the interior angle at vertex v
+// between the edges from prev and to next of a polygon with positive
+// signed area.
+func inWedge(prev, v, next, m geom.Vec2) bool {
+	if orientation(prev, v, next) >= 0 {
+		return orientation(v, next, m) >= 0 && orientation(v, m, prev) >= 0
+	}
+	return !(orientation(v, prev, m) > 0 && orientation(v, m, next) > 0)
+}
+
+// pointInTriangle checks if point p lies inside or on the boundary of the
+// triangle a, b, c of any winding order.
+func pointInTriangle(p, a, b, c geom.Vec2) bool {
+	d1, d2, d3 := orientation(a, b, p), orientation(b, c, p), orientation(c, a, p)
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
+// pointStrictlyInTriangle checks if point p lies inside the triangle a, b, c
+// with positive winding order, but not on its boundary.
+func pointStrictlyInTriangle(p, a, b, c geom.Vec2) bool {
+	return orientation(a, b, p) > 0 && orientation(b, c, p) > 0 && orientation(c, a, p) > 0
+}
+
+func abs64(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// earClip triangulates a weakly simple polygon with positive signed area
+// by repeatedly cutting off ears, triangles formed by three consecutive
+// vertices that contain no other vertex. Collinear vertices are kept, so
+// that neighbouring triangles always share whole edges.
+func earClip(p Polygon) []Triangle {
+	n := len(p)
+	next := make([]int, n)
+	prev := make([]int, n)
+	for i := range n {
+		next[i] = (i + 1) % n
+		prev[i] = (i + n - 1) % n
+	}
+	remove := func(i int) {
+		next[prev[i]] = next[i]
+		prev[next[i]] = prev[i]
+		n--
+	}
+	var tris []Triangle
+	i, stalled := 0, 0
+	touching := false
+	for n > 3 {
+		if stalled > n {
+			if touching {
+				// The remaining vertices enclose no area, e.g. because
+				// they are collinear.
+				break
+			}
+			// Vertices on the outline of every candidate, e.g. a vertex
+			// of a hole that touches the outer boundary. Accept ears with
+			// vertices on their outline but not inside of them, even
+			// though such a vertex then lies on an edge of the ear
+			// instead of being one of its corners.
+			touching, stalled = true, 0
+		}
+		a, b, c := p[prev[i]], p[i], p[next[i]]
+		switch {
+		case b == a || b == c:
+			remove(i)
+			i, stalled = prev[i], 0
+		case orientation(a, b, c) > 0 && isEar(p, next, prev[i], i, next[i], touching):
+			tris = append(tris, Triangle{a, b, c})
+			remove(i)
+			i, stalled, touching = prev[i], 0, false
+		default:
+			i = next[i]
+			stalled++
+		}
+	}
+	if n == 3 {
+		a, b, c := p[prev[i]], p[i], p[next[i]]
+		if orientation(a, b, c) > 0 {
+			tris = append(tris, Triangle{a, b, c})
+		}
+	}
+	return tris
+}
+
+// isEar checks if the convex vertex b with neighbours a and c is an ear of
+// the remaining polygon, given by the linked list next starting at a.
+// If touching is true, other vertices may lie on the outline of the ear.
+func isEar(p Polygon, next []int, a, b, c int, touching bool) bool {
+	ta, tb, tc := p[a], p[b], p[c]
+	for j := next[c]; j != a; j = next[j] {
+		v := p[j]
+		if v == ta || v == tb || v == tc {
+			continue
+		}
+		if touching && pointStrictlyInTriangle(v, ta, tb, tc) ||
+			!touching && pointInTriangle(v, ta, tb, tc) {
+			return false
+		}
+	}
+	return true
+}
+
+// delaunayFlips flips the inner edges of a triangulation whose opposite
+// vertices lie within the circumcircle of the other triangle, until the
+// triangulation is Delaunay apart from the polygon edges, which can't be
+// flipped because they are not shared by two triangles. Since the
+// predicates are exact, each flip makes the triangulation strictly closer
+// to Delaunay, so that the flipping terminates.
+func delaunayFlips(tris []Triangle) []Triangle {
+	type side struct {
+		tri, edge int
+	}
+	for {
+		edges := make(map[[2]geom.Vec2]side, 3*len(tris))
+		for t, tri := range tris {
+			for e := range 3 {
+				edges[[2]geom.Vec2{tri[e], tri[(e+1)%3]}] = side{t, e}
+			}
+		}
+		flipped := make([]bool, len(tris))
+		changed := false
+		for t := range tris {
+			for e := range 3 {
+				if flipped[t] {
+					break
+				}
+				tri := tris[t]
+				a, b, c := tri[e], tri[(e+1)%3], tri[(e+2)%3]
+				o, ok := edges[[2]geom.Vec2{b, a}]
+				if !ok || flipped[o.tri] || o.tri == t {
+					continue
+				}
+				d := tris[o.tri][(o.edge+2)%3]
+				if inCircle(a, b, c, d) <= 0 ||
+					orientation(c, a, d) <= 0 || orientation(d, b, c) <= 0 {
+					continue
+				}
+				tris[t] = Triangle{c, a, d}
+				tris[o.tri] = Triangle{d, b, c}
+				flipped[t], flipped[o.tri] = true, true
+				changed = true
+			}
+		}
+		if !changed {
+			return tris
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package poly_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+func TestPolygonSetTriangulateNested(t *testing.T) {
+	// U-shaped polygon with a concave notch from the top.
+	uShape := poly.PolygonSet{{
+		geom.V2(0, 0), geom.V2(5, 0), geom.V2(5, 8), geom.V2(10, 8),
+		geom.V2(10, 0), geom.V2(15, 0), geom.V2(15, 10), geom.V2(0, 10),
+	}}
+	// Collinear vertices and a hole with the same orientation as the area.
+	collinear := poly.PolygonSet{
+		{geom.V2(0, 0), geom.V2(5, 0), geom.V2(10, 0), geom.V2(10, 5), geom.V2(10, 10), geom.V2(0, 10)},
+		{geom.V2(2, 2), geom.V2(4, 2), geom.V2(4, 4), geom.V2(2, 4)},
+		{geom.V2(6, 6), geom.V2(8, 6), geom.V2(8, 8), geom.V2(6, 8)},
+	}
+	// A hole with an edge on the outline of the area, whose vertices lie
+	// on the outline of every ear candidate at some point.
+	touching := poly.PolygonSet{
+		{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)},
+		{geom.V2(7, 3), geom.V2(3, 3), geom.V2(4, 0), geom.V2(6, 0)},
+	}
+	// A corridor with holes touching its outline and each other at a
+	// corner, which separate the area into parts. The first vertices of
+	// the holes lie inside the corridor for the nesting.
+	corridor := poly.PolygonSet{
+		{geom.V2(0, 0), geom.V2(30, 0), geom.V2(30, 10), geom.V2(0, 10)},
+		{geom.V2(15, 5), geom.V2(10, 5), geom.V2(10, 0), geom.V2(15, 0)},
+		{geom.V2(15, 5), geom.V2(20, 5), geom.V2(20, 10), geom.V2(15, 10)},
+	}
+	// A hole across a corridor with notches on both sides.
+	blocked := poly.PolygonSet{
+		{geom.V2(0, 0), geom.V2(30, 0), geom.V2(30, 10), geom.V2(0, 10)},
+		{geom.V2(18, 5), geom.V2(20, 10), geom.V2(10, 10), geom.V2(12, 5), geom.V2(10, 0), geom.V2(20, 0)},
+	}
+	tests := []struct {
+		name       string
+		polygonSet poly.PolygonSet
+		wantArea   float64
+	}{
+		{"Empty", poly.PolygonSet{}, 0},
+		{"Two disjoint squares", twoDisjointSquares, 200},
+		{"Two squares nested", twoSquaresNested, 1600 - 400},
+		{"Three squares nested", threeSquaresNested, 3600 - 1600 + 400},
+		{"U shape", uShape, 150 - 40},
+		{"Collinear", collinear, 100 - 8},
+		{"Grid", gridPolygonSet(4), 2500 - 16*25},
+		{"Touching hole", touching, 100 - 9},
+		{"Touching holes", corridor, 300 - 50},
+		{"Hole across the area", blocked, 300 - 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tris := tt.polygonSet.TriangulateNested(nesting(tt.polygonSet))
+			var area float64
+			for _, tri := range tris {
+				a := poly.Polygon(tri[:]).SignedArea()
+				if a <= 0 {
+					t.Errorf("triangle %v: got signed area %g, want > 0", tri, a)
+				}
+				area += float64(a)
+			}
+			if math.Abs(area-tt.wantArea) > 1e-6*max(tt.wantArea, 1) {
+				t.Errorf("got total area %g, want %g", area, tt.wantArea)
+			}
+			r := rand.New(rand.NewPCG(1, 2))
+			for range 1000 {
+				pt := geom.V2(r.Float32()*70-10, r.Float32()*70-10)
+				inside := tt.polygonSet.Contains(pt)
+				covered := 0
+				for _, tri := range tris {
+					if poly.Polygon(tri[:]).Contains(pt, false) {
+						covered++
+					}
+				}
+				if inside && covered != 1 && !onAnyEdge(tris, pt) {
+					t.Errorf("point %v inside polygon set is covered by %d triangles, want 1", pt, covered)
+				}
+				if !inside && covered > 0 {
+					t.Errorf("point %v outside polygon set is covered by %d triangles, want 0", pt, covered)
+				}
+			}
+		})
+	}
+}
+
+// nesting returns the parent and the nesting depth of each polygon of
+// polygon set ps, derived from their containment.
+func nesting(ps poly.PolygonSet) (parent, depth []int) {
+	parent = make([]int, len(ps))
+	depth = make([]int, len(ps))
+	for i, p := range ps {
+		parent[i] = -1
+		for j, q := range ps {
+			if i != j && q.Contains(p[0], false) {
+				depth[i]++
+				if k := parent[i]; k < 0 || ps[k].Contains(q[0], false) {
+					parent[i] = j
+				}
+			}
+		}
+	}
+	return parent, depth
+}
+
+func onAnyEdge(tris []poly.Triangle, pt geom.Vec2) bool {
+	for _, tri := range tris {
+		for i := range 3 {
+			l := poly.LineSeg{A: tri[i], B: tri[(i+1)%3]}
+			if l.ClosestPt(pt).Dist(pt) < 1e-4 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestPolygonSetTriangulateDelaunay(t *testing.T) {
+	// Ear clipping of a convex polygon yields a fan of thin triangles. After
+	// the flips no vertex may lie inside the circumcircle of a triangle.
+	var p poly.Polygon
+	for i := range 16 {
+		a := 2 * math.Pi * float64(i) / 16
+		p = append(p, geom.V2(float32(10*math.Cos(a)), float32(6*math.Sin(a))))
+	}
+	tris := poly.PolygonSet{p}.TriangulateNested([]int{-1}, []int{0})
+	if len(tris) != len(p)-2 {
+		t.Fatalf("got %d triangles, want %d", len(tris), len(p)-2)
+	}
+	for _, tri := range tris {
+		cx, cy, r2 := circumcircle(tri)
+		for _, v := range p {
+			dx, dy := float64(v.X)-cx, float64(v.Y)-cy
+			if dx*dx+dy*dy < r2*(1-1e-5) {
+				t.Errorf("vertex %v lies inside the circumcircle of triangle %v", v, tri)
+			}
+		}
+	}
+}
+
+func circumcircle(tri poly.Triangle) (cx, cy, r2 float64) {
+	ax, ay := float64(tri[0].X), float64(tri[0].Y)
+	bx, by := float64(tri[1].X), float64(tri[1].Y)
+	cx0, cy0 := float64(tri[2].X), float64(tri[2].Y)
+	d := 2 * (ax*(by-cy0) + bx*(cy0-ay) + cx0*(ay-by))
+	cx = ((ax*ax+ay*ay)*(by-cy0) + (bx*bx+by*by)*(cy0-ay) + (cx0*cx0+cy0*cy0)*(ay-by)) / d
+	cy = ((ax*ax+ay*ay)*(cx0-bx) + (bx*bx+by*by)*(ax-cx0) + (cx0*cx0+cy0*cy0)*(bx-ax)) / d
+	return cx, cy, (ax-cx)*(ax-cx) + (ay-cy)*(ay-cy)
+}
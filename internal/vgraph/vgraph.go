@@ -0,0 +1,59 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vgraph provides a deterministic view of visibility graphs, as
+// returned by pathfind.Pathfinder.VisibilityGraphF, for the packages that
+// write them.
+package vgraph
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/fzipp/geom"
+)
+
+// Nodes returns the nodes of graph g, including nodes that only occur as
+// neighbours, sorted by Compare.
+func Nodes(g map[geom.Vec2][]geom.Vec2) []geom.Vec2 {
+	var nodes []geom.Vec2
+	for n, nbs := range g {
+		nodes = append(nodes, n)
+		nodes = append(nodes, nbs...)
+	}
+	slices.SortFunc(nodes, Compare)
+	return slices.Compact(nodes)
+}
+
+// Edges returns the edges of graph g as undirected edges. Each pair of
+// linked nodes occurs only once, from the smaller to the greater node
+// according to Compare, and the edges are sorted.
+func Edges(g map[geom.Vec2][]geom.Vec2) [][2]geom.Vec2 {
+	var edges [][2]geom.Vec2
+	for a, nbs := range g {
+		for _, b := range nbs {
+			e := [2]geom.Vec2{a, b}
+			if Compare(a, b) > 0 {
+				e = [2]geom.Vec2{b, a}
+			}
+			edges = append(edges, e)
+		}
+	}
+	slices.SortFunc(edges, func(e1, e2 [2]geom.Vec2) int {
+		return cmp.Or(Compare(e1[0], e2[0]), Compare(e1[1], e2[1]))
+	})
+	return slices.Compact(edges)
+}
+
+// Compare orders vectors by x and then by y coordinate.
+func Compare(v, w geom.Vec2) int {
+	return cmp.Or(cmp.Compare(v.X, w.X), cmp.Compare(v.Y, w.Y))
+}
+
+// Length returns the Euclidean length of edge e, calculated in float64
+// like the path lengths of pathfind.
+func Length(e [2]geom.Vec2) float64 {
+	return math.Hypot(float64(e[0].X)-float64(e[1].X), float64(e[0].Y)-float64(e[1].Y))
+}
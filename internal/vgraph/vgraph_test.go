@@ -0,0 +1,45 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vgraph_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/vgraph"
+)
+
+var graph = map[geom.Vec2][]geom.Vec2{
+	geom.V2(10, 0): {geom.V2(0, 5), geom.V2(0, 0)},
+	geom.V2(0, 5):  {geom.V2(10, 0)},
+	geom.V2(0, 0):  {geom.V2(10, 0), geom.V2(3, 4)}, // (3, 4) only as neighbour
+}
+
+func TestNodes(t *testing.T) {
+	got := vgraph.Nodes(graph)
+	want := []geom.Vec2{geom.V2(0, 0), geom.V2(0, 5), geom.V2(3, 4), geom.V2(10, 0)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Nodes\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestEdges(t *testing.T) {
+	got := vgraph.Edges(graph)
+	want := [][2]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(3, 4)},
+		{geom.V2(0, 0), geom.V2(10, 0)},
+		{geom.V2(0, 5), geom.V2(10, 0)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Edges\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestLength(t *testing.T) {
+	if got := vgraph.Length([2]geom.Vec2{geom.V2(0, 0), geom.V2(3, 4)}); got != 5 {
+		t.Errorf("Length = %v, want: 5", got)
+	}
+}
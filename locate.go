@@ -0,0 +1,60 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import "github.com/fzipp/geom"
+
+// A PolygonNode describes the position of a polygon in the containment
+// hierarchy of a polygon set.
+type PolygonNode struct {
+	Parent   int   // index of the innermost polygon containing it, or -1
+	Children []int // indices of the polygons it is the parent of
+	Depth    int   // nesting depth: even for areas, odd for holes
+}
+
+// Hierarchy returns the containment hierarchy of the polygon set, with a
+// node for each polygon, indexed like the polygons returned by Polygons.
+// Polygons at the first level have depth 0 and are areas, their children
+// are holes with depth 1, the children of those are areas again, and so
+// on. Obstacles added by AddObstacle are holes in the hierarchy.
+func (p *Pathfinder) Hierarchy() []PolygonNode {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	nodes := make([]PolygonNode, len(p.nesting))
+	for i, parent := range p.nesting {
+		nodes[i].Parent = parent
+		nodes[i].Depth = p.nesting.depth(i)
+		if parent >= 0 {
+			nodes[parent].Children = append(nodes[parent].Children, i)
+		}
+	}
+	return nodes
+}
+
+// Locate returns the index of the innermost polygon of the polygon set that
+// contains point pt, or -1 if pt is outside of all polygons. The index
+// refers to the polygons returned by Polygons, see Hierarchy for their
+// nesting. A point on the outline of an area counts as inside the area, a
+// point on the outline of a hole as outside the hole, so that pt is in the
+// accessible area if and only if the located polygon is an area.
+//
+// Like Contains, it only tests the polygon edges near pt and to its right.
+func (p *Pathfinder) Locate(pt geom.Vec2) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !isFinite(pt.X) || !isFinite(pt.Y) {
+		return -1
+	}
+	isArea := func(i int) bool {
+		return p.nesting.depth(i)%2 == 0
+	}
+	innermost, depth := -1, -1
+	for _, i := range p.index.Containing(pt, isArea) {
+		if d := p.nesting.depth(i); d > depth {
+			innermost, depth = i, d
+		}
+	}
+	return innermost
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+// polygonsNested has an area with a hole with an island, and a separate
+// area.
+var polygonsNested = [][]geom.Vec2{
+	{geom.V2(0, 0), geom.V2(40, 0), geom.V2(40, 40), geom.V2(0, 40)},
+	{geom.V2(20, 10), geom.V2(30, 20), geom.V2(20, 30), geom.V2(10, 20)},
+	{geom.V2(18, 18), geom.V2(22, 18), geom.V2(22, 22), geom.V2(18, 22)},
+	{geom.V2(50, 0), geom.V2(60, 0), geom.V2(60, 10), geom.V2(50, 10)},
+}
+
+func TestPathfinderHierarchy(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF(polygonsNested)
+	got := pathfinder.Hierarchy()
+	want := []pathfind.PolygonNode{
+		{Parent: -1, Children: []int{1}, Depth: 0},
+		{Parent: 0, Children: []int{2}, Depth: 1},
+		{Parent: 1, Children: nil, Depth: 2},
+		{Parent: -1, Children: nil, Depth: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Hierarchy()\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestPathfinderLocate(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF(polygonsNested)
+	tests := []struct {
+		name string
+		pt   geom.Vec2
+		want int
+	}{
+		{"Area", geom.V2(5, 5), 0},
+		{"Hole", geom.V2(15, 20), 1},
+		{"Island", geom.V2(20, 20), 2},
+		{"Separate area", geom.V2(55, 5), 3},
+		{"Between the areas", geom.V2(45, 5), -1},
+		{"Outside the bounds", geom.V2(-5, 5), -1},
+		{"On the outline of an area", geom.V2(0, 5), 0},
+		{"On the outline of a hole", geom.V2(10, 20), 0},
+		{"On the outline of an island", geom.V2(18, 20), 2},
+		{"NaN", geom.V2(float32(math.NaN()), 5), -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathfinder.Locate(tt.pt); got != tt.want {
+				t.Errorf("Locate(%v) = %d, want %d", tt.pt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathfinderLocateContains(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF(polygonsNested)
+	hierarchy := pathfinder.Hierarchy()
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 1000 {
+		pt := geom.V2(float32(r.IntN(65)), float32(r.IntN(45))).Sub(geom.V2(2, 2))
+		if r.IntN(2) == 0 {
+			pt = pt.Add(geom.V2(r.Float32(), r.Float32()))
+		}
+		i := pathfinder.Locate(pt)
+		inArea := i >= 0 && hierarchy[i].Depth%2 == 0
+		if want := pathfinder.Contains(pt); inArea != want {
+			t.Errorf("Locate(%v) = %d, but Contains(%v) = %v", pt, i, pt, want)
+		}
+	}
+}
+
+func TestPathfinderLocateObstacle(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF(polygonsNested)
+	pt := geom.V2(55, 5)
+	o, err := pathfinder.AddObstacleF([]geom.Vec2{geom.V2(53, 3), geom.V2(57, 3), geom.V2(57, 7), geom.V2(53, 7)})
+	if err != nil {
+		t.Fatalf("AddObstacleF: unexpected error: %v", err)
+	}
+	if got := pathfinder.Locate(pt); got != 4 {
+		t.Errorf("Locate(%v) after AddObstacleF = %d, want %d", pt, got, 4)
+	}
+	if got := pathfinder.Hierarchy()[4].Parent; got != 3 {
+		t.Errorf("parent of obstacle: got %d, want %d", got, 3)
+	}
+	pathfinder.RemoveObstacle(o)
+	if got := pathfinder.Locate(pt); got != 3 {
+		t.Errorf("Locate(%v) after RemoveObstacle = %d, want %d", pt, got, 3)
+	}
+}
@@ -0,0 +1,531 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"container/heap"
+	"math"
+	"slices"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// A Backend determines the data structure a Pathfinder searches paths in.
+type Backend int
+
+const (
+	// VisibilityGraphBackend searches paths in the visibility graph between
+	// the concave vertices of the polygon set. It finds the shortest path.
+	VisibilityGraphBackend Backend = iota
+	// NavMeshBackend searches paths in a navigation mesh, a triangulation
+	// of the accessible area. The search runs over the parts of the
+	// triangle edges that are visible from the points where the path
+	// turns, so it finds the shortest path like VisibilityGraphBackend.
+	// The preprocessing is much faster than that of the visibility graph
+	// for large polygon sets.
+	//
+	// The Pathfinder has no visibility graph with this backend. Regions
+	// and the search options, see WithSearch, are not supported:
+	// NewCheckedPathfinder reports an error for them, and NewPathfinder
+	// ignores them.
+	NavMeshBackend
+)
+
+// WithBackend configures the data structure the Pathfinder searches paths
+// in. The default is VisibilityGraphBackend.
+func WithBackend(b Backend) Option {
+	return func(c *config) {
+		c.backend = b
+	}
+}
+
+// navMesh is a triangulation of the accessible area of a polygon set.
+type navMesh struct {
+	tris []poly.Triangle
+	// adj holds for each triangle and edge the index of the neighbour
+	// triangle across this edge, or -1 if the edge is a polygon edge.
+	// Edge k of a triangle leads from vertex k to vertex k+1.
+	adj [][3]int
+	// grid indexes the bounding boxes of the triangles for locate.
+	grid boxGrid
+}
+
+// newNavMesh builds the navigation mesh from the triangles of the
+// accessible area of the polygon set indexed by idx. Triangles that share
+// an edge on the outlines of touching polygons, like an island and the
+// area around its hole, aren't neighbours, because the accessible area
+// is interrupted there.
+func newNavMesh(tris []poly.Triangle, idx *poly.Index) *navMesh {
+	type side struct {
+		tri, edge int
+	}
+	edges := make(map[[2]geom.Vec2]side, 3*len(tris))
+	for t, tri := range tris {
+		for k := range 3 {
+			edges[[2]geom.Vec2{tri[k], tri[(k+1)%3]}] = side{t, k}
+		}
+	}
+	m := &navMesh{tris: tris, adj: make([][3]int, len(tris))}
+	boxes := make([]box, len(tris))
+	order := make([]int, len(tris))
+	for t, tri := range tris {
+		boxes[t] = bounds(tri[:]...)
+		order[t] = t
+	}
+	m.grid = newBoxGrid(boxes, order)
+	for t, tri := range tris {
+		for k := range 3 {
+			m.adj[t][k] = -1
+			e := poly.LineSeg{A: tri[k], B: tri[(k+1)%3]}
+			if s, ok := edges[[2]geom.Vec2{e.B, e.A}]; ok && s.tri != t && idx.ContainsMiddle(e) {
+				m.adj[t][k] = s.tri
+			}
+		}
+	}
+	return m
+}
+
+// locate returns the index of the triangle that contains point pt. Points
+// on an edge count as inside. If no triangle contains pt, e.g. because of
+// a rounding error, it returns the closest triangle. It returns -1 if the
+// mesh has no triangles.
+//
+// Only the triangles in the grid cell of pt are tested for containment.
+// All triangles are searched for the closest one only if none of them
+// contains pt.
+func (m *navMesh) locate(pt geom.Vec2) int {
+	for _, t := range m.grid.at(pt) {
+		tri := m.tris[t]
+		if orientation(tri[0], tri[1], pt) >= 0 &&
+			orientation(tri[1], tri[2], pt) >= 0 &&
+			orientation(tri[2], tri[0], pt) >= 0 {
+			return t
+		}
+	}
+	closest, closestDist := -1, math.Inf(1)
+	for t, tri := range m.tris {
+		for k := range 3 {
+			e := poly.LineSeg{A: tri[k], B: tri[(k+1)%3]}
+			if d := nodeDist(e.ClosestPt(pt), pt); d < closestDist {
+				closest, closestDist = t, d
+			}
+		}
+	}
+	return closest
+}
+
+// A boxGrid is a spatial index over bounding boxes. It is a uniform grid
+// of square cells, each of which lists the boxes that overlap the cell.
+type boxGrid struct {
+	bounds     box // the bounding box of all boxes
+	cellSize   float32
+	cols, rows int
+	cells      [][]int
+}
+
+// newBoxGrid builds a grid over the boxes with the indices in order, which
+// is also the order in which the cells list them. The grid is sized so that
+// there are about as many cells as boxes.
+func newBoxGrid(boxes []box, order []int) boxGrid {
+	var g boxGrid
+	if len(order) == 0 {
+		return g
+	}
+	g.bounds = boxes[order[0]]
+	for _, i := range order[1:] {
+		g.bounds = bounds(g.bounds.min, g.bounds.max, boxes[i].min, boxes[i].max)
+	}
+	w, h := g.bounds.max.X-g.bounds.min.X, g.bounds.max.Y-g.bounds.min.Y
+	n := float32(len(order))
+	g.cellSize = max(float32(math.Sqrt(float64(w*h/n))), max(w, h)/n, 1e-6)
+	g.cols = int(w/g.cellSize) + 1
+	g.rows = int(h/g.cellSize) + 1
+	g.cells = make([][]int, g.cols*g.rows)
+	for _, i := range order {
+		c0, r0 := g.cell(boxes[i].min)
+		c1, r1 := g.cell(boxes[i].max)
+		for r := r0; r <= r1; r++ {
+			for c := c0; c <= c1; c++ {
+				g.cells[r*g.cols+c] = append(g.cells[r*g.cols+c], i)
+			}
+		}
+	}
+	return g
+}
+
+// cell returns the column and row of the grid cell that contains point pt,
+// clamped to the grid.
+func (g *boxGrid) cell(pt geom.Vec2) (c, r int) {
+	c = int(math.Floor(float64((pt.X - g.bounds.min.X) / g.cellSize)))
+	r = int(math.Floor(float64((pt.Y - g.bounds.min.Y) / g.cellSize)))
+	return min(max(c, 0), g.cols-1), min(max(r, 0), g.rows-1)
+}
+
+// at returns the indices of the boxes that overlap the grid cell that
+// contains point pt, or nil if pt lies outside of all boxes.
+func (g *boxGrid) at(pt geom.Vec2) []int {
+	if len(g.cells) == 0 || !isFinite(pt.X) || !isFinite(pt.Y) || !g.bounds.contains(pt) {
+		return nil
+	}
+	c, r := g.cell(pt)
+	return g.cells[r*g.cols+c]
+}
+
+// path finds the shortest path from start to dest through the navigation
+// mesh. It returns nil if dest is not reachable.
+func (m *navMesh) path(start, dest geom.Vec2) []geom.Vec2 {
+	path, _ := m.findNearest(start, []geom.Vec2{dest})
+	return path
+}
+
+// findNearest finds the shortest path from start to the closest of the
+// goals through the navigation mesh. It returns the path and the index of
+// the reached goal, or nil and -1 if none of the goals is reachable.
+func (m *navMesh) findNearest(start geom.Vec2, goals []geom.Vec2) ([]geom.Vec2, int) {
+	if len(m.tris) == 0 || len(goals) == 0 {
+		return nil, -1
+	}
+	var path []geom.Vec2
+	goal := -1
+	m.query(start, goals, true).explore(func(p []geom.Vec2, g int) bool {
+		path, goal = p, g
+		return true
+	})
+	return path, goal
+}
+
+// findAll finds the shortest paths from start to each of the goals through
+// the navigation mesh in a single search. The path to an unreachable goal
+// is nil.
+func (m *navMesh) findAll(start geom.Vec2, goals []geom.Vec2) [][]geom.Vec2 {
+	paths := make([][]geom.Vec2, len(goals))
+	if len(m.tris) == 0 || len(goals) == 0 {
+		return paths
+	}
+	left := len(goals)
+	m.query(start, goals, false).explore(func(p []geom.Vec2, g int) bool {
+		paths[g] = p
+		left--
+		return left == 0
+	})
+	return paths
+}
+
+// navMeshQuery is the search of a path query on a navMesh.
+//
+// The search is the Polyanya algorithm: a search node is the part of a
+// triangle edge that is visible from a root, the start point or a vertex
+// where the path turns, on the way into the triangle across this edge.
+// Since the shortest path only turns at vertices, and it is straight
+// between them, the sequence of the roots up to a goal is the path, and
+// no funnel algorithm is needed to pull it taut.
+type navMeshQuery struct {
+	mesh     *navMesh
+	start    geom.Vec2
+	startTri int
+	goals    []geom.Vec2
+	goalsIn  map[int][]int // goal indices by triangle
+	estimate bool          // use the A* heuristic instead of Dijkstra's algorithm
+
+	pq      nodeQueue[*meshNode]
+	best    map[geom.Vec2]float64 // shortest known path length to each root
+	visited map[meshKey]float64
+	reached []bool
+}
+
+// A meshNode is a node of the search of a navMeshQuery: the part of edge
+// k of triangle t that is visible from the root through the edges crossed
+// before. It lies between the lines left and right, which pass through the
+// root and are directed away from it, as seen from the root looking into
+// the triangle across the edge. If the root lies on the edge, the whole
+// triangle across the edge is visible, and the lines don't matter.
+// A node with a goal index of at least 0 is the goal reached from the root.
+type meshNode struct {
+	root        *meshRoot
+	g           float64 // length of the path from the start to the root
+	left, right [2]geom.Vec2
+	tri, edge   int
+	goal        int
+}
+
+// A meshRoot is a point of the path where it turns, linked to the root
+// before it.
+type meshRoot struct {
+	pt   geom.Vec2
+	prev *meshRoot
+}
+
+// meshKey identifies a search node independently of the path length to
+// its root.
+type meshKey struct {
+	root        geom.Vec2
+	left, right [2]geom.Vec2
+	tri, edge   int
+}
+
+func (m *navMesh) query(start geom.Vec2, goals []geom.Vec2, estimate bool) *navMeshQuery {
+	q := &navMeshQuery{
+		mesh:     m,
+		start:    start,
+		startTri: m.locate(start),
+		goals:    goals,
+		goalsIn:  make(map[int][]int),
+		estimate: estimate,
+		best:     make(map[geom.Vec2]float64),
+		visited:  make(map[meshKey]float64),
+		reached:  make([]bool, len(goals)),
+	}
+	for i, g := range goals {
+		t := m.locate(g)
+		q.goalsIn[t] = append(q.goalsIn[t], i)
+	}
+	return q
+}
+
+// explore searches the shortest paths from the start point to the goals.
+// It calls reached with each path and the index of its goal in the order
+// in which the goals are reached, until reached returns true.
+func (q *navMeshQuery) explore(reached func(path []geom.Vec2, goal int) bool) {
+	root := &meshRoot{pt: q.start}
+	q.best[q.start] = 0
+	for _, i := range q.goalsIn[q.startTri] {
+		q.pushGoal(root, nodeDist(q.start, q.goals[i]), i)
+	}
+	tri := q.mesh.tris[q.startTri]
+	for k := range 3 {
+		q.push(root, 0, [2]geom.Vec2{q.start, tri[(k+1)%3]}, [2]geom.Vec2{q.start, tri[k]}, q.startTri, k)
+	}
+	for q.pq.Len() > 0 {
+		n := heap.Pop(&q.pq).(queuedNode[*meshNode]).node
+		if n.goal >= 0 {
+			if q.reached[n.goal] {
+				continue
+			}
+			q.reached[n.goal] = true
+			var path []geom.Vec2
+			for r := n.root; r != nil; r = r.prev {
+				path = append(path, r.pt)
+			}
+			slices.Reverse(path)
+			if reached(appendPoint(path, q.goals[n.goal]), n.goal) {
+				return
+			}
+			continue
+		}
+		if q.best[n.root.pt] < n.g {
+			// The root was reached by a shorter path in the meantime.
+			continue
+		}
+		q.expand(n)
+	}
+}
+
+// expand pushes the successors of search node n: the parts of the other
+// edges of the triangle across its edge that are visible from its root,
+// the parts that are only visible after turning at an end point of the
+// visible part of its edge, and the goals in the triangle.
+func (q *navMeshQuery) expand(n *meshNode) {
+	m := q.mesh
+	t := m.adj[n.tri][n.edge]
+	l, r := m.tris[n.tri][(n.edge+1)%3], m.tris[n.tri][n.edge]
+	// The triangle has the edges from l to r, from r to o and from o to l.
+	j := slices.Index(m.tris[t][:], l)
+	o := m.tris[t][(j+2)%3]
+	ro, ol := (j+1)%3, (j+2)%3
+	rp := n.root.pt
+
+	if orientation(l, r, rp) >= 0 {
+		// The root lies on the edge, so the whole triangle is visible.
+		for _, i := range q.goalsIn[t] {
+			q.pushGoal(n.root, n.g+nodeDist(rp, q.goals[i]), i)
+		}
+		q.push(n.root, n.g, [2]geom.Vec2{rp, o}, [2]geom.Vec2{rp, r}, t, ro)
+		q.push(n.root, n.g, [2]geom.Vec2{rp, l}, [2]geom.Vec2{rp, o}, t, ol)
+		return
+	}
+
+	// The visible part of the edge ends at l or r if the left or right
+	// line passes through it. Only then can the path turn there into the
+	// part of the triangle that is not visible from the root.
+	turnL := side(n.left, l) == 0
+	turnR := side(n.right, r) == 0
+	for _, i := range q.goalsIn[t] {
+		g := q.goals[i]
+		switch {
+		case side(n.left, g) <= 0 && side(n.right, g) >= 0:
+			q.pushGoal(n.root, n.g+nodeDist(rp, g), i)
+		case side(n.left, g) > 0 && turnL:
+			q.pushGoal(&meshRoot{pt: l, prev: n.root}, n.g+nodeDist(rp, l)+nodeDist(l, g), i)
+		case side(n.right, g) < 0 && turnR:
+			q.pushGoal(&meshRoot{pt: r, prev: n.root}, n.g+nodeDist(rp, r)+nodeDist(r, g), i)
+		}
+	}
+
+	sideL, sideR := side(n.left, o), side(n.right, o)
+	switch {
+	case sideL > 0:
+		// o lies left of the visible part.
+		q.push(n.root, n.g, n.left, n.right, t, ro)
+	case sideR < 0:
+		// o lies right of the visible part.
+		q.push(n.root, n.g, n.left, n.right, t, ol)
+	default:
+		if sideR > 0 {
+			q.push(n.root, n.g, [2]geom.Vec2{rp, o}, n.right, t, ro)
+		}
+		if sideL < 0 {
+			q.push(n.root, n.g, n.left, [2]geom.Vec2{rp, o}, t, ol)
+		}
+	}
+	// If o lies on the left or right line, the part that is not visible
+	// begins with the triangle across the edge from o to l or from r to o.
+	if turnL && sideL >= 0 {
+		g := n.g + nodeDist(rp, l)
+		if root := q.turn(n.root, l, g); root != nil {
+			q.push(root, g, [2]geom.Vec2{l, l}, [2]geom.Vec2{l, o}, t, ol)
+			if sideL > 0 {
+				q.push(root, g, [2]geom.Vec2{l, o}, n.left, t, ro)
+			}
+		}
+	}
+	if turnR && sideR <= 0 {
+		g := n.g + nodeDist(rp, r)
+		if root := q.turn(n.root, r, g); root != nil {
+			q.push(root, g, [2]geom.Vec2{r, o}, [2]geom.Vec2{r, r}, t, ro)
+			if sideR < 0 {
+				q.push(root, g, n.right, [2]geom.Vec2{r, o}, t, ol)
+			}
+		}
+	}
+}
+
+// turn returns a new root at vertex v after root prev, which is reached by
+// a path of length g, or nil if v was already reached by a shorter path.
+func (q *navMeshQuery) turn(prev *meshRoot, v geom.Vec2, g float64) *meshRoot {
+	if best, ok := q.best[v]; ok && best < g {
+		return nil
+	}
+	q.best[v] = g
+	return &meshRoot{pt: v, prev: prev}
+}
+
+// push adds the search node for the part of edge k of triangle t between
+// the lines left and right that is visible from the root, unless the edge
+// is a polygon edge or the node was already added with a path length
+// that is not longer.
+func (q *navMeshQuery) push(root *meshRoot, g float64, left, right [2]geom.Vec2, t, k int) {
+	if q.mesh.adj[t][k] < 0 {
+		return
+	}
+	l, r := q.mesh.tris[t][(k+1)%3], q.mesh.tris[t][k]
+	rp := root.pt
+	onEdge := orientation(l, r, rp) >= 0
+	if onEdge && !bounds(l, r).contains(rp) {
+		// The root lies on the line through the edge, but not on the edge.
+		return
+	}
+	key := meshKey{root: rp, left: left, right: right, tri: t, edge: k}
+	if old, ok := q.visited[key]; ok && old <= g {
+		return
+	}
+	q.visited[key] = g
+	f := g
+	if q.estimate {
+		a, b := l, r
+		if !onEdge {
+			a, b = clipEdge(left, l, r), clipEdge(right, r, l)
+		}
+		h := math.Inf(1)
+		for _, goal := range q.goals {
+			h = min(h, detour(rp, a, b, goal))
+		}
+		f += h
+	}
+	n := &meshNode{root: root, g: g, left: left, right: right, tri: t, edge: k, goal: -1}
+	heap.Push(&q.pq, queuedNode[*meshNode]{node: n, priority: f})
+}
+
+// pushGoal adds the goal with index i, which is reached by a path of
+// length g via the root.
+func (q *navMeshQuery) pushGoal(root *meshRoot, g float64, i int) {
+	if q.reached[i] {
+		return
+	}
+	n := &meshNode{root: root, g: g, goal: i}
+	heap.Push(&q.pq, queuedNode[*meshNode]{node: n, priority: g})
+}
+
+// orientation returns +1 if the points a, b, c are in counterclockwise
+// order, -1 if they are in clockwise order, and 0 if they are collinear.
+// The result is exact, see poly.Line.Side.
+func orientation(a, b, c geom.Vec2) int {
+	return -poly.Line{Seg: poly.LineSeg{A: a, B: b}}.Side(c)
+}
+
+// side returns the side of point pt relative to the directed line ln: +1
+// on the left, -1 on the right, and 0 on the line. The result is exact.
+func side(ln [2]geom.Vec2, pt geom.Vec2) int {
+	return orientation(ln[0], ln[1], pt)
+}
+
+// clipEdge returns the point where line ln intersects the triangle edge
+// from a to b, or a if ln passes through a. It is only used to estimate
+// path lengths, so it needn't be exact.
+func clipEdge(ln [2]geom.Vec2, a, b geom.Vec2) geom.Vec2 {
+	if side(ln, a) == 0 {
+		return a
+	}
+	ux, uy := float64(ln[1].X)-float64(ln[0].X), float64(ln[1].Y)-float64(ln[0].Y)
+	vx, vy := float64(b.X)-float64(a.X), float64(b.Y)-float64(a.Y)
+	wx, wy := float64(a.X)-float64(ln[0].X), float64(a.Y)-float64(ln[0].Y)
+	d := ux*vy - uy*vx
+	if d == 0 {
+		return a
+	}
+	s := min(max((uy*wx-ux*wy)/d, 0), 1)
+	return a.Lerp(b, float32(s))
+}
+
+// detour returns the length of the shortest polyline from point from via
+// a point of the line segment from a to b to point to, which is the
+// heuristic of the search. Mirroring to at the line through a and b if it
+// lies on the same side as from doesn't change its distance to the points
+// on the line segment, but then the straight line from from to to crosses
+// the line, and the closest point of the line segment to the crossing is
+// the point of the shortest polyline.
+func detour(from, a, b, to geom.Vec2) float64 {
+	ax, ay := float64(a.X), float64(a.Y)
+	dx, dy := float64(b.X)-ax, float64(b.Y)-ay
+	l2 := dx*dx + dy*dy
+	if l2 == 0 {
+		return nodeDist(from, a) + nodeDist(a, to)
+	}
+	fx, fy := float64(from.X), float64(from.Y)
+	tx, ty := float64(to.X), float64(to.Y)
+	// sf and st are the distances from the line, scaled by its length.
+	sf := (dx*(fy-ay) - dy*(fx-ax)) / l2
+	st := (dx*(ty-ay) - dy*(tx-ax)) / l2
+	if sf*st > 0 {
+		tx, ty = tx+2*st*dy, ty-2*st*dx
+		st = -st
+	}
+	cx, cy := fx, fy
+	if w := math.Abs(sf) + math.Abs(st); w > 0 {
+		u := math.Abs(sf) / w
+		cx, cy = fx+u*(tx-fx), fy+u*(ty-fy)
+	}
+	s := min(max(((cx-ax)*dx+(cy-ay)*dy)/l2, 0), 1)
+	px, py := ax+s*dx, ay+s*dy
+	return math.Hypot(px-fx, py-fy) + math.Hypot(tx-px, ty-py)
+}
+
+// appendPoint appends point pt to the path unless it repeats the last point.
+func appendPoint(path []geom.Vec2, pt geom.Vec2) []geom.Vec2 {
+	if len(path) > 0 && path[len(path)-1] == pt {
+		return path
+	}
+	return append(path, pt)
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"image"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+func TestNavMeshBackend(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygons [][]geom.Vec2
+		start    geom.Vec2
+		dest     geom.Vec2
+	}{
+		{"U two corners", polygonUF, geom.V2(2.5, 2.5), geom.V2(12.5, 2.5)},
+		{"U one corner", polygonUF, geom.V2(2.5, 2.5), geom.V2(12.5, 9.5)},
+		{"U straight", polygonUF, geom.V2(1, 9), geom.V2(15, 9)},
+		{"U same point", polygonUF, geom.V2(2.5, 2.5), geom.V2(2.5, 2.5)},
+		{"U destination moved", polygonUF, geom.V2(2.5, 2.5), geom.V2(7.5, 2.5)},
+		{"O around hole", vecPolygons(polygonO), geom.V2(20, 5), geom.V2(18, 35)},
+		{"O left of hole", vecPolygons(polygonO), geom.V2(5, 20), geom.V2(15, 35)},
+		{"O outside hole", vecPolygons(polygonO), geom.V2(20, 5), geom.V2(20, 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vis := pathfind.NewPathfinderF(tt.polygons)
+			mesh := pathfind.NewPathfinderF(tt.polygons, pathfind.WithBackend(pathfind.NavMeshBackend))
+			want, wantErr := vis.Find(tt.start, tt.dest)
+			got, err := mesh.Find(tt.start, tt.dest)
+			if err != wantErr {
+				t.Errorf("Find(%v, %v): got error %v, want %v", tt.start, tt.dest, err, wantErr)
+			}
+			if !equalResults(got, want) {
+				t.Errorf("Find(%v, %v)\n got: %+v\nwant: %+v", tt.start, tt.dest, got, want)
+			}
+			if g := mesh.VisibilityGraphF(); g != nil {
+				t.Errorf("VisibilityGraphF() = %v, want nil", g)
+			}
+		})
+	}
+}
+
+func TestNavMeshBackendRandom(t *testing.T) {
+	// Squares with 5×5 holes: quadrilaterals of different shapes, and
+	// aligned squares, whose collinear edges the paths run along.
+	var quads, squares [][]geom.Vec2
+	for _, ps := range []*[][]geom.Vec2{&quads, &squares} {
+		*ps = append(*ps, []geom.Vec2{geom.V2(0, 0), geom.V2(60, 0), geom.V2(60, 60), geom.V2(0, 60)})
+	}
+	for i := range 5 {
+		for j := range 5 {
+			x, y := float32(10*i+8), float32(10*j+8)
+			quads = append(quads, []geom.Vec2{
+				geom.V2(x, y), geom.V2(x+4, y), geom.V2(x+4+float32(i%3), y+4), geom.V2(x, y+4+float32(j%2)),
+			})
+			squares = append(squares, []geom.Vec2{
+				geom.V2(x, y), geom.V2(x+4, y), geom.V2(x+4, y+4), geom.V2(x, y+4),
+			})
+		}
+	}
+	for _, polygons := range [][][]geom.Vec2{quads, squares} {
+		vis := pathfind.NewPathfinderF(polygons)
+		mesh := pathfind.NewPathfinderF(polygons, pathfind.WithBackend(pathfind.NavMeshBackend))
+		r := rand.New(rand.NewPCG(1, 2))
+		for range 500 {
+			var start, dest geom.Vec2
+			for {
+				start = geom.V2(r.Float32()*60, r.Float32()*60)
+				dest = geom.V2(r.Float32()*60, r.Float32()*60)
+				if vis.Contains(start) && vis.Contains(dest) {
+					break
+				}
+			}
+			want, err := vis.Find(start, dest)
+			if err != nil {
+				t.Fatalf("visibility graph: Find(%v, %v): unexpected error: %v", start, dest, err)
+			}
+			got, err := mesh.Find(start, dest)
+			if err != nil {
+				t.Fatalf("navigation mesh: Find(%v, %v): unexpected error: %v", start, dest, err)
+			}
+			path := got.Path
+			if path[0] != start || path[len(path)-1] != dest {
+				t.Errorf("Find(%v, %v): path %v does not connect start and destination", start, dest, path)
+			}
+			for i := 1; i < len(path); i++ {
+				// Segments can run along collinear hole edges, which
+				// InLineOfSight doesn't accept, so sample them instead.
+				// Samples on the edges are rounded and can be just outside.
+				for k := range 11 {
+					pt := path[i-1].Lerp(path[i], float32(k)/10)
+					if !vis.Contains(pt) && vis.ClosestPt(pt).Dist(pt) > 1e-4 {
+						t.Errorf("Find(%v, %v): path %v leaves the polygon set at %v", start, dest, path, pt)
+					}
+				}
+			}
+			// Both backends find the shortest path, up to rounding errors.
+			if math.Abs(got.Length-want.Length) > 1e-9*max(want.Length, 1) {
+				t.Errorf("Find(%v, %v): got path length %g, want shortest path length %g", start, dest, got.Length, want.Length)
+			}
+		}
+	}
+}
+
+func TestNavMeshBackendFindNearest(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF(polygonUF, pathfind.WithBackend(pathfind.NavMeshBackend))
+	start := geom.V2(2.5, 2.5)
+	dests := []geom.Vec2{geom.V2(12.5, 2.5), geom.V2(12.5, 9.5), geom.V2(40, 40)}
+	got, goal, err := pathfinder.FindNearest(start, dests)
+	if err != nil {
+		t.Fatalf("FindNearest(%v, %v): unexpected error: %v", start, dests, err)
+	}
+	if goal != 1 {
+		t.Errorf("FindNearest(%v, %v): got goal %d, want 1", start, dests, goal)
+	}
+	want := []geom.Vec2{geom.V2(2.5, 2.5), geom.V2(5.4375, 5.25), geom.V2(12.5, 9.5)}
+	if !reflect.DeepEqual(got.Path, want) {
+		t.Errorf("FindNearest(%v, %v)\n got path: %v\nwant path: %v", start, dests, got.Path, want)
+	}
+}
+
+func TestNavMeshBackendFindNearestNoDests(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF(polygonUF, pathfind.WithBackend(pathfind.NavMeshBackend))
+	start := geom.V2(2.5, 2.5)
+	got, goal, err := pathfinder.FindNearest(start, nil)
+	if err != pathfind.ErrUnreachable || goal != -1 || got.Path != nil {
+		t.Errorf("FindNearest(%v, nil) = %+v, %d, %v; want no path, -1, %v", start, got, goal, err, pathfind.ErrUnreachable)
+	}
+}
+
+func TestNavMeshBackendUnreachable(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF([][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)},
+		{geom.V2(20, 0), geom.V2(30, 0), geom.V2(30, 10), geom.V2(20, 10)},
+	}, pathfind.WithBackend(pathfind.NavMeshBackend))
+	start, dest := geom.V2(5, 5), geom.V2(25, 5)
+	if _, err := pathfinder.Find(start, dest); err != pathfind.ErrUnreachable {
+		t.Errorf("Find(%v, %v): got error %v, want %v", start, dest, err, pathfind.ErrUnreachable)
+	}
+}
+
+func TestNavMeshBackendRegions(t *testing.T) {
+	region := pathfind.Region{
+		Polygon: []geom.Vec2{geom.V2(1, 6), geom.V2(4, 6), geom.V2(4, 9), geom.V2(1, 9)},
+		Cost:    2,
+	}
+	_, err := pathfind.NewCheckedPathfinderF(polygonUF,
+		pathfind.WithBackend(pathfind.NavMeshBackend), pathfind.WithRegions(region))
+	want := "pathfind: regions are not supported by the navigation mesh backend"
+	if err == nil || err.Error() != want {
+		t.Errorf("NewCheckedPathfinderF with regions: got error %v, want %s", err, want)
+	}
+}
+
+func vecPolygons(polygons [][]image.Point) [][]geom.Vec2 {
+	vs := make([][]geom.Vec2, len(polygons))
+	for i, p := range polygons {
+		for _, pt := range p {
+			vs[i] = append(vs[i], geom.V2(float32(pt.X), float32(pt.Y)))
+		}
+	}
+	return vs
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import "github.com/fzipp/pathfind/internal/poly"
+
+// nesting describes how the polygons of a polygon set are nested. It holds
+// for each polygon the index of its parent, the innermost polygon that
+// contains it, or -1 for polygons at the first level.
+type nesting []int
+
+// nestingOf derives the nesting of the polygons of polygon set ps from
+// their containment. A polygon is considered inside another polygon if its
+// first vertex is.
+func nestingOf(ps poly.PolygonSet) nesting {
+	parents := make(nesting, len(ps))
+	for i, p := range ps {
+		parents[i] = -1
+		if len(p) == 0 {
+			continue
+		}
+		for j, q := range ps {
+			if i == j || !q.Contains(p[0], false) {
+				continue
+			}
+			if k := parents[i]; k < 0 || ps[k].Contains(q[0], false) {
+				parents[i] = j
+			}
+		}
+	}
+	return parents
+}
+
+// isTree reports whether the parents form a tree, i.e. whether no polygon
+// is its own ancestor.
+func (n nesting) isTree() bool {
+	for i := range n {
+		steps := 0
+		for j := n[i]; j >= 0; j = n[j] {
+			if steps++; steps > len(n) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// depth returns the nesting depth of polygon i, which is 0 for polygons at
+// the first level.
+func (n nesting) depth(i int) int {
+	d := 0
+	for j := n[i]; j >= 0; j = n[j] {
+		d++
+	}
+	return d
+}
+
+// depths returns the nesting depths of all polygons.
+func (n nesting) depths() []int {
+	ds := make([]int, len(n))
+	for i := range n {
+		ds[i] = n.depth(i)
+	}
+	return ds
+}
+
+// isHole reports whether polygon i is a hole, i.e. whether its nesting
+// depth is odd.
+func (n nesting) isHole(i int) bool {
+	return n.depth(i)%2 != 0
+}
+
+// without returns the nesting after removing polygon i, which must not be
+// the parent of another polygon.
+func (n nesting) without(i int) nesting {
+	m := make(nesting, 0, len(n)-1)
+	for j, parent := range n {
+		if j == i {
+			continue
+		}
+		if parent > i {
+			parent--
+		}
+		m = append(m, parent)
+	}
+	return m
+}
@@ -0,0 +1,259 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"slices"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// ErrObstacleOutside is returned by AddObstacle and AddObstacleF if the
+// obstacle polygon is not entirely inside the accessible area, i.e. if it
+// overlaps a polygon edge or surrounds another polygon.
+var ErrObstacleOutside = errors.New("pathfind: obstacle not inside accessible area")
+
+// An Obstacle is a hole that was added to the polygon set of a Pathfinder
+// at runtime by AddObstacle or AddObstacleF. It serves as a handle to
+// remove the hole again with RemoveObstacle.
+type Obstacle struct {
+	polygon  poly.Polygon // the hole in the polygon set
+	vertices []geom.Vec2  // vertices of the hole added to the visibility graph
+	covered  []geom.Vec2  // region vertices removed from the visibility graph
+}
+
+// AddObstacle adds a hole polygon, e.g. a closed door or a crate, to the
+// polygon set of the Pathfinder and returns a handle to remove it again.
+// The polygon must lie inside the accessible area without touching any
+// other polygon, otherwise AddObstacle returns ErrObstacleOutside. An
+// invalid polygon is reported like by NewCheckedPathfinder.
+//
+// Instead of repeating the preprocessing of NewPathfinder, only the
+// vertices of the obstacle are added to the visibility graph, and only the
+// edges of the graph whose line segments pass the obstacle are checked
+// for being blocked. With the NavMeshBackend, the navigation mesh is
+// rebuilt. If the Pathfinder was configured with a radius, the obstacle is
+// inflated by the radius, and the inflated polygon must lie inside the
+// accessible area, which is shrunk by the radius. If the inflated polygon
+// intersects itself, e.g. because of a gap narrower than 2r between parts
+// of the obstacle, AddObstacle returns ErrOffsetOverlap.
+func (p *Pathfinder) AddObstacle(polygon []image.Point) (*Obstacle, error) {
+	return p.AddObstacleF(ps2vs(polygon))
+}
+
+// AddObstacleF is like AddObstacle, but the polygon vertices are given as
+// floating-point coordinates.
+func (p *Pathfinder) AddObstacleF(polygon []geom.Vec2) (*Obstacle, error) {
+	hole := poly.Polygon(slices.Clone(polygon))
+	if err := validatePolygon(hole); err != nil {
+		return nil, fmt.Errorf("pathfind: obstacle: %w", err.Err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.radius > 0 {
+		hole = hole.Offset(p.radius, p.join.maxAngle())
+		if err := validatePolygon(hole); err != nil {
+			return nil, fmt.Errorf("pathfind: obstacle: %w", ErrOffsetOverlap)
+		}
+	}
+	parent := p.container(hole)
+	if parent < 0 {
+		return nil, ErrObstacleOutside
+	}
+	o := &Obstacle{polygon: hole}
+	p.polygonSet = append(slices.Clip(p.polygonSet), hole)
+	p.nesting = append(slices.Clip(p.nesting), parent)
+	p.index.Insert(hole)
+	if p.mesh != nil {
+		p.mesh = newNavMesh(p.polygonSet.TriangulateNested(p.nesting, p.nesting.depths()), p.index)
+	} else {
+		p.insertHole(o)
+	}
+	if p.obstacles == nil {
+		p.obstacles = make(map[*Obstacle]bool)
+	}
+	p.obstacles[o] = true
+	p.lastGraph.Store(nil)
+	return o, nil
+}
+
+// RemoveObstacle removes an obstacle that was added by AddObstacle or
+// AddObstacleF from the polygon set. Like for adding, only the affected
+// parts of the visibility graph are updated. It reports false if the
+// obstacle was not added to this Pathfinder or was already removed.
+func (p *Pathfinder) RemoveObstacle(o *Obstacle) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.obstacles[o] {
+		return false
+	}
+	delete(p.obstacles, o)
+	i := slices.IndexFunc(p.polygonSet, func(q poly.Polygon) bool {
+		return len(q) > 0 && &q[0] == &o.polygon[0]
+	})
+	p.polygonSet = slices.Delete(slices.Clone(p.polygonSet), i, i+1)
+	p.nesting = p.nesting.without(i)
+	p.index.Remove(i)
+	if p.mesh != nil {
+		p.mesh = newNavMesh(p.polygonSet.TriangulateNested(p.nesting, p.nesting.depths()), p.index)
+	} else {
+		p.extractHole(o)
+	}
+	p.lastGraph.Store(nil)
+	return true
+}
+
+// container returns the index of the innermost polygon of the polygon set
+// that contains the hole polygon, or -1 if the hole is not entirely inside
+// the accessible area.
+func (p *Pathfinder) container(hole poly.Polygon) int {
+	for i, v := range hole {
+		if !p.index.Contains(v) || p.index.IsCrossedBy(hole.Edge(i)) {
+			return -1
+		}
+	}
+	container := -1
+	for i, q := range p.polygonSet {
+		if len(q) == 0 {
+			continue
+		}
+		if hole.Contains(q[0], true) {
+			return -1
+		}
+		if q.Contains(hole[0], false) && (container < 0 || p.polygonSet[container].Contains(q[0], false)) {
+			container = i
+		}
+	}
+	return container
+}
+
+// insertHole updates the visibility graph for the hole of obstacle o,
+// which was added to the polygon set.
+func (p *Pathfinder) insertHole(o *Obstacle) {
+	b := bounds(o.polygon...)
+	for a, nbs := range p.staticGraph {
+		if !slices.ContainsFunc(nbs, func(n geom.Vec2) bool {
+			return b.overlaps(bounds(a, n))
+		}) {
+			continue
+		}
+		p.setNeighbours(a, slices.DeleteFunc(slices.Clone(nbs), func(n geom.Vec2) bool {
+			if !b.overlaps(bounds(a, n)) || inLineOfSight(p.index, a, n) {
+				return false
+			}
+			delete(p.edgeCosts, [2]geom.Vec2{a, n})
+			return true
+		}))
+	}
+	for _, v := range p.vertices {
+		if b.contains(v) && !p.index.Contains(v) {
+			o.covered = append(o.covered, v)
+		}
+	}
+	p.removeVertices(o.covered)
+	o.vertices = verticesOfType(o.polygon, convex)
+	p.addVertices(o.vertices)
+}
+
+// extractHole updates the visibility graph for the hole of obstacle o,
+// which was removed from the polygon set.
+func (p *Pathfinder) extractHole(o *Obstacle) {
+	p.removeVertices(o.vertices)
+	b := bounds(o.polygon...)
+	for i, v := range p.vertices {
+		for j, w := range p.vertices {
+			if i == j || v == w || !b.overlaps(bounds(v, w)) ||
+				slices.Contains(p.staticGraph[v], w) || !inLineOfSight(p.index, v, w) {
+				continue
+			}
+			p.link(v, w)
+		}
+	}
+	p.addVertices(o.covered)
+}
+
+// addVertices adds vertices to the visibility graph and links them with
+// all vertices in their line of sight.
+func (p *Pathfinder) addVertices(vs []geom.Vec2) {
+	for _, v := range vs {
+		for _, w := range p.vertices {
+			if v != w && inLineOfSight(p.index, v, w) && !slices.Contains(p.staticGraph[v], w) {
+				p.link(v, w)
+				p.link(w, v)
+			}
+		}
+		p.vertices = append(p.vertices, v)
+	}
+}
+
+// removeVertices removes one occurrence of each of the vertices from the
+// visibility graph, and their edges unless the vertex occurs again.
+func (p *Pathfinder) removeVertices(vs []geom.Vec2) {
+	for _, v := range vs {
+		i := slices.Index(p.vertices, v)
+		if i < 0 {
+			continue
+		}
+		p.vertices = slices.Delete(p.vertices, i, i+1)
+		if slices.Contains(p.vertices, v) {
+			continue
+		}
+		for _, w := range p.staticGraph[v] {
+			p.setNeighbours(w, slices.DeleteFunc(slices.Clone(p.staticGraph[w]), func(n geom.Vec2) bool {
+				return n == v
+			}))
+			delete(p.edgeCosts, [2]geom.Vec2{v, w})
+			delete(p.edgeCosts, [2]geom.Vec2{w, v})
+		}
+		delete(p.staticGraph, v)
+	}
+}
+
+// setNeighbours replaces the neighbours of vertex v in the visibility
+// graph. A vertex without neighbours is removed from the graph.
+func (p *Pathfinder) setNeighbours(v geom.Vec2, nbs []geom.Vec2) {
+	if len(nbs) == 0 {
+		delete(p.staticGraph, v)
+		return
+	}
+	p.staticGraph[v] = nbs
+}
+
+// link creates a directed edge from vertex a to vertex b in the visibility
+// graph and calculates its cost if there are regions.
+func (p *Pathfinder) link(a, b geom.Vec2) {
+	p.staticGraph.link(a, b)
+	if len(p.regions) > 0 {
+		p.edgeCosts[[2]geom.Vec2{a, b}] = weightedDist(p.regions, a, b)
+	}
+}
+
+// box is an axis-aligned bounding box.
+type box struct {
+	min, max geom.Vec2
+}
+
+// bounds returns the bounding box of the points.
+func bounds(pts ...geom.Vec2) box {
+	b := box{min: pts[0], max: pts[0]}
+	for _, pt := range pts[1:] {
+		b.min = geom.V2(min(b.min.X, pt.X), min(b.min.Y, pt.Y))
+		b.max = geom.V2(max(b.max.X, pt.X), max(b.max.Y, pt.Y))
+	}
+	return b
+}
+
+func (b box) overlaps(c box) bool {
+	return b.min.X <= c.max.X && c.min.X <= b.max.X &&
+		b.min.Y <= c.max.Y && c.min.Y <= b.max.Y
+}
+
+func (b box) contains(pt geom.Vec2) bool {
+	return b.overlaps(box{pt, pt})
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"errors"
+	"image"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+// obstacleSquare is a square area for obstacle tests.
+var obstacleSquare = []geom.Vec2{
+	geom.V2(0, 0), geom.V2(60, 0), geom.V2(60, 60), geom.V2(0, 60),
+}
+
+func squareHole(x, y, size float32) []geom.Vec2 {
+	return []geom.Vec2{
+		geom.V2(x, y), geom.V2(x+size, y), geom.V2(x+size, y+size), geom.V2(x, y+size),
+	}
+}
+
+func TestPathfinderAddObstacle(t *testing.T) {
+	holes := [][]geom.Vec2{
+		squareHole(10, 10, 10),
+		squareHole(40, 10, 10),
+		// reversed winding order
+		{geom.V2(25, 30), geom.V2(25, 40), geom.V2(35, 40), geom.V2(35, 30)},
+		squareHole(10, 45, 5),
+		// covers a region vertex
+		squareHole(42, 42, 6),
+	}
+	mud := pathfind.Region{Polygon: squareHole(20, 20, 25), Cost: 2}
+	for _, opts := range [][]pathfind.Option{
+		nil,
+		{pathfind.WithRegions(mud)},
+	} {
+		pathfinder := pathfind.NewPathfinderF([][]geom.Vec2{obstacleSquare}, opts...)
+		var obstacles []*pathfind.Obstacle
+		for i, h := range holes {
+			o, err := pathfinder.AddObstacleF(h)
+			if err != nil {
+				t.Fatalf("AddObstacleF(%v): unexpected error: %v", h, err)
+			}
+			obstacles = append(obstacles, o)
+			want := pathfind.NewPathfinderF(append([][]geom.Vec2{obstacleSquare}, holes[:i+1]...), opts...)
+			assertSamePathfinders(t, pathfinder, want)
+		}
+		// Remove the obstacles in a different order.
+		removed := make([]bool, len(holes))
+		for _, i := range []int{1, 4, 3, 0, 2} {
+			if !pathfinder.RemoveObstacle(obstacles[i]) {
+				t.Fatalf("RemoveObstacle(obstacle %d) = false, want true", i)
+			}
+			removed[i] = true
+			polygons := [][]geom.Vec2{obstacleSquare}
+			for j, h := range holes {
+				if !removed[j] {
+					polygons = append(polygons, h)
+				}
+			}
+			assertSamePathfinders(t, pathfinder, pathfind.NewPathfinderF(polygons, opts...))
+		}
+		if pathfinder.RemoveObstacle(obstacles[0]) {
+			t.Errorf("RemoveObstacle of removed obstacle = true, want false")
+		}
+	}
+}
+
+// assertSamePathfinders compares the visibility graphs and paths of
+// the Pathfinders for a few queries.
+func assertSamePathfinders(t *testing.T, got, want *pathfind.Pathfinder) {
+	t.Helper()
+	queries := [][2]geom.Vec2{
+		{geom.V2(5, 5), geom.V2(55, 55)},
+		{geom.V2(15, 5), geom.V2(15, 55)},
+		{geom.V2(5, 35), geom.V2(55, 30)},
+	}
+	for _, q := range queries {
+		gotRes, gotErr := got.Find(q[0], q[1])
+		wantRes, wantErr := want.Find(q[0], q[1])
+		if gotErr != wantErr || !nearEqFloat(gotRes.Cost, wantRes.Cost) {
+			t.Errorf("Find(%v, %v)\n got: %+v, %v\nwant: %+v, %v", q[0], q[1], gotRes, gotErr, wantRes, wantErr)
+		}
+		if g, w := sortedGraph(got.VisibilityGraphF()), sortedGraph(want.VisibilityGraphF()); !reflect.DeepEqual(g, w) {
+			t.Errorf("VisibilityGraphF() after Find(%v, %v)\n got: %v\nwant: %v", q[0], q[1], g, w)
+		}
+	}
+}
+
+// sortedGraph sorts the neighbours of each node and removes duplicate
+// neighbours and self-loops, which the visibility graph of a new Pathfinder
+// has for vertices at the same position, like a region vertex on a hole
+// vertex.
+func sortedGraph(g map[geom.Vec2][]geom.Vec2) map[geom.Vec2][]geom.Vec2 {
+	for n, nbs := range g {
+		nbs = slices.DeleteFunc(nbs, func(m geom.Vec2) bool { return m == n })
+		slices.SortFunc(nbs, func(a, b geom.Vec2) int {
+			if a.X != b.X {
+				return int(a.X - b.X)
+			}
+			return int(a.Y - b.Y)
+		})
+		g[n] = slices.Compact(nbs)
+	}
+	return g
+}
+
+func TestPathfinderAddObstacleErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		polygon []geom.Vec2
+		wantErr error
+	}{
+		{"Too few vertices", []geom.Vec2{geom.V2(10, 10), geom.V2(20, 20)}, pathfind.ErrTooFewVertices},
+		{"Outside", squareHole(70, 10, 5), pathfind.ErrObstacleOutside},
+		{"Overlapping edge", squareHole(55, 10, 10), pathfind.ErrObstacleOutside},
+		{"Inside hole", squareHole(22, 22, 2), pathfind.ErrObstacleOutside},
+		{"Overlapping hole", squareHole(15, 15, 10), pathfind.ErrObstacleOutside},
+		{"Surrounding hole", squareHole(5, 5, 40), pathfind.ErrObstacleOutside},
+	}
+	pathfinder := pathfind.NewPathfinderF([][]geom.Vec2{obstacleSquare, squareHole(20, 20, 10)})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := pathfinder.AddObstacleF(tt.polygon)
+			if !errors.Is(err, tt.wantErr) || o != nil {
+				t.Errorf("AddObstacleF(%v) = %v, %v; want nil, %v", tt.polygon, o, err, tt.wantErr)
+			}
+		})
+	}
+	if got := len(pathfinder.Polygons()); got != 2 {
+		t.Errorf("got %d polygons after failed AddObstacleF calls, want 2", got)
+	}
+}
+
+func TestPathfinderAddObstacleWithRadius(t *testing.T) {
+	// The accessible area shrinks to 4 ≤ x, y ≤ 56, and the obstacles are
+	// inflated by the radius.
+	pathfinder := pathfind.NewPathfinderF([][]geom.Vec2{obstacleSquare}, pathfind.WithRadius(4, pathfind.MiterJoin))
+	tooClose := squareHole(5, 40, 10)
+	if o, err := pathfinder.AddObstacleF(tooClose); err != pathfind.ErrObstacleOutside || o != nil {
+		t.Errorf("AddObstacleF(%v) = %v, %v; want nil, %v", tooClose, o, err, pathfind.ErrObstacleOutside)
+	}
+	if pt := geom.V2(4.5, 45); !pathfinder.Contains(pt) {
+		t.Errorf("Contains(%v) after failed AddObstacleF = false, want true", pt)
+	}
+	inside := squareHole(10, 40, 10)
+	if _, err := pathfinder.AddObstacleF(inside); err != nil {
+		t.Fatalf("AddObstacleF(%v): unexpected error: %v", inside, err)
+	}
+	if pt := geom.V2(8, 45); pathfinder.Contains(pt) {
+		t.Errorf("Contains(%v) inside the inflated obstacle = true, want false", pt)
+	}
+}
+
+func TestPathfinderAddObstacleWithRadiusSelfIntersection(t *testing.T) {
+	// The gap of the U-shaped obstacle is narrower than twice the radius,
+	// so that the inflated obstacle intersects itself.
+	pathfinder := pathfind.NewPathfinderF([][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(100, 0), geom.V2(100, 100), geom.V2(0, 100)},
+	}, pathfind.WithRadius(5, pathfind.MiterJoin))
+	u := []geom.Vec2{
+		geom.V2(50, 50), geom.V2(70, 50), geom.V2(70, 90), geom.V2(62, 90),
+		geom.V2(62, 60), geom.V2(58, 60), geom.V2(58, 90), geom.V2(50, 90),
+	}
+	if o, err := pathfinder.AddObstacleF(u); !errors.Is(err, pathfind.ErrOffsetOverlap) || o != nil {
+		t.Errorf("AddObstacleF(%v) = %v, %v; want nil, %v", u, o, err, pathfind.ErrOffsetOverlap)
+	}
+	if got := len(pathfinder.Polygons()); got != 1 {
+		t.Errorf("got %d polygons after failed AddObstacleF, want 1", got)
+	}
+}
+
+func TestPathfinderAddObstacleBlocksPath(t *testing.T) {
+	// A corridor that is closed by a door.
+	//
+	//	0,0 >-----------------+ 30,0
+	//	    | s     |d|     d |
+	//	0,5 +-----------------+ 30,5
+	corridor := [][]image.Point{
+		{image.Pt(0, 0), image.Pt(30, 0), image.Pt(30, 5), image.Pt(0, 5)},
+	}
+	door := []image.Point{image.Pt(14, 0), image.Pt(16, 0), image.Pt(16, 5), image.Pt(14, 5)}
+	for _, backend := range []pathfind.Backend{pathfind.VisibilityGraphBackend, pathfind.NavMeshBackend} {
+		pathfinder := pathfind.NewPathfinder(corridor, pathfind.WithBackend(backend))
+		start, dest := image.Pt(2, 2), image.Pt(28, 2)
+		if got := pathfinder.Path(start, dest); len(got) != 2 {
+			t.Errorf("backend %d: Path before closing the door = %v, want direct path", backend, got)
+		}
+		// The door touches the walls, so it can't be added as obstacle.
+		if _, err := pathfinder.AddObstacle(door); err != pathfind.ErrObstacleOutside {
+			t.Errorf("backend %d: AddObstacle(%v): got error %v, want %v", backend, door, err, pathfind.ErrObstacleOutside)
+		}
+		crate := []image.Point{image.Pt(14, 1), image.Pt(16, 1), image.Pt(16, 4), image.Pt(14, 4)}
+		o, err := pathfinder.AddObstacle(crate)
+		if err != nil {
+			t.Fatalf("backend %d: AddObstacle(%v): unexpected error: %v", backend, crate, err)
+		}
+		want := []image.Point{start, image.Pt(14, 1), image.Pt(16, 1), dest}
+		if got := pathfinder.Path(start, dest); !reflect.DeepEqual(got, want) {
+			t.Errorf("backend %d: Path around crate = %v, want %v", backend, got, want)
+		}
+		pathfinder.RemoveObstacle(o)
+		if got := pathfinder.Path(start, dest); len(got) != 2 {
+			t.Errorf("backend %d: Path after removing the crate = %v, want direct path", backend, got)
+		}
+	}
+}
+
+func TestPathfinderObstaclesConcurrent(t *testing.T) {
+	pathfinder := pathfind.NewPathfinderF([][]geom.Vec2{obstacleSquare})
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rand.New(rand.NewPCG(uint64(i), 0))
+			for range 50 {
+				start := geom.V2(r.Float32()*60, r.Float32()*60)
+				dest := geom.V2(r.Float32()*60, r.Float32()*60)
+				pathfinder.PathF(start, dest)
+			}
+		}()
+	}
+	for range 20 {
+		o, err := pathfinder.AddObstacleF(squareHole(20, 20, 10))
+		if err != nil {
+			t.Fatalf("AddObstacleF: unexpected error: %v", err)
+		}
+		pathfinder.RemoveObstacle(o)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"errors"
+	"math"
+
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// A Join determines the shape of the corners of the polygons that are offset
+// via the WithRadius option, where the offset edges of a polygon move apart.
+type Join int
+
+const (
+	// RoundJoin approximates a circular arc around the original corner.
+	RoundJoin Join = iota
+	// MiterJoin extends the offset edges until they meet. Sharp corners,
+	// where the miter would be longer than twice the offset distance,
+	// are bevelled.
+	MiterJoin
+)
+
+// maxAngle returns the maximum angle in radians spanned by a segment of
+// a corner arc with this join style. See poly.Polygon.Offset.
+func (j Join) maxAngle() float32 {
+	if j == MiterJoin {
+		return 2 * math.Pi / 3
+	}
+	return math.Pi / 8
+}
+
+// offsetPolygons shrinks the area polygons and inflates the holes of the
+// polygon set ps by distance r. Polygons that vanish are removed together
+// with the polygons nested inside them. It returns the offset polygon set,
+// its nesting, and for each offset polygon the index of its polygon in ps.
+func offsetPolygons(ps poly.PolygonSet, nest nesting, r float32, join Join) (poly.PolygonSet, nesting, []int) {
+	offset := make(poly.PolygonSet, len(ps))
+	for i, p := range ps {
+		d := -r
+		if nest.isHole(i) {
+			d = r
+		}
+		q := p.Offset(d, join.maxAngle())
+		if len(q) < 3 || (q.SignedArea() < 0) != (p.SignedArea() < 0) {
+			continue
+		}
+		offset[i] = q
+	}
+	kept := func(i int) bool {
+		for ; i >= 0; i = nest[i] {
+			if offset[i] == nil {
+				return false
+			}
+		}
+		return true
+	}
+	index := make([]int, len(ps))
+	res := make(poly.PolygonSet, 0, len(offset))
+	var src []int
+	for i, q := range offset {
+		index[i] = -1
+		if kept(i) {
+			index[i] = len(res)
+			res = append(res, q)
+			src = append(src, i)
+		}
+	}
+	resNest := make(nesting, 0, len(res))
+	for i := range offset {
+		if index[i] >= 0 {
+			parent := nest[i]
+			if parent >= 0 {
+				parent = index[parent]
+			}
+			resNest = append(resNest, parent)
+		}
+	}
+	return res, resNest, src
+}
+
+// validateOffset checks that the polygons of polygon set ps with the given
+// nesting still neither intersect themselves nor each other when they are
+// offset by distance r, like by offsetPolygons. This is not the case for
+// features narrower than 2r, e.g. a hole closer than 2r to the outline of
+// its area. The returned *PolygonError refers to the polygon in ps.
+func validateOffset(ps poly.PolygonSet, nest nesting, r float32, join Join) error {
+	if r == 0 {
+		return nil
+	}
+	offset, offsetNest, src := offsetPolygons(ps, nest, r, join)
+	for i, q := range offset {
+		if _, _, found := q.SelfIntersection(); found {
+			return &PolygonError{Polygon: src[i], Vertex: -1, Err: ErrOffsetOverlap}
+		}
+	}
+	var err *PolygonError
+	if errors.As(validateNesting(offset, offsetNest), &err) {
+		return &PolygonError{Polygon: src[err.Polygon], Vertex: -1, Err: ErrOffsetOverlap}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// An Option configures a Pathfinder created by NewPathfinder or
+// NewPathfinderF.
+type Option func(*config)
+
+// config holds the configuration of a Pathfinder as set by options.
+type config struct {
+	radius      float32
+	join        Join
+	startPolicy StartPolicy
+	regions     []Region
+	backend     Backend
+	search      searchConfig
+}
+
+// validate reports invalid option values.
+func (c config) validate() error {
+	if c.radius < 0 || !isFinite(c.radius) {
+		return fmt.Errorf("pathfind: invalid radius %v", c.radius)
+	}
+	if c.join != RoundJoin && c.join != MiterJoin {
+		return fmt.Errorf("pathfind: invalid join %d", c.join)
+	}
+	if c.startPolicy < RejectStart || c.startPolicy > ExitStart {
+		return fmt.Errorf("pathfind: invalid start policy %d", c.startPolicy)
+	}
+	if c.backend != VisibilityGraphBackend && c.backend != NavMeshBackend {
+		return fmt.Errorf("pathfind: invalid backend %d", c.backend)
+	}
+	if c.backend == NavMeshBackend && len(c.regions) > 0 {
+		return errors.New("pathfind: regions are not supported by the navigation mesh backend")
+	}
+	if c.search.algorithm < AStarSearch || c.search.algorithm > WeightedAStarSearch {
+		return fmt.Errorf("pathfind: invalid search %d", c.search.algorithm)
+	}
+	if !(c.search.epsilon >= 0) || math.IsInf(c.search.epsilon, 0) {
+		return fmt.Errorf("pathfind: invalid epsilon %v", c.search.epsilon)
+	}
+	if c.backend == NavMeshBackend && !c.search.isDefault() {
+		return errors.New("pathfind: search options are not supported by the navigation mesh backend")
+	}
+	for i, r := range c.regions {
+		if !(r.Cost > 0) || math.IsInf(r.Cost, 0) {
+			return fmt.Errorf("pathfind: region %d: invalid cost %v", i, r.Cost)
+		}
+		if err := validatePolygon(r.Polygon); err != nil {
+			return fmt.Errorf("pathfind: region %d: %w", i, err.Err)
+		}
+	}
+	return nil
+}
+
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithRadius configures the Pathfinder for a round agent with radius r
+// instead of a point.
+//
+// The area polygons are shrunk and the holes are inflated by r, and paths
+// are searched within this offset polygon set, so that they keep a distance
+// of at least r from every polygon edge. The join determines the shape of
+// the corners that are created by the offset. Area polygons that vanish
+// when shrunk by r are removed together with the polygons inside them.
+//
+// The offset is applied to each polygon individually. Features narrower
+// than 2r, such as narrow passages, holes close to each other or close
+// to an area boundary, result in overlapping or self-intersecting offset
+// polygons, which are not resolved. NewCheckedPathfinder and
+// NewPathfinderFromAreas report them with ErrOffsetOverlap; with the
+// other constructors they must be avoided in the input.
+//
+// The Path method rounds the waypoints on the offset polygons to integer
+// coordinates, which can bring them closer than r to a polygon edge by up to
+// half a unit. Use PathF to get exact waypoints.
+func WithRadius(r float32, join Join) Option {
+	return func(c *config) {
+		c.radius = r
+		c.join = join
+	}
+}
+
+// WithStartPolicy configures how path queries handle start points outside
+// the polygon set. The default is RejectStart.
+func WithStartPolicy(policy StartPolicy) Option {
+	return func(c *config) {
+		c.startPolicy = policy
+	}
+}
@@ -9,20 +9,44 @@ package pathfind
 import (
 	"image"
 	"math"
+	"sync"
+	"sync/atomic"
 
-	"github.com/fzipp/astar"
 	"github.com/fzipp/geom"
 	"github.com/fzipp/pathfind/internal/poly"
 )
 
 // A Pathfinder is created and initialized with a set of polygons via
-// NewPathfinder. Its Path method finds the shortest path between two points
-// in this polygon set.
+// NewPathfinder or NewPathfinderF. Its Path and PathF methods find the
+// shortest path between two points in this polygon set.
+// A preprocessed Pathfinder can be stored and restored with its
+// MarshalBinary and UnmarshalBinary methods. Obstacles can be added and
+// removed at runtime with AddObstacle and RemoveObstacle.
+//
+// A Pathfinder is safe for concurrent use by multiple goroutines.
 type Pathfinder struct {
-	polygons        [][]image.Point
-	polygonSet      poly.PolygonSet
-	concaveVertices []image.Point
-	visibilityGraph graph[image.Point]
+	// mu guards the state below against changes by AddObstacle,
+	// RemoveObstacle and UnmarshalBinary while it is read by queries.
+	mu sync.RWMutex
+
+	polygonSet  poly.PolygonSet
+	nesting     nesting
+	index       *poly.Index // spatial index over the edges of polygonSet
+	vertices    []geom.Vec2 // concave vertices and region vertices
+	staticGraph graph[geom.Vec2]
+	startPolicy StartPolicy
+	radius      float32
+	join        Join
+	regions     []region
+	edgeCosts   map[[2]geom.Vec2]float64 // costs of the staticGraph edges with regions
+	minCost     float64
+	mesh        *navMesh // navigation mesh with the NavMeshBackend, otherwise nil
+	obstacles   map[*Obstacle]bool
+	search      searchConfig
+
+	// lastGraph holds the visibility graph of the most recent path query.
+	// It is the only state written by path queries.
+	lastGraph atomic.Pointer[overlay[geom.Vec2]]
 }
 
 // NewPathfinder creates a Pathfinder instance and initializes it with a set of
@@ -38,41 +62,219 @@ type Pathfinder struct {
 //   - Polygons at the first level are area polygons.
 //   - Polygons contained inside an area polygon are holes.
 //   - Polygons contained inside a hole are area polygons again.
-func NewPathfinder(polygons [][]image.Point) *Pathfinder {
-	polygonSet := convert(polygons, func(ps []image.Point) poly.Polygon {
-		return ps2vs(ps)
-	})
-	return &Pathfinder{
-		polygons:        polygons,
-		polygonSet:      polygonSet,
-		concaveVertices: concaveVertices(polygonSet),
+//
+// The winding order of the polygons doesn't matter and can differ between
+// polygons. See NormalizeWinding to bring them into a canonical order.
+//
+// The visibility graph between the concave vertices of the polygon set is
+// calculated once by NewPathfinder, so that Path only has to connect the
+// start and destination points to it.
+//
+// The Pathfinder can be configured with options, see WithRadius,
+// WithStartPolicy, WithRegions, WithBackend and WithSearch.
+//
+// The coordinates are converted to float32 internally. Coordinates beyond
+// ±2^24 are rounded to the nearest representable value, which can move
+// vertices and make nearby polygons touch or intersect.
+//
+// NewPathfinder does not validate the polygon set. See NewCheckedPathfinder
+// for a variant that does and that reports such coordinates.
+func NewPathfinder(polygons [][]image.Point, opts ...Option) *Pathfinder {
+	return newPathfinder(polygonSetFromPoints(polygons), nil, newConfig(opts))
+}
+
+// NewPathfinderF is like NewPathfinder, but the polygon vertices are given
+// as floating-point coordinates. Use it together with PathF to find paths
+// without rounding the coordinates to integers.
+func NewPathfinderF(polygons [][]geom.Vec2, opts ...Option) *Pathfinder {
+	return newPathfinder(polygonSetFromVecs(polygons), nil, newConfig(opts))
+}
+
+// newPathfinder creates a Pathfinder for the polygon set with the given
+// nesting of the polygons. If the nesting is nil, it is derived from the
+// containment of the polygons.
+func newPathfinder(polygonSet poly.PolygonSet, nest nesting, c config) *Pathfinder {
+	if nest == nil {
+		nest = nestingOf(polygonSet)
+	}
+	if c.radius > 0 {
+		polygonSet, nest, _ = offsetPolygons(polygonSet, nest, c.radius, c.join)
+	}
+	index := poly.NewIndex(polygonSet)
+	if c.backend == NavMeshBackend {
+		return &Pathfinder{
+			polygonSet:  polygonSet,
+			nesting:     nest,
+			index:       index,
+			staticGraph: make(graph[geom.Vec2]),
+			startPolicy: c.startPolicy,
+			radius:      c.radius,
+			join:        c.join,
+			minCost:     1,
+			mesh:        newNavMesh(polygonSet.TriangulateNested(nest, nest.depths()), index),
+		}
+	}
+	regions := newRegions(c.regions)
+	vertices := append(concaveVertices(polygonSet, nest), regionVertices(regions, index)...)
+	p := &Pathfinder{
+		polygonSet:  polygonSet,
+		nesting:     nest,
+		index:       index,
+		vertices:    vertices,
+		staticGraph: visibilityGraph(index, vertices),
+		startPolicy: c.startPolicy,
+		radius:      c.radius,
+		join:        c.join,
+		regions:     regions,
+		minCost:     minCost(regions),
+		search:      c.search,
 	}
+	if len(regions) > 0 {
+		p.edgeCosts = edgeCosts(regions, p.staticGraph)
+	}
+	return p
 }
 
-// VisibilityGraph returns the calculated visibility graph from the last Path
-// call. It is only available after Path was called, otherwise nil.
+// VisibilityGraph returns the calculated visibility graph from the last path
+// query, including the start and destination points of that query.
+// It is only available after Path or PathF was called, otherwise nil.
+// If paths are queried concurrently, it is unspecified which of the queries
+// counts as the last one.
+// The coordinates of the nodes are rounded to integers.
+// With the NavMeshBackend there is no visibility graph, and it returns nil.
 func (p *Pathfinder) VisibilityGraph() map[image.Point][]image.Point {
-	return p.visibilityGraph
+	vis := p.VisibilityGraphF()
+	if vis == nil {
+		return nil
+	}
+	g := make(map[image.Point][]image.Point, len(vis))
+	for n, nbs := range vis {
+		g[v2p(n)] = convert(nbs, v2p)
+	}
+	return g
+}
+
+// VisibilityGraphF is like VisibilityGraph, but returns the nodes with
+// floating-point coordinates.
+func (p *Pathfinder) VisibilityGraphF() map[geom.Vec2][]geom.Vec2 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	vis := p.lastGraph.Load()
+	if vis == nil {
+		return nil
+	}
+	return vis.merged()
 }
 
 // Path finds the shortest path from start to dest within the bounds of the
 // polygons the Pathfinder was initialized with.
 // If dest is outside the polygon set it will be clamped to the nearest
 // polygon edge.
-// The function returns nil if no path exists because start is outside
-// the polygon set.
+// If start is outside the polygon set it is handled according to the start
+// policy of the Pathfinder, see WithStartPolicy. By default, the function
+// returns nil in this case, because no path exists.
+// Like the polygon coordinates, start and dest are converted to float32,
+// so coordinates beyond ±2^24 are rounded, see NewPathfinder.
 func (p *Pathfinder) Path(start, dest image.Point) []image.Point {
-	d := p2v(dest)
-	if !p.polygonSet.Contains(d) {
-		dest = ensureInside(p.polygonSet, v2p(p.polygonSet.ClosestPt(d)))
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var exit []image.Point
+	if !p.index.Contains(p2v(start)) {
+		switch p.startPolicy {
+		case SnapStart:
+			start = p.moveInside(start)
+		case ExitStart:
+			exit = []image.Point{start}
+			start = p.moveInside(start)
+		default:
+			return nil
+		}
+	}
+	if !p.index.Contains(p2v(dest)) {
+		dest = p.moveInside(dest)
+	}
+	path := p.path(p2v(start), p2v(dest))
+	if path == nil {
+		return nil
+	}
+	return append(exit, convert(path, v2p)...)
+}
+
+// PathF is like Path, but works with floating-point coordinates.
+// The coordinates are not rounded, so the waypoints of the path are
+// exactly the respective polygon vertices.
+// See Find for a variant that reports more details about the path.
+func (p *Pathfinder) PathF(start, dest geom.Vec2) []geom.Vec2 {
+	res, _ := p.Find(start, dest)
+	return res.Path
+}
+
+func (p *Pathfinder) path(start, dest geom.Vec2) []geom.Vec2 {
+	if p.mesh != nil {
+		return p.mesh.path(start, dest)
+	}
+	vis := p.queryGraph(start, dest)
+	p.lastGraph.Store(&vis)
+	return p.searchPath(vis, start, dest)
+}
+
+// queryGraph connects the start and destination points to the precomputed
+// visibility graph of the concave vertices and region vertices. The edges
+// are added in the same order as if the visibility graph were calculated
+// from scratch with start and dests appended to the vertices, except that
+// destinations are not linked with each other.
+func (p *Pathfinder) queryGraph(start geom.Vec2, dests ...geom.Vec2) overlay[geom.Vec2] {
+	points := append([]geom.Vec2{start}, dests...)
+	extra := make(graph[geom.Vec2])
+	sees := make([][]bool, len(points))
+	for j := range points {
+		sees[j] = make([]bool, len(p.vertices))
+	}
+	for i, v := range p.vertices {
+		for j, q := range points {
+			sees[j][i] = inLineOfSight(p.index, v, q)
+			if sees[j][i] {
+				extra.link(v, q)
+			}
+		}
+	}
+	direct := make([]bool, len(points))
+	for j, dest := range dests {
+		direct[j+1] = inLineOfSight(p.index, start, dest)
+	}
+	for j, q := range points {
+		for i, v := range p.vertices {
+			if sees[j][i] {
+				extra.link(q, v)
+			}
+		}
+		if j == 0 {
+			for k, dest := range dests {
+				if direct[k+1] {
+					extra.link(start, dest)
+				}
+			}
+		} else if direct[j] {
+			extra.link(q, start)
+		}
 	}
-	graphVertices := append(p.concaveVertices, start, dest)
-	p.visibilityGraph = visibilityGraph(p.polygonSet, graphVertices)
-	return astar.FindPath[image.Point](p.visibilityGraph, start, dest, nodeDist, nodeDist)
+	return overlay[geom.Vec2]{base: p.staticGraph, extra: extra}
+}
+
+// moveInside moves a point outside the polygon set to the closest point
+// inside with integer coordinates.
+func (p *Pathfinder) moveInside(pt image.Point) image.Point {
+	return ensureInside(p.index, v2p(p.index.ClosestPt(p2v(pt))))
 }
 
-func ensureInside(ps poly.PolygonSet, pt image.Point) image.Point {
-	if ps.Contains(p2v(pt)) {
+// moveInsideF moves a point outside the polygon set to the closest point
+// inside.
+func (p *Pathfinder) moveInsideF(pt geom.Vec2) geom.Vec2 {
+	return ensureInsideF(p.index, p.index.ClosestPt(pt))
+}
+
+func ensureInside(idx *poly.Index, pt image.Point) image.Point {
+	if idx.Contains(p2v(pt)) {
 		return pt
 	}
 adjustment:
@@ -82,7 +284,7 @@ adjustment:
 				continue
 			}
 			npt := pt.Add(image.Point{X: dx, Y: dy})
-			if ps.Contains(p2v(npt)) {
+			if idx.Contains(p2v(npt)) {
 				pt = npt
 				break adjustment
 			}
@@ -91,11 +293,51 @@ adjustment:
 	return pt
 }
 
-func concaveVertices(ps poly.PolygonSet) []image.Point {
-	var vs []image.Point
+// maxNudge limits the distance by which ensureInsideF moves a point,
+// measured in units in the last place of its coordinates.
+const maxNudge = 1 << 10
+
+// ensureInsideF is the floating-point counterpart of ensureInside.
+// Instead of moving the point by whole units it moves the point by a
+// growing number of units in the last place of its largest coordinate,
+// because the closest point on a polygon edge can be off by a rounding error.
+func ensureInsideF(idx *poly.Index, pt geom.Vec2) geom.Vec2 {
+	if idx.Contains(pt) {
+		return pt
+	}
+	u := ulp(max(abs(pt.X), abs(pt.Y), 1))
+	for n := float32(1); n <= maxNudge; n *= 2 {
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if dx == 0 && dy == 0 {
+					continue
+				}
+				npt := pt.Add(geom.V2(float32(dx), float32(dy)).Mul(n * u))
+				if idx.Contains(npt) {
+					return npt
+				}
+			}
+		}
+	}
+	return pt
+}
+
+// ulp returns the distance between a non-negative x and the next larger
+// float32 value.
+func ulp(x float32) float32 {
+	return math.Nextafter32(x, float32(math.Inf(1))) - x
+}
+
+// abs returns the absolute value of x.
+func abs(x float32) float32 {
+	return float32(math.Abs(float64(x)))
+}
+
+func concaveVertices(ps poly.PolygonSet, nest nesting) []geom.Vec2 {
+	var vs []geom.Vec2
 	for i, p := range ps {
 		t := concave
-		if isHole(ps, i) {
+		if nest.isHole(i) {
 			t = convex
 		}
 		vs = append(vs, verticesOfType(p, t)...)
@@ -103,16 +345,6 @@ func concaveVertices(ps poly.PolygonSet) []image.Point {
 	return vs
 }
 
-func isHole(ps poly.PolygonSet, i int) bool {
-	hole := false
-	for j, p := range ps {
-		if i != j && p.Contains(ps[i][0], false) {
-			hole = !hole
-		}
-	}
-	return hole
-}
-
 type vertexType int
 
 const (
@@ -120,25 +352,28 @@ const (
 	convex
 )
 
-func verticesOfType(p poly.Polygon, t vertexType) []image.Point {
-	var vs []image.Point
+// verticesOfType returns the vertices of polygon p of the given type.
+// Concave vertices are the reflex vertices of p, whatever its winding order.
+func verticesOfType(p poly.Polygon, t vertexType) []geom.Vec2 {
+	var vs []geom.Vec2
+	positive := p.SignedArea() >= 0
 	for i, v := range p {
-		isConcave := p.IsConcaveAt(i)
+		isConcave := p.IsReflexAt(i, positive)
 		if (t == concave && isConcave) || (t == convex && !isConcave) {
-			vs = append(vs, v2p(v))
+			vs = append(vs, v)
 		}
 	}
 	return vs
 }
 
-func visibilityGraph(ps poly.PolygonSet, points []image.Point) graph[image.Point] {
-	vis := make(graph[image.Point])
+func visibilityGraph(idx *poly.Index, points []geom.Vec2) graph[geom.Vec2] {
+	vis := make(graph[geom.Vec2])
 	for i, a := range points {
 		for j, b := range points {
 			if i == j {
 				continue
 			}
-			if inLineOfSight(ps, p2v(a), p2v(b)) {
+			if inLineOfSight(idx, a, b) {
 				vis.link(a, b)
 			}
 		}
@@ -146,19 +381,18 @@ func visibilityGraph(ps poly.PolygonSet, points []image.Point) graph[image.Point
 	return vis
 }
 
-func inLineOfSight(ps poly.PolygonSet, start, end geom.Vec2) bool {
+func inLineOfSight(idx *poly.Index, start, end geom.Vec2) bool {
 	lineOfSight := poly.LineSeg{A: start, B: end}
-	for _, p := range ps {
-		if p.IsCrossedBy(lineOfSight) {
-			return false
-		}
+	if idx.IsCrossedBy(lineOfSight) || idx.PassesContact(lineOfSight) {
+		return false
 	}
-	return ps.Contains(lineOfSight.Middle())
+	return idx.ContainsMiddle(lineOfSight)
 }
 
 // nodeDist is the cost function for the A* algorithm. The visibility graph has
 // 2d points as nodes, so we calculate the Euclidean distance.
-func nodeDist(a, b image.Point) float64 {
-	c := a.Sub(b)
-	return math.Sqrt(float64(c.X*c.X + c.Y*c.Y))
+func nodeDist(a, b geom.Vec2) float64 {
+	dx := float64(a.X) - float64(b.X)
+	dy := float64(a.Y) - float64(b.Y)
+	return math.Sqrt(dx*dx + dy*dy)
 }
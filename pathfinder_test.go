@@ -6,9 +6,12 @@ package pathfind_test
 
 import (
 	"image"
+	"math"
 	"reflect"
+	"sync"
 	"testing"
 
+	"github.com/fzipp/geom"
 	"github.com/fzipp/pathfind"
 )
 
@@ -237,6 +240,25 @@ want: %v`,
 	}
 }
 
+func TestPathfinderPathStartPolicy(t *testing.T) {
+	tests := []struct {
+		policy pathfind.StartPolicy
+		want   []image.Point
+	}{
+		{pathfind.RejectStart, nil},
+		{pathfind.SnapStart, []image.Point{image.Pt(10, 5), image.Pt(5, 15)}},
+		{pathfind.ExitStart, []image.Point{image.Pt(15, 5), image.Pt(10, 5), image.Pt(5, 15)}},
+	}
+	start, dest := image.Pt(15, 5), image.Pt(5, 15)
+	for _, tt := range tests {
+		pathfinder := pathfind.NewPathfinder(polygonU, pathfind.WithStartPolicy(tt.policy))
+		got := pathfinder.Path(start, dest)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("start policy %v: Path(%v, %v)\n got: %v\nwant: %v", tt.policy, start, dest, got, tt.want)
+		}
+	}
+}
+
 func TestPathfinderVisibilityGraph(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -280,3 +302,177 @@ want: %v`,
 		})
 	}
 }
+
+func TestPathfinderVisibilityGraphIndependentQueries(t *testing.T) {
+	pathfinder := pathfind.NewPathfinder(polygonU)
+	pathfinder.Path(image.Pt(5, 5), image.Pt(25, 5))
+	pathfinder.Path(image.Pt(5, 15), image.Pt(25, 15))
+	got := pathfinder.VisibilityGraph()
+	for _, pt := range []image.Point{image.Pt(5, 5), image.Pt(25, 5)} {
+		if _, ok := got[pt]; ok {
+			t.Errorf("visibility graph of second query contains start or destination of first query %v: %v", pt, got)
+		}
+	}
+	want := map[image.Point][]image.Point{
+		image.Pt(5, 15):  {image.Pt(10, 10), image.Pt(20, 10), image.Pt(25, 15)},
+		image.Pt(10, 10): {image.Pt(20, 10), image.Pt(5, 15), image.Pt(25, 15)},
+		image.Pt(20, 10): {image.Pt(10, 10), image.Pt(5, 15), image.Pt(25, 15)},
+		image.Pt(25, 15): {image.Pt(10, 10), image.Pt(20, 10), image.Pt(5, 15)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VisibilityGraph()\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestPathfinderPathConcurrent(t *testing.T) {
+	pathfinder := pathfind.NewPathfinder(polygonO)
+	want := []image.Point{
+		image.Pt(15, 10),
+		image.Pt(20, 10),
+		image.Pt(30, 20),
+		image.Pt(30, 30),
+	}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				got := pathfinder.Path(image.Pt(15, 10), image.Pt(30, 30))
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Path(%v, %v)\n got: %v\nwant: %v", image.Pt(15, 10), image.Pt(30, 30), got, want)
+					return
+				}
+				pathfinder.VisibilityGraph()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// The U-shaped polygon from polygonU with fractional coordinates.
+//
+//	 0.4375,0.25 >---+   +---+ 15.4375,0.25
+//	             |   |   |   |
+//	             |   +---+   |
+//	             |           |
+//	0.4375,10.25 +-----------+ 15.4375,10.25
+var polygonUF = [][]geom.Vec2{
+	{
+		geom.V2(0.4375, 0.25),
+		geom.V2(5.4375, 0.25),
+		geom.V2(5.4375, 5.25),
+		geom.V2(10.4375, 5.25),
+		geom.V2(10.4375, 0.25),
+		geom.V2(15.4375, 0.25),
+		geom.V2(15.4375, 10.25),
+		geom.V2(0.4375, 10.25),
+	},
+}
+
+func TestPathfinderPathF(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygons [][]geom.Vec2
+		start    geom.Vec2
+		dest     geom.Vec2
+		want     []geom.Vec2
+	}{
+		{
+			name:     "Direct connection",
+			polygons: polygonUF,
+			start:    geom.V2(2.5, 2.5),
+			dest:     geom.V2(2.5, 7.5),
+			want: []geom.Vec2{
+				geom.V2(2.5, 2.5),
+				geom.V2(2.5, 7.5),
+			},
+		},
+		{
+			name:     "Two corners",
+			polygons: polygonUF,
+			start:    geom.V2(2.5, 2.5),
+			dest:     geom.V2(12.5, 2.5),
+			want: []geom.Vec2{
+				geom.V2(2.5, 2.5),
+				geom.V2(5.4375, 5.25),
+				geom.V2(10.4375, 5.25),
+				geom.V2(12.5, 2.5),
+			},
+		},
+		{
+			name:     "No path through wall: dest clamped to polygons",
+			polygons: polygonUF,
+			start:    geom.V2(2.5, 2.5),
+			dest:     geom.V2(7.5, 2.5),
+			want: []geom.Vec2{
+				geom.V2(2.5, 2.5),
+				geom.V2(5.4375, 2.5),
+			},
+		},
+		{
+			name:     "No path outside polygon",
+			polygons: polygonUF,
+			start:    geom.V2(7.5, 0),
+			dest:     geom.V2(7.5, 2.5),
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathfinder := pathfind.NewPathfinderF(tt.polygons)
+			got := pathfinder.PathF(tt.start, tt.dest)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(`%s
+polygons: %v
+PathF(%v, %v)
+ got: %v
+want: %v`,
+					tt.name, tt.polygons, tt.start, tt.dest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathfinderWithRadius(t *testing.T) {
+	const r = 2
+	for _, join := range []pathfind.Join{pathfind.RoundJoin, pathfind.MiterJoin} {
+		pathfinder := pathfind.NewPathfinder(polygonO, pathfind.WithRadius(r, join))
+		start := geom.V2(15, 5)
+		dest := geom.V2(30, 35)
+		path := pathfinder.PathF(start, dest)
+		if len(path) < 3 || path[0] != start || path[len(path)-1] != dest {
+			t.Fatalf("join %v: PathF(%v, %v) = %v, want path around the hole from start to dest", join, start, dest, path)
+		}
+		for i := range len(path) - 1 {
+			for s := float32(0); s <= 1; s += 0.05 {
+				pt := path[i].Lerp(path[i+1], s)
+				if d := distToPolygons(polygonO, pt); d < r-1e-3 {
+					t.Errorf("join %v: path point %v has distance %v to a polygon edge, want at least %v\npath: %v", join, pt, d, r, path)
+				}
+			}
+		}
+	}
+}
+
+// distToPolygons returns the distance between point pt and the closest edge
+// of the polygons.
+func distToPolygons(polygons [][]image.Point, pt geom.Vec2) float32 {
+	dist := float32(math.Inf(1))
+	for _, p := range polygons {
+		for i := range p {
+			a := geom.V2(float32(p[i].X), float32(p[i].Y))
+			j := (i + 1) % len(p)
+			b := geom.V2(float32(p[j].X), float32(p[j].Y))
+			dist = min(dist, distToSegment(a, b, pt))
+		}
+	}
+	return dist
+}
+
+func distToSegment(a, b, pt geom.Vec2) float32 {
+	ab := b.Sub(a)
+	t := pt.Sub(a).Dot(ab) / ab.SqLen()
+	t = max(0, min(1, t))
+	return a.Lerp(b, t).Dist(pt)
+}
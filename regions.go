@@ -0,0 +1,207 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"slices"
+
+	"github.com/fzipp/astar"
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// A Region is a part of the accessible area where moving is more or less
+// expensive than elsewhere, like mud, stairs or water.
+type Region struct {
+	// Polygon is the outline of the region.
+	Polygon []geom.Vec2
+	// Cost is the factor by which the length of a path segment inside the
+	// region is multiplied. It must be positive. Values greater than 1
+	// make the region more expensive, values less than 1 cheaper.
+	Cost float64
+}
+
+// WithRegions configures the Pathfinder with regions of different
+// traversal costs. Outside of any region the cost factor is 1.
+// If regions overlap, the region given last takes precedence.
+//
+// With regions, path queries minimize the cost of a path instead of its
+// length, i.e. the sum of the lengths of its parts inside and outside the
+// regions, each multiplied by the respective cost factor. The vertices of
+// the regions become additional waypoint candidates. Paths only bend at
+// polygon vertices and region vertices, so a path that crosses a region
+// boundary between two region vertices is the cheapest among such paths,
+// but not necessarily the cheapest path overall.
+func WithRegions(regions ...Region) Option {
+	return func(c *config) {
+		c.regions = append(c.regions, regions...)
+	}
+}
+
+// region is the internal representation of a Region.
+type region struct {
+	polygon poly.Polygon
+	cost    float64
+}
+
+func newRegions(regions []Region) []region {
+	return convert(regions, func(r Region) region {
+		return region{polygon: slices.Clone(r.Polygon), cost: r.Cost}
+	})
+}
+
+// minCost returns the smallest cost factor of the regions, but at most 1,
+// which is the cost factor outside of the regions.
+func minCost(regions []region) float64 {
+	c := 1.0
+	for _, r := range regions {
+		c = min(c, r.cost)
+	}
+	return c
+}
+
+// regionVertices returns the vertices of the regions that are inside the
+// polygon set indexed by idx.
+func regionVertices(regions []region, idx *poly.Index) []geom.Vec2 {
+	var vs []geom.Vec2
+	for _, r := range regions {
+		for _, v := range r.polygon {
+			if idx.Contains(v) {
+				vs = append(vs, v)
+			}
+		}
+	}
+	return vs
+}
+
+// edgeCosts returns the costs of all edges of graph g as calculated by
+// weightedDist.
+func edgeCosts(regions []region, g graph[geom.Vec2]) map[[2]geom.Vec2]float64 {
+	costs := make(map[[2]geom.Vec2]float64)
+	for a, nbs := range g {
+		for _, b := range nbs {
+			costs[[2]geom.Vec2{a, b}] = weightedDist(regions, a, b)
+		}
+	}
+	return costs
+}
+
+// costFunc returns the cost function for the graph search of a path query.
+// It is the default cost, see weightedDistFunc, unless the Pathfinder was
+// configured with an edge cost.
+func (p *Pathfinder) costFunc() astar.CostFunc[geom.Vec2] {
+	d := p.weightedDistFunc()
+	if p.search.edgeCost == nil {
+		return d
+	}
+	return func(a, b geom.Vec2) float64 {
+		return p.search.edgeCost(a, b, d(a, b))
+	}
+}
+
+// weightedDistFunc returns the default cost function. Without regions it
+// is the Euclidean distance. With regions, the costs of the edges that are
+// not part of the precomputed visibility graph are calculated on demand
+// and cached for the duration of the query.
+func (p *Pathfinder) weightedDistFunc() astar.CostFunc[geom.Vec2] {
+	if len(p.regions) == 0 {
+		return nodeDist
+	}
+	queryCosts := make(map[[2]geom.Vec2]float64)
+	return func(a, b geom.Vec2) float64 {
+		e := [2]geom.Vec2{a, b}
+		if c, ok := p.edgeCosts[e]; ok {
+			return c
+		}
+		if c, ok := queryCosts[e]; ok {
+			return c
+		}
+		c := weightedDist(p.regions, a, b)
+		queryCosts[e] = c
+		return c
+	}
+}
+
+// heuristic is the cost heuristic function for the graph search of a path
+// query. By default it never overestimates the cost, because no part of a
+// path can be cheaper than its length multiplied by the smallest cost
+// factor. It is adapted to the configured search algorithm.
+func (p *Pathfinder) heuristic(a, b geom.Vec2) float64 {
+	switch p.search.algorithm {
+	case DijkstraSearch:
+		return 0
+	case WeightedAStarSearch:
+		return p.estimate(a, b) * (1 + p.search.epsilon)
+	default:
+		return p.estimate(a, b)
+	}
+}
+
+// estimate returns the configured heuristic or the default one.
+func (p *Pathfinder) estimate(a, b geom.Vec2) float64 {
+	if p.search.heuristic != nil {
+		return p.search.heuristic(a, b)
+	}
+	return nodeDist(a, b) * p.minCost
+}
+
+// weightedDist returns the cost of the straight line segment from a to b.
+// The segment is split at the region boundaries, and the length of each
+// part is multiplied by the cost factor of the region it lies in.
+func weightedDist(regions []region, a, b geom.Vec2) float64 {
+	seg := poly.LineSeg{A: a, B: b}
+	ts := []float64{0, 1}
+	for _, r := range regions {
+		for i := range r.polygon {
+			if t, ok := intersectionParam(seg, r.polygon.Edge(i)); ok {
+				ts = append(ts, t)
+			}
+		}
+	}
+	slices.Sort(ts)
+	length := nodeDist(a, b)
+	var cost float64
+	for i := 1; i < len(ts); i++ {
+		dt := ts[i] - ts[i-1]
+		if dt <= 0 {
+			continue
+		}
+		mid := a.Lerp(b, float32((ts[i-1]+ts[i])/2))
+		cost += dt * length * costAt(regions, mid)
+	}
+	return cost
+}
+
+// costAt returns the cost factor at point pt. Points on a region boundary
+// do not count as inside of the region.
+func costAt(regions []region, pt geom.Vec2) float64 {
+	for i := len(regions) - 1; i >= 0; i-- {
+		if regions[i].polygon.Contains(pt, false) {
+			return regions[i].cost
+		}
+	}
+	return 1
+}
+
+// intersectionParam returns the parameter t in [0, 1] of the point where
+// line segment l intersects line segment m, such that the point is
+// l.A + t(l.B - l.A). It returns false if the line segments don't intersect
+// in a single point.
+func intersectionParam(l, m poly.LineSeg) (t float64, ok bool) {
+	px, py := float64(l.A.X), float64(l.A.Y)
+	rx, ry := float64(l.B.X)-px, float64(l.B.Y)-py
+	qx, qy := float64(m.A.X), float64(m.A.Y)
+	sx, sy := float64(m.B.X)-qx, float64(m.B.Y)-qy
+	denom := rx*sy - ry*sx
+	if denom == 0 {
+		return 0, false
+	}
+	t = ((qx-px)*sy - (qy-py)*sx) / denom
+	u := ((qx-px)*ry - (qy-py)*rx) / denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
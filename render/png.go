@@ -0,0 +1,172 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/vgraph"
+)
+
+// WritePNG writes the scene as PNG image to w.
+func WritePNG(w io.Writer, s *Scene) error {
+	return png.Encode(w, Image(s))
+}
+
+// Image draws the scene into a new RGBA image. The bounds of the image
+// are the bounds of the scene.
+func Image(s *Scene) *image.RGBA {
+	img := image.NewRGBA(s.bounds())
+	Draw(img, s)
+	return img
+}
+
+// Draw draws the scene onto dst, using the coordinates of the scene as
+// pixel coordinates of dst. Only the Bounds of dst are drawn. Lines and
+// circles are anti-aliased.
+func Draw(dst draw.Image, s *Scene) {
+	b := dst.Bounds()
+	draw.Draw(dst, b, image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	layer := func(c color.Color, paint func(m *mask)) {
+		m := newMask(b)
+		paint(m)
+		draw.DrawMask(dst, b, image.NewUniform(c), image.Point{}, m.Alpha, b.Min, draw.Over)
+	}
+
+	layer(polygonColor, func(m *mask) {
+		for _, p := range s.Polygons {
+			for i, v := range p {
+				m.strokeLine(v, p[(i+1)%len(p)], lineWidth)
+			}
+		}
+	})
+	if s.Graph != nil {
+		layer(visibilityGraphColor, func(m *mask) {
+			for _, e := range vgraph.Edges(s.Graph) {
+				m.strokeLine(e[0], e[1], graphLineWidth)
+			}
+		})
+	}
+	layer(concaveVertexColor, func(m *mask) {
+		for _, v := range s.ConcaveVertices {
+			m.strokeCircle(v, pointRadius, lineWidth)
+		}
+	})
+	layer(backgroundColor, func(m *mask) {
+		for _, v := range s.ConcaveVertices {
+			m.fillCircle(v, pointRadius)
+		}
+	})
+	layer(pathColor, func(m *mask) {
+		for i := 1; i < len(s.Path); i++ {
+			m.strokeLine(s.Path[i-1], s.Path[i], lineWidth)
+		}
+	})
+	layer(startPointColor, func(m *mask) {
+		m.fillCircle(s.Start, pointRadius+lineWidth/2.0)
+	})
+	if len(s.Path) > 0 {
+		layer(destPointColor, func(m *mask) {
+			m.fillCircle(s.Path[len(s.Path)-1], pointRadius+lineWidth/2.0)
+		})
+	}
+}
+
+// A mask accumulates the coverage of the pixels by the shapes of a layer.
+// Coverage is approximated by a box filter of one pixel width across the
+// outline of a shape.
+type mask struct {
+	*image.Alpha
+}
+
+func newMask(r image.Rectangle) *mask {
+	return &mask{image.NewAlpha(r)}
+}
+
+// cover raises the coverage of pixel (x, y) to c, a value in [0, 1].
+func (m *mask) cover(x, y int, c float64) {
+	if !(image.Point{X: x, Y: y}.In(m.Rect)) {
+		return
+	}
+	a := uint8(math.Round(min(c, 1) * 0xFF))
+	i := m.PixOffset(x, y)
+	m.Pix[i] = max(m.Pix[i], a)
+}
+
+// strokeLine covers a line of width w from a to b.
+func (m *mask) strokeLine(a, b geom.Vec2, w float64) {
+	ax, ay, bx, by := float64(a.X), float64(a.Y), float64(b.X), float64(b.Y)
+	hw := w / 2
+	e := hw + 1
+	y0 := max(int(math.Floor(min(ay, by)-e)), m.Rect.Min.Y)
+	y1 := min(int(math.Ceil(max(ay, by)+e)), m.Rect.Max.Y)
+	for y := y0; y < y1; y++ {
+		cy := float64(y) + 0.5
+		// The x range of the line in the rows near y.
+		x0, x1 := min(ax, bx), max(ax, bx)
+		if ay != by {
+			t0 := clamp((cy-e-ay)/(by-ay), 0, 1)
+			t1 := clamp((cy+e-ay)/(by-ay), 0, 1)
+			x0, x1 = ax+t0*(bx-ax), ax+t1*(bx-ax)
+			x0, x1 = min(x0, x1), max(x0, x1)
+		}
+		for x := int(math.Floor(x0 - e)); x < int(math.Ceil(x1+e)); x++ {
+			d := distToSegment(float64(x)+0.5, cy, ax, ay, bx, by)
+			m.cover(x, y, overlap(d-0.5, d+0.5, -hw, hw))
+		}
+	}
+}
+
+// strokeCircle covers a circle outline of width w.
+func (m *mask) strokeCircle(c geom.Vec2, r, w float64) {
+	m.circle(c, r+w/2, func(d float64) float64 {
+		return overlap(d-0.5, d+0.5, r-w/2, r+w/2)
+	})
+}
+
+// fillCircle covers a disk.
+func (m *mask) fillCircle(c geom.Vec2, r float64) {
+	m.circle(c, r, func(d float64) float64 {
+		return clamp(r+0.5-d, 0, 1)
+	})
+}
+
+// circle covers the pixels up to distance r from c with the coverage
+// returned by f for their distance from c.
+func (m *mask) circle(c geom.Vec2, r float64, f func(d float64) float64) {
+	cx, cy := float64(c.X), float64(c.Y)
+	r++
+	for y := int(math.Floor(cy - r)); y < int(math.Ceil(cy+r)); y++ {
+		for x := int(math.Floor(cx - r)); x < int(math.Ceil(cx+r)); x++ {
+			m.cover(x, y, f(math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)))
+		}
+	}
+}
+
+// overlap returns the length of the intersection of the intervals
+// [a0, a1] and [b0, b1].
+func overlap(a0, a1, b0, b1 float64) float64 {
+	return max(0, min(a1, b1)-max(a0, b0))
+}
+
+func distToSegment(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		t = clamp(((px-ax)*dx+(py-ay)*dy)/l2, 0, 1)
+	}
+	return math.Hypot(px-ax-t*dx, py-ay-t*dy)
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return min(max(x, lo), hi)
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/fzipp/pathfind/render"
+)
+
+func TestImage(t *testing.T) {
+	img := render.Image(triangleScene)
+	if want := image.Rect(-10, -10, 50, 40); img.Bounds() != want {
+		t.Fatalf("Bounds() = %v, want: %v", img.Bounds(), want)
+	}
+	var (
+		background = color.RGBA{R: 0x26, G: 0x46, B: 0x53, A: 0xFF}
+		polygon    = color.RGBA{R: 0xf4, G: 0xa2, B: 0x61, A: 0xFF}
+		vertex     = color.RGBA{R: 0xe9, G: 0xc4, B: 0x6a, A: 0xFF}
+		path       = color.RGBA{R: 0x2a, G: 0x9d, B: 0x8f, A: 0xFF}
+		start      = color.RGBA{R: 0x90, G: 0xee, B: 0x90, A: 0xFF}
+		dest       = color.RGBA{R: 0xe7, G: 0x6f, B: 0x51, A: 0xFF}
+	)
+	tests := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{-5, -5, background},
+		{30, 20, background},
+		{30, 0, polygon},
+		{0, 20, polygon},
+		{20, 4, vertex},
+		{22, 8, background}, // the inside of a vertex
+		{17, 11, path},
+		{5, 5, start},
+		{10, 15, dest},
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("RGBAAt(%d, %d) = %v, want: %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := render.WritePNG(&buf, triangleScene); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decoding PNG: %v", err)
+	}
+	if want := image.Rect(0, 0, 60, 50); img.Bounds() != want {
+		t.Errorf("Bounds() = %v, want: %v", img.Bounds(), want)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package render draws the polygon set of a pathfind.Pathfinder together
+// with its visibility graph and a path as SVG or PNG image, without the
+// browser needed by the interactive demo. This is useful for bug reports
+// and as artifacts of automated tests.
+//
+// The colors and the order of the layers are the same as in the demo:
+// the polygons are drawn first, then the visibility graph and its
+// concave vertices, then the path, and finally the start and
+// destination points.
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+var (
+	backgroundColor      = color.RGBA{R: 0x26, G: 0x46, B: 0x53, A: 0xFF}
+	polygonColor         = color.RGBA{R: 0xf4, G: 0xa2, B: 0x61, A: 0xFF}
+	concaveVertexColor   = color.RGBA{R: 0xe9, G: 0xc4, B: 0x6a, A: 0xFF}
+	visibilityGraphColor = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	pathColor            = color.RGBA{R: 0x2a, G: 0x9d, B: 0x8f, A: 0xFF}
+	startPointColor      = color.RGBA{R: 0x90, G: 0xee, B: 0x90, A: 0xff}
+	destPointColor       = color.RGBA{R: 0xe7, G: 0x6f, B: 0x51, A: 0xFF}
+)
+
+const (
+	lineWidth      = 3
+	graphLineWidth = 0.3
+	pointRadius    = 5
+	margin         = 10
+)
+
+// A Scene describes what is drawn.
+type Scene struct {
+	// Bounds is the part of the coordinate space that is drawn. One unit
+	// corresponds to one pixel. If Bounds is empty, it is the bounding box
+	// of all elements of the scene plus a margin.
+	Bounds image.Rectangle
+
+	Polygons        [][]geom.Vec2
+	ConcaveVertices []geom.Vec2
+	Graph           map[geom.Vec2][]geom.Vec2 // visibility graph
+	Path            []geom.Vec2
+
+	// Start is the start point of the path query. It is drawn even if
+	// no path was found. The destination point is the last point of
+	// the path.
+	Start geom.Vec2
+}
+
+// NewScene creates a scene with the polygons, concave vertices and the
+// visibility graph of the last path query of pathfinder p, and a path
+// from start. Pass a nil path to draw the start point only.
+func NewScene(p *pathfind.Pathfinder, start geom.Vec2, path []geom.Vec2) *Scene {
+	return &Scene{
+		Polygons:        p.Polygons(),
+		ConcaveVertices: p.ConcaveVertices(),
+		Graph:           p.VisibilityGraphF(),
+		Path:            path,
+		Start:           start,
+	}
+}
+
+// bounds returns the drawn part of the coordinate space.
+func (s *Scene) bounds() image.Rectangle {
+	if !s.Bounds.Empty() {
+		return s.Bounds
+	}
+	minX, minY := float64(s.Start.X), float64(s.Start.Y)
+	maxX, maxY := minX, minY
+	include := func(vs []geom.Vec2) {
+		for _, v := range vs {
+			minX, minY = min(minX, float64(v.X)), min(minY, float64(v.Y))
+			maxX, maxY = max(maxX, float64(v.X)), max(maxY, float64(v.Y))
+		}
+	}
+	for _, p := range s.Polygons {
+		include(p)
+	}
+	include(s.ConcaveVertices)
+	for n, nbs := range s.Graph {
+		include([]geom.Vec2{n})
+		include(nbs)
+	}
+	include(s.Path)
+	return image.Rect(
+		int(math.Floor(minX))-margin, int(math.Floor(minY))-margin,
+		int(math.Ceil(maxX))+margin, int(math.Ceil(maxY))+margin,
+	)
+}
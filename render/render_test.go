@@ -0,0 +1,36 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/render"
+)
+
+func TestNewScene(t *testing.T) {
+	polygons := [][]geom.Vec2{{
+		geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10),
+		geom.V2(20, 10), geom.V2(20, 0), geom.V2(30, 0),
+		geom.V2(30, 20), geom.V2(0, 20),
+	}}
+	pathfinder := pathfind.NewPathfinderF(polygons)
+	start := geom.V2(5, 5)
+	path := pathfinder.PathF(start, geom.V2(25, 5))
+	got := render.NewScene(pathfinder, start, path)
+	want := &render.Scene{
+		Polygons:        polygons,
+		ConcaveVertices: []geom.Vec2{geom.V2(10, 10), geom.V2(20, 10)},
+		Graph:           pathfinder.VisibilityGraphF(),
+		Path:            []geom.Vec2{geom.V2(5, 5), geom.V2(10, 10), geom.V2(20, 10), geom.V2(25, 5)},
+		Start:           start,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewScene\n got: %+v\nwant: %+v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/vgraph"
+)
+
+// WriteSVG writes the scene as SVG image to w.
+func WriteSVG(w io.Writer, s *Scene) error {
+	b := s.bounds()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="%d %d %d %d">`+"\n",
+		b.Dx(), b.Dy(), b.Min.X, b.Min.Y, b.Dx(), b.Dy())
+	fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+		b.Min.X, b.Min.Y, b.Dx(), b.Dy(), hex(backgroundColor))
+
+	fmt.Fprintf(bw, `<g fill="none" stroke="%s" stroke-width="%v">`+"\n", hex(polygonColor), lineWidth)
+	for _, p := range s.Polygons {
+		fmt.Fprintf(bw, `<polygon points="%s"/>`+"\n", points(p))
+	}
+	fmt.Fprintln(bw, `</g>`)
+
+	if s.Graph != nil {
+		fmt.Fprintf(bw, `<g stroke="%s" stroke-width="%v">`+"\n", hex(visibilityGraphColor), graphLineWidth)
+		for _, e := range vgraph.Edges(s.Graph) {
+			fmt.Fprintf(bw, `<line x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n",
+				formatFloat(e[0].X), formatFloat(e[0].Y), formatFloat(e[1].X), formatFloat(e[1].Y))
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+
+	// The vertices are filled with the background color and the fill is
+	// painted over the inner half of the stroke, like in the demo.
+	fmt.Fprintf(bw, `<g fill="%s" stroke="%s" stroke-width="%v" paint-order="stroke">`+"\n",
+		hex(backgroundColor), hex(concaveVertexColor), lineWidth)
+	for _, v := range s.ConcaveVertices {
+		writeCircle(bw, v)
+	}
+	fmt.Fprintln(bw, `</g>`)
+
+	if len(s.Path) > 0 {
+		fmt.Fprintf(bw, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%v"/>`+"\n",
+			points(s.Path), hex(pathColor), lineWidth)
+	}
+
+	writePoint(bw, s.Start, startPointColor)
+	if len(s.Path) > 0 {
+		writePoint(bw, s.Path[len(s.Path)-1], destPointColor)
+	}
+	fmt.Fprintln(bw, `</svg>`)
+	return bw.Flush()
+}
+
+func writePoint(w io.Writer, pt geom.Vec2, c color.RGBA) {
+	fmt.Fprintf(w, `<circle cx="%s" cy="%s" r="%v" fill="%s" stroke="%s" stroke-width="%v"/>`+"\n",
+		formatFloat(pt.X), formatFloat(pt.Y), pointRadius, hex(c), hex(c), lineWidth)
+}
+
+func writeCircle(w io.Writer, pt geom.Vec2) {
+	fmt.Fprintf(w, `<circle cx="%s" cy="%s" r="%v"/>`+"\n", formatFloat(pt.X), formatFloat(pt.Y), pointRadius)
+}
+
+func points(vs []geom.Vec2) string {
+	var b []byte
+	for i, v := range vs {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendFloat(b, float64(v.X), 'f', -1, 32)
+		b = append(b, ',')
+		b = strconv.AppendFloat(b, float64(v.Y), 'f', -1, 32)
+	}
+	return string(b)
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render_test
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/render"
+)
+
+// A triangle with a path across it and a visibility graph that links
+// two nodes in both directions.
+var triangleScene = &render.Scene{
+	Polygons:        [][]geom.Vec2{{geom.V2(0, 0), geom.V2(40, 0), geom.V2(0, 30)}},
+	ConcaveVertices: []geom.Vec2{geom.V2(20, 10)},
+	Graph: map[geom.Vec2][]geom.Vec2{
+		geom.V2(5, 5):   {geom.V2(20, 10)},
+		geom.V2(20, 10): {geom.V2(5, 5)},
+	},
+	Path:  []geom.Vec2{geom.V2(5, 5), geom.V2(20, 10), geom.V2(10, 15.5)},
+	Start: geom.V2(5, 5),
+}
+
+func TestWriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := render.WriteSVG(&buf, triangleScene); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="60" height="50" viewBox="-10 -10 60 50">
+<rect x="-10" y="-10" width="60" height="50" fill="#264653"/>
+<g fill="none" stroke="#f4a261" stroke-width="3">
+<polygon points="0,0 40,0 0,30"/>
+</g>
+<g stroke="#ffffff" stroke-width="0.3">
+<line x1="5" y1="5" x2="20" y2="10"/>
+</g>
+<g fill="#264653" stroke="#e9c46a" stroke-width="3" paint-order="stroke">
+<circle cx="20" cy="10" r="5"/>
+</g>
+<polyline points="5,5 20,10 10,15.5" fill="none" stroke="#2a9d8f" stroke-width="3"/>
+<circle cx="5" cy="5" r="5" fill="#90ee90" stroke="#90ee90" stroke-width="3"/>
+<circle cx="10" cy="15.5" r="5" fill="#e76f51" stroke="#e76f51" stroke-width="3"/>
+</svg>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteSVG\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestWriteSVGBounds(t *testing.T) {
+	scene := *triangleScene
+	scene.Bounds = image.Rect(0, 0, 800, 600)
+	var buf bytes.Buffer
+	if err := render.WriteSVG(&buf, &scene); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="800" height="600" viewBox="0 0 800 600">`
+	if got, _, _ := bytes.Cut(buf.Bytes(), []byte("\n")); string(got) != want {
+		t.Errorf("WriteSVG\n got: %s\nwant: %s", got, want)
+	}
+}
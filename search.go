@@ -0,0 +1,244 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"container/heap"
+	"math"
+	"slices"
+
+	"github.com/fzipp/astar"
+)
+
+// findNearest finds the shortest path in graph g from node start to the
+// closest of several goal nodes using the A* algorithm with the cost
+// function d and the cost heuristic function h, which estimates the cost
+// from a node to the closest goal. It returns the path and the index of the
+// reached goal in goals, or nil and -1 if none of the goals is reachable.
+//
+// If turn is not nil, it adds the cost of turning at node b when arriving
+// from node a and continuing to node c. The search then runs over the
+// edges of the graph instead of its nodes, because the cost of continuing
+// from a node depends on the edge by which it was reached.
+func findNearest[Node comparable](g astar.Graph[Node], start Node, goals []Node, d astar.CostFunc[Node], turn func(a, b, c Node) float64, h func(Node) float64) (path []Node, goal int) {
+	goalIndex := make(map[Node]int, len(goals))
+	for i, n := range goals {
+		if _, ok := goalIndex[n]; !ok {
+			goalIndex[n] = i
+		}
+	}
+	// A state is a node reached by an edge from node prev. Without turn
+	// costs and for the start node, prev is the node itself.
+	type state struct {
+		prev, node Node
+	}
+	next := func(s state, n Node) state {
+		if turn == nil {
+			return state{n, n}
+		}
+		return state{s.node, n}
+	}
+	first := state{start, start}
+	cost := map[state]float64{first: 0}
+	prev := make(map[state]state)
+	closed := make(map[state]bool)
+	pq := &nodeQueue[state]{{node: first, priority: h(start)}}
+	for pq.Len() > 0 {
+		s := heap.Pop(pq).(queuedNode[state]).node
+		if closed[s] {
+			continue
+		}
+		if i, ok := goalIndex[s.node]; ok {
+			states := tracePath(prev, first, s)
+			path := make([]Node, len(states))
+			for k, t := range states {
+				path[k] = t.node
+			}
+			return path, i
+		}
+		closed[s] = true
+		for _, nb := range g.Neighbours(s.node) {
+			t := next(s, nb)
+			if closed[t] {
+				continue
+			}
+			c := cost[s] + d(s.node, nb)
+			if turn != nil && s != first {
+				c += turn(s.prev, s.node, nb)
+			}
+			if old, ok := cost[t]; ok && old <= c {
+				continue
+			}
+			cost[t] = c
+			prev[t] = s
+			heap.Push(pq, queuedNode[state]{node: t, priority: c + h(nb)})
+		}
+	}
+	return nil, -1
+}
+
+// shortestPaths finds the shortest paths in graph g from node start to each
+// of the goal nodes in a single search with Dijkstra's algorithm using the
+// cost function d. The path to an unreachable goal is nil.
+func shortestPaths[Node comparable](g astar.Graph[Node], start Node, goals []Node, d astar.CostFunc[Node]) [][]Node {
+	left := make(map[Node]bool, len(goals))
+	for _, n := range goals {
+		left[n] = true
+	}
+	cost := map[Node]float64{start: 0}
+	prev := make(map[Node]Node)
+	closed := make(map[Node]bool)
+	pq := &nodeQueue[Node]{{node: start, priority: 0}}
+	for pq.Len() > 0 && len(left) > 0 {
+		n := heap.Pop(pq).(queuedNode[Node]).node
+		if closed[n] {
+			continue
+		}
+		closed[n] = true
+		delete(left, n)
+		for _, nb := range g.Neighbours(n) {
+			if closed[nb] {
+				continue
+			}
+			c := cost[n] + d(n, nb)
+			if old, ok := cost[nb]; ok && old <= c {
+				continue
+			}
+			cost[nb] = c
+			prev[nb] = n
+			heap.Push(pq, queuedNode[Node]{node: nb, priority: c})
+		}
+	}
+	paths := make([][]Node, len(goals))
+	for i, n := range goals {
+		if closed[n] {
+			paths[i] = tracePath(prev, start, n)
+		}
+	}
+	return paths
+}
+
+// findPathBidirectional finds the shortest path in graph g from node start
+// to node dest with two A* searches, one from start towards dest and one
+// from dest towards start, using the cost function d and the cost
+// heuristic function h. The graph must contain the reverse of each edge.
+// It returns nil if dest is not reachable.
+//
+// The searches alternately expand the node with the lowest estimated path
+// cost of either search. They stop when this estimate reaches the cost of
+// the cheapest path found so far through a node reached by both searches.
+func findPathBidirectional[Node comparable](g astar.Graph[Node], start, dest Node, d, h astar.CostFunc[Node]) []Node {
+	if start == dest {
+		return []Node{start}
+	}
+	type search struct {
+		origin Node
+		cost   map[Node]float64
+		prev   map[Node]Node
+		closed map[Node]bool
+		pq     *nodeQueue[Node]
+		d      astar.CostFunc[Node] // the cost of an edge in search direction
+		h      func(Node) float64
+	}
+	newSearch := func(origin Node, d astar.CostFunc[Node], h func(Node) float64) *search {
+		return &search{
+			origin: origin,
+			cost:   map[Node]float64{origin: 0},
+			prev:   make(map[Node]Node),
+			closed: make(map[Node]bool),
+			pq:     &nodeQueue[Node]{{node: origin, priority: h(origin)}},
+			d:      d,
+			h:      h,
+		}
+	}
+	fwd := newSearch(start, d, func(n Node) float64 { return h(n, dest) })
+	bwd := newSearch(dest, func(a, b Node) float64 { return d(b, a) }, func(n Node) float64 { return h(start, n) })
+	// top returns the lowest priority of the open nodes of search s.
+	top := func(s *search) float64 {
+		for s.pq.Len() > 0 && s.closed[(*s.pq)[0].node] {
+			heap.Pop(s.pq)
+		}
+		if s.pq.Len() == 0 {
+			return math.Inf(1)
+		}
+		return (*s.pq)[0].priority
+	}
+	best := math.Inf(1)
+	var meet Node
+	for {
+		f, b := top(fwd), top(bwd)
+		if math.IsInf(min(f, b), 1) || max(f, b) >= best {
+			break
+		}
+		s, other := fwd, bwd
+		if b < f {
+			s, other = bwd, fwd
+		}
+		n := heap.Pop(s.pq).(queuedNode[Node]).node
+		s.closed[n] = true
+		for _, nb := range g.Neighbours(n) {
+			if s.closed[nb] {
+				continue
+			}
+			c := s.cost[n] + s.d(n, nb)
+			if old, ok := s.cost[nb]; ok && old <= c {
+				continue
+			}
+			s.cost[nb] = c
+			s.prev[nb] = n
+			heap.Push(s.pq, queuedNode[Node]{node: nb, priority: c + s.h(nb)})
+			if oc, ok := other.cost[nb]; ok && c+oc < best {
+				best, meet = c+oc, nb
+			}
+		}
+	}
+	if math.IsInf(best, 1) {
+		return nil
+	}
+	path := tracePath(fwd.prev, start, meet)
+	back := tracePath(bwd.prev, dest, meet)
+	for i := len(back) - 2; i >= 0; i-- {
+		path = append(path, back[i])
+	}
+	return path
+}
+
+// tracePath follows the predecessor links from node end back to node start
+// and returns the nodes in order from start to end.
+func tracePath[Node comparable](prev map[Node]Node, start, end Node) []Node {
+	path := []Node{end}
+	for n := end; n != start; {
+		n = prev[n]
+		path = append(path, n)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// queuedNode is an element of a nodeQueue.
+type queuedNode[Node any] struct {
+	node     Node
+	priority float64
+}
+
+// nodeQueue is a priority queue of nodes with the lowest priority value
+// first. It implements heap.Interface.
+type nodeQueue[Node any] []queuedNode[Node]
+
+func (q nodeQueue[Node]) Len() int           { return len(q) }
+func (q nodeQueue[Node]) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q nodeQueue[Node]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *nodeQueue[Node]) Push(x any) {
+	*q = append(*q, x.(queuedNode[Node]))
+}
+
+func (q *nodeQueue[Node]) Pop() any {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	*q = old[:n-1]
+	return x
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindNearest(t *testing.T) {
+	//   a --1-- b --1-- c
+	//   |               |
+	//   5               1
+	//   |               |
+	//   d               e
+	g := make(graph[string])
+	link := func(a, b string) {
+		g.link(a, b).link(b, a)
+	}
+	link("a", "b")
+	link("b", "c")
+	link("a", "d")
+	link("c", "e")
+	cost := map[[2]string]float64{
+		{"a", "b"}: 1, {"b", "c"}: 1, {"a", "d"}: 5, {"c", "e"}: 1,
+	}
+	d := func(a, b string) float64 {
+		if c, ok := cost[[2]string{a, b}]; ok {
+			return c
+		}
+		return cost[[2]string{b, a}]
+	}
+	h := func(string) float64 { return 0 }
+
+	tests := []struct {
+		start    string
+		goals    []string
+		wantPath []string
+		wantGoal int
+	}{
+		{"a", []string{"d", "e"}, []string{"a", "b", "c", "e"}, 1},
+		{"a", []string{"e", "d"}, []string{"a", "b", "c", "e"}, 0},
+		{"d", []string{"e", "b"}, []string{"d", "a", "b"}, 1},
+		{"a", []string{"a", "b"}, []string{"a"}, 0},
+		{"a", []string{"x"}, nil, -1},
+		{"a", nil, nil, -1},
+	}
+	for _, tt := range tests {
+		path, goal := findNearest[string](g, tt.start, tt.goals, d, nil, h)
+		if !reflect.DeepEqual(path, tt.wantPath) || goal != tt.wantGoal {
+			t.Errorf("findNearest(%q, %q) = %v, %d; want %v, %d", tt.start, tt.goals, path, goal, tt.wantPath, tt.wantGoal)
+		}
+	}
+}
+
+func TestShortestPaths(t *testing.T) {
+	//   a --1-- b --1-- c     x
+	//   |               |
+	//   5               1
+	//   |               |
+	//   d               e
+	g := make(graph[string])
+	cost := make(map[[2]string]float64)
+	link := func(a, b string, c float64) {
+		g.link(a, b).link(b, a)
+		cost[[2]string{a, b}], cost[[2]string{b, a}] = c, c
+	}
+	link("a", "b", 1)
+	link("b", "c", 1)
+	link("a", "d", 5)
+	link("c", "e", 1)
+	d := func(a, b string) float64 { return cost[[2]string{a, b}] }
+
+	got := shortestPaths[string](g, "b", []string{"d", "e", "x", "b", "c"}, d)
+	want := [][]string{
+		{"b", "a", "d"},
+		{"b", "c", "e"},
+		nil,
+		{"b"},
+		{"b", "c"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("shortestPaths\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestFindNearestTurnCost(t *testing.T) {
+	//   s ------2------ x ------2------ t
+	//    \                             /
+	//     1                           1
+	//      \                         /
+	//       y ---------1---------- z
+	g := make(graph[string])
+	cost := make(map[[2]string]float64)
+	link := func(a, b string, c float64) {
+		g.link(a, b).link(b, a)
+		cost[[2]string{a, b}], cost[[2]string{b, a}] = c, c
+	}
+	link("s", "x", 2)
+	link("x", "t", 2)
+	link("s", "y", 1)
+	link("y", "z", 1)
+	link("z", "t", 1)
+	d := func(a, b string) float64 { return cost[[2]string{a, b}] }
+	h := func(string) float64 { return 0 }
+	// Every turn costs 1, going straight through x is free.
+	turn := func(a, b, c string) float64 {
+		if b == "x" && a != c {
+			return 0
+		}
+		return 1
+	}
+	tests := []struct {
+		start, goal string
+		turn        func(a, b, c string) float64
+		wantPath    []string
+	}{
+		{"s", "t", nil, []string{"s", "y", "z", "t"}},
+		{"s", "t", turn, []string{"s", "x", "t"}},
+		{"t", "s", turn, []string{"t", "x", "s"}},
+		{"s", "z", turn, []string{"s", "y", "z"}},
+	}
+	for _, tt := range tests {
+		path, _ := findNearest[string](g, tt.start, []string{tt.goal}, d, tt.turn, h)
+		if !reflect.DeepEqual(path, tt.wantPath) {
+			t.Errorf("findNearest(%q, %q), turn cost %t: got %v, want %v", tt.start, tt.goal, tt.turn != nil, path, tt.wantPath)
+		}
+	}
+}
+
+func TestFindPathBidirectional(t *testing.T) {
+	//   a --1-- b --1-- c --1-- d
+	//   |                       |
+	//   1                       4
+	//   |                       |
+	//   e ----------2---------- f      g
+	g := make(graph[string])
+	cost := make(map[[2]string]float64)
+	link := func(a, b string, c float64) {
+		g.link(a, b).link(b, a)
+		cost[[2]string{a, b}], cost[[2]string{b, a}] = c, c
+	}
+	link("a", "b", 1)
+	link("b", "c", 1)
+	link("c", "d", 1)
+	link("a", "e", 1)
+	link("e", "f", 2)
+	link("d", "f", 4)
+	d := func(a, b string) float64 { return cost[[2]string{a, b}] }
+	h := func(a, b string) float64 { return 0 }
+
+	tests := []struct {
+		start, dest string
+		want        []string
+	}{
+		{"a", "d", []string{"a", "b", "c", "d"}},
+		{"d", "a", []string{"d", "c", "b", "a"}},
+		{"b", "f", []string{"b", "a", "e", "f"}},
+		{"c", "f", []string{"c", "d", "f"}},
+		{"a", "a", []string{"a"}},
+		{"a", "g", nil},
+	}
+	for _, tt := range tests {
+		got := findPathBidirectional[string](g, tt.start, tt.dest, d, h)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findPathBidirectional(%q, %q) = %v, want %v", tt.start, tt.dest, got, tt.want)
+		}
+	}
+}
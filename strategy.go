@@ -0,0 +1,134 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"github.com/fzipp/astar"
+	"github.com/fzipp/geom"
+)
+
+// A Search selects the algorithm that searches the visibility graph for
+// the path queries of a Pathfinder.
+type Search int
+
+const (
+	// AStarSearch is the A* algorithm, guided by the heuristic towards the
+	// destination.
+	AStarSearch Search = iota
+	// BidirectionalSearch runs A* from both the start point and the
+	// destination until the searches meet. It visits fewer nodes than
+	// AStarSearch in large graphs. With a turn cost, see WithTurnCost, and
+	// for FindNearest it falls back to AStarSearch.
+	BidirectionalSearch
+	// DijkstraSearch is Dijkstra's algorithm, i.e. A* without heuristic.
+	// It finds the cheapest path even if the cost is lower than the
+	// heuristic assumes.
+	DijkstraSearch
+	// WeightedAStarSearch is A* with the heuristic multiplied by 1+ε. It
+	// visits fewer nodes than AStarSearch, but the cost of the path found
+	// can exceed the cost of the cheapest path by a factor of up to 1+ε.
+	WeightedAStarSearch
+)
+
+// An EdgeCost returns the cost of the straight path edge from a to b.
+// It receives the default cost of the edge, which is its length, or its
+// length weighted by the cost factors of the regions it passes if the
+// Pathfinder was configured with regions. The cost must not be negative.
+type EdgeCost func(a, b geom.Vec2, cost float64) float64
+
+// A TurnCost returns the additional cost of a path that arrives at
+// waypoint b from waypoint a and continues to waypoint c, e.g. depending
+// on the angle between the edges. The cost must not be negative.
+type TurnCost func(a, b, c geom.Vec2) float64
+
+// A Heuristic estimates the cost of the cheapest path from point a to
+// point dest. A* finds the cheapest path only if the estimate never
+// exceeds the actual cost and the estimate for a never exceeds the cost
+// of an edge to a point b plus the estimate for b.
+type Heuristic func(a, dest geom.Vec2) float64
+
+// WithSearch configures the algorithm that searches the visibility graph
+// for the path queries. The default is AStarSearch. The parameter epsilon
+// is only used by WeightedAStarSearch.
+func WithSearch(s Search, epsilon float64) Option {
+	return func(c *config) {
+		c.search.algorithm = s
+		c.search.epsilon = epsilon
+	}
+}
+
+// WithEdgeCost configures the cost of the path edges, e.g. to penalize
+// edges near certain zones. The default heuristic assumes that the cost
+// of an edge is at least its default cost, or its length multiplied by
+// the smallest region cost factor with regions. If the cost can be lower,
+// configure a matching heuristic with WithHeuristic or use DijkstraSearch.
+func WithEdgeCost(cost EdgeCost) Option {
+	return func(c *config) {
+		c.search.edgeCost = cost
+	}
+}
+
+// WithTurnCost configures an additional cost for the turns of the path at
+// its waypoints, e.g. to penalize sharp turns. The path search considers
+// the edge by which a waypoint is reached, so it visits the nodes of the
+// visibility graph repeatedly.
+func WithTurnCost(cost TurnCost) Option {
+	return func(c *config) {
+		c.search.turnCost = cost
+	}
+}
+
+// WithHeuristic configures the heuristic that guides the path search
+// towards the destination. The default is the distance to the destination,
+// multiplied by the smallest cost factor of the regions if it is smaller
+// than 1.
+func WithHeuristic(h Heuristic) Option {
+	return func(c *config) {
+		c.search.heuristic = h
+	}
+}
+
+// searchConfig holds the configuration of the path search as set by
+// WithSearch, WithEdgeCost, WithTurnCost and WithHeuristic.
+type searchConfig struct {
+	algorithm Search
+	epsilon   float64
+	edgeCost  EdgeCost
+	turnCost  TurnCost
+	heuristic Heuristic
+}
+
+// isDefault reports whether the configuration is the default one.
+func (s searchConfig) isDefault() bool {
+	return s.algorithm == AStarSearch && s.epsilon == 0 &&
+		s.edgeCost == nil && s.turnCost == nil && s.heuristic == nil
+}
+
+// searchPath finds the cheapest path from start to dest in the query graph
+// vis with the configured search algorithm.
+func (p *Pathfinder) searchPath(vis overlay[geom.Vec2], start, dest geom.Vec2) []geom.Vec2 {
+	switch {
+	case p.search.turnCost != nil:
+		path, _ := findNearest[geom.Vec2](vis, start, []geom.Vec2{dest}, p.costFunc(), p.search.turnCost, func(n geom.Vec2) float64 {
+			return p.heuristic(n, dest)
+		})
+		return path
+	case p.search.algorithm == BidirectionalSearch:
+		return findPathBidirectional[geom.Vec2](vis, start, dest, p.costFunc(), p.heuristic)
+	default:
+		return astar.FindPath[geom.Vec2](vis, start, dest, p.costFunc(), p.heuristic)
+	}
+}
+
+// turnCosts returns the sum of the turn costs of a path.
+func (p *Pathfinder) turnCosts(path []geom.Vec2) float64 {
+	var c float64
+	if p.search.turnCost != nil {
+		for i := 2; i < len(path); i++ {
+			c += p.search.turnCost(path[i-2], path[i-1], path[i])
+		}
+	}
+	return c
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+// polygonsMaze is a square with 4×4 holes of different shapes.
+var polygonsMaze = func() [][]geom.Vec2 {
+	polygons := [][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(50, 0), geom.V2(50, 50), geom.V2(0, 50)},
+	}
+	for i := range 4 {
+		for j := range 4 {
+			x, y := float32(12*i+5), float32(12*j+5)
+			polygons = append(polygons, []geom.Vec2{
+				geom.V2(x, y), geom.V2(x+4, y), geom.V2(x+4+float32(i%3), y+4), geom.V2(x, y+4+float32(j%2)),
+			})
+		}
+	}
+	return polygons
+}()
+
+// randomQueries returns n pairs of random points inside the polygon set of
+// the Pathfinder.
+func randomQueries(pathfinder *pathfind.Pathfinder, n int) [][2]geom.Vec2 {
+	r := rand.New(rand.NewPCG(1, 2))
+	var queries [][2]geom.Vec2
+	for len(queries) < n {
+		q := [2]geom.Vec2{geom.V2(r.Float32()*50, r.Float32()*50), geom.V2(r.Float32()*50, r.Float32()*50)}
+		if pathfinder.Contains(q[0]) && pathfinder.Contains(q[1]) {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+func TestPathfinderWithSearch(t *testing.T) {
+	astar := pathfind.NewPathfinderF(polygonsMaze)
+	tests := []struct {
+		name    string
+		search  pathfind.Search
+		epsilon float64
+	}{
+		{"Bidirectional", pathfind.BidirectionalSearch, 0},
+		{"Dijkstra", pathfind.DijkstraSearch, 0},
+		{"Weighted A*", pathfind.WeightedAStarSearch, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathfinder := pathfind.NewPathfinderF(polygonsMaze, pathfind.WithSearch(tt.search, tt.epsilon))
+			for _, q := range randomQueries(astar, 100) {
+				want, err := astar.Find(q[0], q[1])
+				if err != nil {
+					t.Fatalf("A*: Find(%v, %v): unexpected error: %v", q[0], q[1], err)
+				}
+				got, err := pathfinder.Find(q[0], q[1])
+				if err != nil {
+					t.Fatalf("Find(%v, %v): unexpected error: %v", q[0], q[1], err)
+				}
+				if got.Path[0] != q[0] || got.Path[len(got.Path)-1] != q[1] {
+					t.Errorf("Find(%v, %v): path %v does not connect start and destination", q[0], q[1], got.Path)
+				}
+				if got.Cost < want.Cost-1e-9 || got.Cost > want.Cost*(1+tt.epsilon)+1e-9 {
+					t.Errorf("Find(%v, %v): got cost %g, want %g (ε = %g)", q[0], q[1], got.Cost, want.Cost, tt.epsilon)
+				}
+			}
+		})
+	}
+}
+
+func TestPathfinderWithSearchUnreachable(t *testing.T) {
+	polygons := [][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)},
+		{geom.V2(20, 0), geom.V2(30, 0), geom.V2(30, 10), geom.V2(20, 10)},
+	}
+	pathfinder := pathfind.NewPathfinderF(polygons, pathfind.WithSearch(pathfind.BidirectionalSearch, 0))
+	start, dest := geom.V2(5, 5), geom.V2(25, 5)
+	if _, err := pathfinder.Find(start, dest); err != pathfind.ErrUnreachable {
+		t.Errorf("Find(%v, %v): got error %v, want %v", start, dest, err, pathfind.ErrUnreachable)
+	}
+}
+
+func TestPathfinderWithEdgeCost(t *testing.T) {
+	// Edges left of the hole are expensive, so the path passes it on the
+	// right instead.
+	penalty := func(a, b geom.Vec2, cost float64) float64 {
+		if a.Add(b).X/2 < 20 {
+			return cost * 10
+		}
+		return cost
+	}
+	polygons := vecPolygons(polygonO)
+	start, dest := geom.V2(20, 5), geom.V2(20, 35)
+	pathfinder := pathfind.NewPathfinderF(polygons, pathfind.WithEdgeCost(penalty))
+	got, err := pathfinder.Find(start, dest)
+	if err != nil {
+		t.Fatalf("Find(%v, %v): unexpected error: %v", start, dest, err)
+	}
+	want := []geom.Vec2{start, geom.V2(30, 20), dest}
+	if !reflect.DeepEqual(got.Path, want) {
+		t.Errorf("Find(%v, %v)\n got: %v\nwant: %v", start, dest, got.Path, want)
+	}
+	if got.Cost != got.Length {
+		t.Errorf("Find(%v, %v): got cost %g, want length %g", start, dest, got.Cost, got.Length)
+	}
+}
+
+func TestPathfinderWithTurnCost(t *testing.T) {
+	// A constant cost per turn makes the search prefer paths with fewer
+	// waypoints.
+	const perTurn = 20
+	turn := func(a, b, c geom.Vec2) float64 {
+		return perTurn
+	}
+	astar := pathfind.NewPathfinderF(polygonsMaze)
+	tests := []struct {
+		name   string
+		search pathfind.Search
+	}{
+		{"A*", pathfind.AStarSearch},
+		{"Bidirectional", pathfind.BidirectionalSearch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathfinder := pathfind.NewPathfinderF(polygonsMaze, pathfind.WithTurnCost(turn), pathfind.WithSearch(tt.search, 0))
+			fewer := false
+			for _, q := range randomQueries(astar, 100) {
+				shortest, err := astar.Find(q[0], q[1])
+				if err != nil {
+					t.Fatalf("A*: Find(%v, %v): unexpected error: %v", q[0], q[1], err)
+				}
+				got, err := pathfinder.Find(q[0], q[1])
+				if err != nil {
+					t.Fatalf("Find(%v, %v): unexpected error: %v", q[0], q[1], err)
+				}
+				turns := func(path []geom.Vec2) float64 {
+					return float64(max(len(path)-2, 0)) * perTurn
+				}
+				if want := got.Length + turns(got.Path); math.Abs(got.Cost-want) > 1e-9 {
+					t.Errorf("Find(%v, %v): got cost %g, want length plus turn costs %g", q[0], q[1], got.Cost, want)
+				}
+				if limit := shortest.Length + turns(shortest.Path); got.Cost > limit+1e-9 {
+					t.Errorf("Find(%v, %v): got cost %g, want at most %g of the shortest path", q[0], q[1], got.Cost, limit)
+				}
+				fewer = fewer || len(got.Path) < len(shortest.Path)
+			}
+			if !fewer {
+				t.Errorf("no path with fewer turns than the shortest path")
+			}
+		})
+	}
+}
+
+func TestPathfinderWithHeuristic(t *testing.T) {
+	var calls int
+	h := func(a, dest geom.Vec2) float64 {
+		calls++
+		return float64(a.Dist(dest))
+	}
+	pathfinder := pathfind.NewPathfinderF(polygonUF, pathfind.WithHeuristic(h))
+	start, dest := geom.V2(2.5, 2.5), geom.V2(12.5, 2.5)
+	got := pathfinder.PathF(start, dest)
+	want := pathfind.NewPathfinderF(polygonUF).PathF(start, dest)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathF(%v, %v)\n got: %v\nwant: %v", start, dest, got, want)
+	}
+	if calls == 0 {
+		t.Errorf("heuristic was not called")
+	}
+}
+
+func TestNewCheckedPathfinderSearchOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []pathfind.Option
+		wantErr error
+	}{
+		{
+			name:    "Invalid search",
+			opts:    []pathfind.Option{pathfind.WithSearch(7, 0)},
+			wantErr: errors.New("pathfind: invalid search 7"),
+		},
+		{
+			name:    "Negative epsilon",
+			opts:    []pathfind.Option{pathfind.WithSearch(pathfind.WeightedAStarSearch, -1)},
+			wantErr: errors.New("pathfind: invalid epsilon -1"),
+		},
+		{
+			name: "Navigation mesh",
+			opts: []pathfind.Option{
+				pathfind.WithBackend(pathfind.NavMeshBackend),
+				pathfind.WithSearch(pathfind.DijkstraSearch, 0),
+			},
+			wantErr: errors.New("pathfind: search options are not supported by the navigation mesh backend"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pathfind.NewCheckedPathfinder(polygonO, tt.opts...)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("NewCheckedPathfinder\n got error: %v\nwant error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
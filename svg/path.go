@@ -0,0 +1,442 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// A point is a position in SVG user space. Points are kept in float64
+// until the final conversion to geom.Vec2, so that transforms and curve
+// flattening don't accumulate float32 rounding errors.
+type point struct {
+	x, y float64
+}
+
+func (p point) add(q point) point             { return point{p.x + q.x, p.y + q.y} }
+func (p point) sub(q point) point             { return point{p.x - q.x, p.y - q.y} }
+func (p point) scale(f float64) point         { return point{p.x * f, p.y * f} }
+func (p point) lerp(q point, t float64) point { return p.add(q.sub(p).scale(t)) }
+
+// parsePath parses SVG path data, the value of the d attribute of a path
+// element, and returns its subpaths as flattened polylines. Curves and arcs
+// are approximated by line segments that deviate at most tol from the curve.
+// Subpaths are implicitly closed.
+func parsePath(d string, tol float64) ([][]point, error) {
+	s := pathScanner{s: d}
+	var (
+		subpaths [][]point
+		cur      []point
+		pos      point // current point
+		start    point // start of the current subpath
+		ctrl     point // last control point for smooth curves
+		prev     byte  // previous command, lower case
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			subpaths = append(subpaths, cur)
+		}
+		cur = nil
+	}
+	lineTo := func(p point) {
+		if len(cur) == 0 {
+			cur = append(cur, pos)
+		}
+		cur = append(cur, p)
+		pos = p
+	}
+	var cmd byte
+	for {
+		s.skipSeparators()
+		if s.eof() {
+			break
+		}
+		if c := s.peek(); isCommand(c) {
+			cmd = c
+			s.i++
+		} else if cmd == 0 {
+			return nil, s.errorf("expected command, found %q", c)
+		} else if cmd == 'M' || cmd == 'm' {
+			// Coordinate pairs following a moveto are implicit lineto commands.
+			cmd -= 'M' - 'L'
+		} else if cmd == 'Z' || cmd == 'z' {
+			return nil, s.errorf("unexpected %q after closepath", c)
+		}
+		rel := cmd >= 'a'
+		lower := cmd | 0x20
+		// origin returns the reference point for relative coordinates.
+		origin := func() point {
+			if rel {
+				return pos
+			}
+			return point{}
+		}
+		switch lower {
+		case 'm':
+			p, err := s.point()
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			pos = origin().add(p)
+			start = pos
+		case 'z':
+			flush()
+			pos = start
+		case 'l':
+			p, err := s.point()
+			if err != nil {
+				return nil, err
+			}
+			lineTo(origin().add(p))
+		case 'h':
+			x, err := s.number()
+			if err != nil {
+				return nil, err
+			}
+			if rel {
+				x += pos.x
+			}
+			lineTo(point{x, pos.y})
+		case 'v':
+			y, err := s.number()
+			if err != nil {
+				return nil, err
+			}
+			if rel {
+				y += pos.y
+			}
+			lineTo(point{pos.x, y})
+		case 'c', 's':
+			o := origin()
+			var c1 point
+			if lower == 'c' {
+				p, err := s.point()
+				if err != nil {
+					return nil, err
+				}
+				c1 = o.add(p)
+			} else {
+				c1 = reflected(pos, ctrl, prev == 'c' || prev == 's')
+			}
+			ps, err := s.points(2)
+			if err != nil {
+				return nil, err
+			}
+			c2, end := o.add(ps[0]), o.add(ps[1])
+			for _, p := range flattenCubic(pos, c1, c2, end, tol) {
+				lineTo(p)
+			}
+			ctrl = c2
+		case 'q', 't':
+			o := origin()
+			var c point
+			if lower == 'q' {
+				p, err := s.point()
+				if err != nil {
+					return nil, err
+				}
+				c = o.add(p)
+			} else {
+				c = reflected(pos, ctrl, prev == 'q' || prev == 't')
+			}
+			p, err := s.point()
+			if err != nil {
+				return nil, err
+			}
+			end := o.add(p)
+			// Elevate the quadratic curve to a cubic one.
+			c1 := pos.lerp(c, 2.0/3)
+			c2 := end.lerp(c, 2.0/3)
+			for _, p := range flattenCubic(pos, c1, c2, end, tol) {
+				lineTo(p)
+			}
+			ctrl = c
+		case 'a':
+			rx, err := s.number()
+			if err != nil {
+				return nil, err
+			}
+			ry, err := s.number()
+			if err != nil {
+				return nil, err
+			}
+			phi, err := s.number()
+			if err != nil {
+				return nil, err
+			}
+			large, err := s.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := s.flag()
+			if err != nil {
+				return nil, err
+			}
+			p, err := s.point()
+			if err != nil {
+				return nil, err
+			}
+			end := origin().add(p)
+			for _, p := range flattenArc(pos, end, rx, ry, phi, large, sweep, tol) {
+				lineTo(p)
+			}
+		}
+		prev = lower
+	}
+	flush()
+	return subpaths, nil
+}
+
+func isCommand(c byte) bool {
+	switch c | 0x20 {
+	case 'm', 'z', 'l', 'h', 'v', 'c', 's', 'q', 't', 'a':
+		return true
+	}
+	return false
+}
+
+// reflected returns the first control point of a smooth curve segment: the
+// reflection of the previous control point ctrl about the current point
+// pos, if the previous segment was a curve of the same kind, otherwise pos.
+func reflected(pos, ctrl point, smooth bool) point {
+	if !smooth {
+		return pos
+	}
+	return pos.scale(2).sub(ctrl)
+}
+
+// maxSubdivision limits the recursion depth of flattenCubic.
+const maxSubdivision = 16
+
+// flattenCubic approximates the cubic Bézier curve from p0 to p3 with
+// control points p1 and p2 by a polyline. It returns the points of the
+// polyline without p0.
+func flattenCubic(p0, p1, p2, p3 point, tol float64) []point {
+	var pts []point
+	var subdivide func(p0, p1, p2, p3 point, depth int)
+	subdivide = func(p0, p1, p2, p3 point, depth int) {
+		if depth >= maxSubdivision ||
+			(distToLine(p1, p0, p3) <= tol && distToLine(p2, p0, p3) <= tol) {
+			pts = append(pts, p3)
+			return
+		}
+		// De Casteljau subdivision at t = 0.5
+		p01, p12, p23 := p0.lerp(p1, 0.5), p1.lerp(p2, 0.5), p2.lerp(p3, 0.5)
+		p012, p123 := p01.lerp(p12, 0.5), p12.lerp(p23, 0.5)
+		mid := p012.lerp(p123, 0.5)
+		subdivide(p0, p01, p012, mid, depth+1)
+		subdivide(mid, p123, p23, p3, depth+1)
+	}
+	subdivide(p0, p1, p2, p3, 0)
+	return pts
+}
+
+// distToLine returns the distance of p from the line segment from a to b.
+// The control points of a curve lie within the convex hull of the curve's
+// end points and the control points, so if they are close to the chord,
+// the whole curve is.
+func distToLine(p, a, b point) float64 {
+	ab, ap := b.sub(a), p.sub(a)
+	l2 := ab.x*ab.x + ab.y*ab.y
+	t := 0.0
+	if l2 > 0 {
+		t = min(max((ap.x*ab.x+ap.y*ab.y)/l2, 0), 1)
+	}
+	return math.Hypot(ap.x-t*ab.x, ap.y-t*ab.y)
+}
+
+// flattenArc approximates an SVG elliptical arc from p0 to p1 by a
+// polyline. It returns the points of the polyline without p0.
+// The conversion from endpoint to center parameterization follows
+// appendix B.2.4 of the SVG 2 specification.
+func flattenArc(p0, p1 point, rx, ry, phiDeg float64, large, sweep bool, tol float64) []point {
+	if p0 == p1 {
+		return nil
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return []point{p1}
+	}
+	sinPhi, cosPhi := math.Sincos(phiDeg * math.Pi / 180)
+	dx, dy := (p0.x-p1.x)/2, (p0.y-p1.y)/2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+	// Scale up radii that are too small to reach the end point.
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		s := math.Sqrt(l)
+		rx, ry = rx*s, ry*s
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	f := math.Sqrt(max(0, num/den))
+	if large == sweep {
+		f = -f
+	}
+	cx1, cy1 := f*rx*y1/ry, -f*ry*x1/rx
+	cx := cosPhi*cx1 - sinPhi*cy1 + (p0.x+p1.x)/2
+	cy := sinPhi*cx1 + cosPhi*cy1 + (p0.y+p1.y)/2
+	theta1 := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	theta2 := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx)
+	dTheta := theta2 - theta1
+	if sweep && dTheta < 0 {
+		dTheta += 2 * math.Pi
+	} else if !sweep && dTheta > 0 {
+		dTheta -= 2 * math.Pi
+	}
+	n := arcSegments(max(rx, ry), math.Abs(dTheta), tol)
+	pts := make([]point, 0, n)
+	for i := 1; i < n; i++ {
+		sin, cos := math.Sincos(theta1 + dTheta*float64(i)/float64(n))
+		x, y := rx*cos, ry*sin
+		pts = append(pts, point{cosPhi*x - sinPhi*y + cx, sinPhi*x + cosPhi*y + cy})
+	}
+	return append(pts, p1)
+}
+
+// maxArcSegments limits the number of segments of a flattened arc.
+const maxArcSegments = 1 << 12
+
+// arcSegments returns the number of line segments needed to approximate
+// an arc of a circle with radius r spanning the given angle, so that the
+// segments deviate at most tol from the arc.
+func arcSegments(r, angle, tol float64) int {
+	if tol >= r {
+		return max(1, int(math.Ceil(angle/(2*math.Pi/3))))
+	}
+	step := 2 * math.Acos(1-tol/r)
+	return min(max(1, int(math.Ceil(angle/step))), maxArcSegments)
+}
+
+// parseNumbers parses a list of numbers separated by whitespace and/or
+// commas, as used by the points attribute and transform functions.
+func parseNumbers(str string) ([]float64, error) {
+	s := pathScanner{s: str}
+	var nums []float64
+	for {
+		s.skipSeparators()
+		if s.eof() {
+			return nums, nil
+		}
+		n, err := s.number()
+		if err != nil {
+			return nil, err
+		}
+		nums = append(nums, n)
+	}
+}
+
+// A pathScanner reads numbers and flags from SVG path data and other
+// attributes with number lists.
+type pathScanner struct {
+	s string
+	i int
+}
+
+func (s *pathScanner) eof() bool  { return s.i >= len(s.s) }
+func (s *pathScanner) peek() byte { return s.s[s.i] }
+
+func (s *pathScanner) skipSeparators() {
+	for !s.eof() {
+		switch s.peek() {
+		case ' ', '\t', '\r', '\n', '\f', ',':
+			s.i++
+		default:
+			return
+		}
+	}
+}
+
+// number scans a number. Numbers may follow each other without separator,
+// if this is unambiguous, e.g. "1-2" or "0.5.5".
+func (s *pathScanner) number() (float64, error) {
+	s.skipSeparators()
+	begin := s.i
+	if !s.eof() && (s.peek() == '+' || s.peek() == '-') {
+		s.i++
+	}
+	digits := s.digits()
+	if !s.eof() && s.peek() == '.' {
+		s.i++
+		digits += s.digits()
+	}
+	if digits == 0 {
+		s.i = begin
+		if s.eof() {
+			return 0, s.errorf("expected number, found end of data")
+		}
+		return 0, s.errorf("expected number, found %q", s.peek())
+	}
+	if !s.eof() && (s.peek() == 'e' || s.peek() == 'E') {
+		exp := s.i
+		s.i++
+		if !s.eof() && (s.peek() == '+' || s.peek() == '-') {
+			s.i++
+		}
+		if s.digits() == 0 {
+			s.i = exp // not an exponent
+		}
+	}
+	f, err := strconv.ParseFloat(s.s[begin:s.i], 64)
+	if err != nil {
+		return 0, s.errorf("invalid number %q", s.s[begin:s.i])
+	}
+	return f, nil
+}
+
+func (s *pathScanner) digits() int {
+	n := 0
+	for !s.eof() && '0' <= s.peek() && s.peek() <= '9' {
+		s.i++
+		n++
+	}
+	return n
+}
+
+// flag scans an arc flag, which is a single 0 or 1 that may be directly
+// followed by the next flag or number.
+func (s *pathScanner) flag() (bool, error) {
+	s.skipSeparators()
+	if s.eof() {
+		return false, s.errorf("expected flag, found end of data")
+	}
+	switch c := s.peek(); c {
+	case '0', '1':
+		s.i++
+		return c == '1', nil
+	default:
+		return false, s.errorf("expected flag, found %q", c)
+	}
+}
+
+func (s *pathScanner) point() (point, error) {
+	x, err := s.number()
+	if err != nil {
+		return point{}, err
+	}
+	y, err := s.number()
+	if err != nil {
+		return point{}, err
+	}
+	return point{x, y}, nil
+}
+
+func (s *pathScanner) points(n int) ([]point, error) {
+	ps := make([]point, n)
+	for i := range ps {
+		p, err := s.point()
+		if err != nil {
+			return nil, err
+		}
+		ps[i] = p
+	}
+	return ps, nil
+}
+
+func (s *pathScanner) errorf(format string, args ...any) error {
+	return fmt.Errorf("offset %d: %s", s.i, fmt.Sprintf(format, args...))
+}
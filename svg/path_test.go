@@ -0,0 +1,91 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		d    string
+		want [][]point
+	}{
+		{"", nil},
+		{"M0 0L10 0L10 10Z", [][]point{{{0, 0}, {10, 0}, {10, 10}}}},
+		// implicit lineto after moveto, relative commands
+		{"m1 1 9 0 0 9z", [][]point{{{1, 1}, {10, 1}, {10, 10}}}},
+		{"M0,0H10V10h-10v-5", [][]point{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 5}}}},
+		// compact number syntax
+		{"M0-1L.5.5-1e1-2e-0", [][]point{{{0, -1}, {0.5, 0.5}, {-10, -2}}}},
+		// subpaths, drawing after closepath starts at the subpath start
+		{"M0 0h1v1z l-1 0 0-1 M5 5 h1v1", [][]point{
+			{{0, 0}, {1, 0}, {1, 1}},
+			{{0, 0}, {-1, 0}, {-1, -1}},
+			{{5, 5}, {6, 5}, {6, 6}},
+		}},
+		// straight curves and zero-radius arcs are lines
+		{"M0 0C0 0 10 0 10 0Q10 5 10 10A0 5 0 0 1 0 10", [][]point{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}},
+		// smooth curves reflect the previous control point
+		{"M0 0Q5 0 5 5T10 10S20 10 20 10", [][]point{{{0, 0}, {5, 5}, {10, 10}, {20, 10}}}},
+		// compact arc flags; a coarse half circle still has a midpoint
+		{"M0 0a5 5 0 1110 0", [][]point{{{0, 0}, {5, -5}, {10, 0}}}},
+	}
+	for _, tt := range tests {
+		got, err := parsePath(tt.d, 1000)
+		if err != nil {
+			t.Errorf("parsePath(%q): unexpected error: %v", tt.d, err)
+			continue
+		}
+		if !nearEqPolylines(got, tt.want) {
+			t.Errorf("parsePath(%q)\n got: %v\nwant: %v", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFlattenCubic(t *testing.T) {
+	p0, p1, p2, p3 := point{0, 0}, point{0, 100}, point{100, 100}, point{100, 0}
+	for _, tol := range []float64{10, 1, 0.1, 0.01} {
+		pts := append([]point{p0}, flattenCubic(p0, p1, p2, p3, tol)...)
+		if last := pts[len(pts)-1]; last != p3 {
+			t.Errorf("tolerance %v: last point %v, want: %v", tol, last, p3)
+		}
+		// Every point of the curve must be within tol of the polyline.
+		for i := range 1001 {
+			c := cubicAt(p0, p1, p2, p3, float64(i)/1000)
+			d := math.Inf(1)
+			for j := 1; j < len(pts); j++ {
+				d = min(d, distToLine(c, pts[j-1], pts[j]))
+			}
+			if d > tol*1.0001 {
+				t.Errorf("tolerance %v: curve point %v deviates %v from the polyline", tol, c, d)
+				break
+			}
+		}
+	}
+}
+
+func cubicAt(p0, p1, p2, p3 point, t float64) point {
+	a, b, c := p0.lerp(p1, t), p1.lerp(p2, t), p2.lerp(p3, t)
+	return a.lerp(b, t).lerp(b.lerp(c, t), t)
+}
+
+func nearEqPolylines(a, b [][]point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if math.Abs(a[i][j].x-b[i][j].x) > 1e-9 || math.Abs(a[i][j].y-b[i][j].y) > 1e-9 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,441 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package svg reads polygon sets for package pathfind from SVG drawings,
+// e.g. floor plans drawn with Inkscape.
+//
+// The outlines of <polygon>, <rect> and <path> elements become polygons.
+// Curves and arcs of paths and rounded rectangle corners are flattened to
+// line segments, and the transforms of the elements and their ancestor
+// groups are applied. The coordinates are the user space coordinates of
+// the outermost <svg> element; its viewBox is not applied.
+//
+// Whether a polygon is an accessible area or a hole can be marked
+// explicitly, either with a data-pathfind attribute on the element or on
+// one of its ancestors:
+//
+//	<rect data-pathfind="hole" x="10" y="10" width="20" height="20"/>
+//
+// or by placing the element on an Inkscape layer named after the role, see
+// Role. The attribute value "ignore" excludes an element or group, e.g.
+// decorations. The marked roles take precedence over the nesting of the
+// polygons. Unmarked polygons get their role from their nesting: a
+// polygon inside an area is a hole, a polygon inside a hole is an area
+// again. Polygons may touch the outline of the polygon they are nested in.
+package svg
+
+import (
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// A Role tells whether a polygon is an accessible area or a hole.
+type Role int
+
+const (
+	Area Role = iota // accessible area, e.g. a floor
+	Hole             // inaccessible hole inside an area, e.g. an obstacle
+)
+
+func (r Role) String() string {
+	switch r {
+	case Area:
+		return "area"
+	case Hole:
+		return "hole"
+	}
+	return "Role(" + strconv.Itoa(int(r)) + ")"
+}
+
+// layerRoles maps the lower-case names of Inkscape layers to the role of
+// the polygons on them.
+var layerRoles = map[string]Role{
+	"area":      Area,
+	"areas":     Area,
+	"floor":     Area,
+	"floors":    Area,
+	"hole":      Hole,
+	"holes":     Hole,
+	"obstacle":  Hole,
+	"obstacles": Hole,
+}
+
+const inkscapeNamespace = "http://www.inkscape.org/namespaces/inkscape"
+
+// A Polygon is a polygon read from an SVG element.
+type Polygon struct {
+	ID     string // id attribute of the element, if any
+	Role   Role
+	Parent int // index of the area or hole the polygon is nested in, or -1
+	Points []geom.Vec2
+}
+
+// A RoleError reports a polygon whose explicitly marked role can't be
+// satisfied by its nesting inside the other polygons, i.e. a polygon marked
+// as hole that doesn't lie inside an area.
+type RoleError struct {
+	Polygon int    // index of the polygon
+	ID      string // id attribute of the element, if any
+	Role    Role   // the marked role
+}
+
+func (e *RoleError) Error() string {
+	id := ""
+	if e.ID != "" {
+		id = fmt.Sprintf(" (id %q)", e.ID)
+	}
+	return fmt.Sprintf("svg: polygon %d%s is marked as %v, but isn't inside of an area",
+		e.Polygon, id, e.Role)
+}
+
+// ReadPolygons reads an SVG document from r and returns the polygons
+// described by its <polygon>, <rect> and <path> elements in document
+// order. Each subpath of a path becomes a polygon of its own, subpaths
+// are implicitly closed. Shapes with fewer than 3 distinct points,
+// like a straight line, are ignored, and so is the content of <defs>
+// and similar elements that are not rendered directly.
+//
+// Curves are flattened so that the line segments deviate at most tolerance
+// from the curve, measured in the coordinates of the result. The tolerance
+// must be positive.
+//
+// The polygons are reoriented to the winding order expected by pathfind.
+// The role of a marked polygon is taken as given, and the polygon is
+// nested in the innermost polygon of the opposite role that contains it,
+// so that a marked area is an island of a hole or at the first level, and
+// a marked hole is a hole of an area. Polygons that aren't marked are
+// nested in the innermost polygon that contains them and get their role
+// from it. If a marked hole isn't inside of an area, ReadPolygons returns
+// a *RoleError.
+func ReadPolygons(r io.Reader, tolerance float64) ([]Polygon, error) {
+	if !(tolerance > 0) {
+		return nil, fmt.Errorf("svg: invalid tolerance %v", tolerance)
+	}
+	d := reader{tolerance: tolerance}
+	if err := d.read(xml.NewDecoder(r)); err != nil {
+		return nil, err
+	}
+	if err := resolveRoles(d.polygons, d.marked); err != nil {
+		return nil, err
+	}
+	return d.polygons, nil
+}
+
+// NewPathfinder reads polygons from an SVG document via ReadPolygons and
+// creates a validated Pathfinder for them with
+// pathfind.NewPathfinderFromAreasF, with the areas, holes and islands
+// nested as read.
+func NewPathfinder(r io.Reader, tolerance float64, opts ...pathfind.Option) (*pathfind.Pathfinder, error) {
+	polygons, err := ReadPolygons(r, tolerance)
+	if err != nil {
+		return nil, err
+	}
+	return pathfind.NewPathfinderFromAreasF(areas(polygons), opts...)
+}
+
+// resolveRoles nests each marked polygon in the innermost polygon of the
+// opposite role containing it, and each unmarked polygon in the innermost
+// polygon containing it, whose role it inverts.
+func resolveRoles(polygons []Polygon, marked []bool) error {
+	size := func(i int) float32 {
+		return float32(math.Abs(float64(poly.Polygon(polygons[i].Points).SignedArea())))
+	}
+	// Visit the larger polygons first, so that the polygons around a
+	// polygon are resolved before it.
+	order := make([]int, len(polygons))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		return cmp.Compare(size(j), size(i))
+	})
+	for n, i := range order {
+		parent := -1
+		for _, j := range order[:n] {
+			if marked[i] && polygons[j].Role == polygons[i].Role {
+				continue
+			}
+			if (parent < 0 || size(j) < size(parent)) && poly.Polygon(polygons[i].Points).IsInside(polygons[j].Points) {
+				parent = j
+			}
+		}
+		polygons[i].Parent = parent
+		switch {
+		case marked[i]:
+			if polygons[i].Role == Hole && parent < 0 {
+				return &RoleError{Polygon: i, ID: polygons[i].ID, Role: Hole}
+			}
+		case parent < 0:
+			polygons[i].Role = Area
+		default:
+			polygons[i].Role = 1 - polygons[parent].Role
+		}
+	}
+	return nil
+}
+
+// areas converts the nested polygons to the areas at the first level, with
+// their holes and islands.
+func areas(polygons []Polygon) []pathfind.AreaF {
+	children := make(map[int][]int)
+	for i, p := range polygons {
+		children[p.Parent] = append(children[p.Parent], i)
+	}
+	var area func(i int) pathfind.AreaF
+	area = func(i int) pathfind.AreaF {
+		a := pathfind.AreaF{Outline: polygons[i].Points}
+		for _, j := range children[i] {
+			h := pathfind.HoleF{Outline: polygons[j].Points}
+			for _, k := range children[j] {
+				h.Islands = append(h.Islands, area(k))
+			}
+			a.Holes = append(a.Holes, h)
+		}
+		return a
+	}
+	var res []pathfind.AreaF
+	for _, i := range children[-1] {
+		res = append(res, area(i))
+	}
+	return res
+}
+
+// A reader collects the polygons of an SVG document.
+type reader struct {
+	tolerance float64
+	polygons  []Polygon
+	marked    []bool // whether the role of the polygon was marked explicitly
+}
+
+// A state is the inherited state of an element: the accumulated
+// transformation from its coordinate system to the outermost one
+// and its role.
+type state struct {
+	transform matrix
+	role      Role
+	marked    bool
+	ignored   bool
+}
+
+// unrendered lists the elements whose content is not rendered directly.
+var unrendered = []string{
+	"clipPath", "defs", "marker", "mask", "metadata", "pattern", "symbol",
+}
+
+func (d *reader) read(dec *xml.Decoder) error {
+	stack := []state{{transform: identity}}
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("svg: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			st, err := elementState(stack[len(stack)-1], t)
+			if err != nil {
+				return fmt.Errorf("svg: %s: %w", describe(t), err)
+			}
+			stack = append(stack, st)
+			if st.ignored {
+				continue
+			}
+			if err := d.shape(t, st); err != nil {
+				return fmt.Errorf("svg: %s: %w", describe(t), err)
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// elementState derives the state of an element from the state of its
+// parent.
+func elementState(parent state, el xml.StartElement) (state, error) {
+	st := parent
+	if slices.Contains(unrendered, el.Name.Local) || attr(el, "display") == "none" {
+		st.ignored = true
+	}
+	if el.Name.Local == "g" && attrNS(el, inkscapeNamespace, "groupmode") == "layer" {
+		if role, ok := layerRoles[strings.ToLower(attrNS(el, inkscapeNamespace, "label"))]; ok {
+			st.role, st.marked = role, true
+		}
+	}
+	switch v := attr(el, "data-pathfind"); v {
+	case "":
+	case "area":
+		st.role, st.marked = Area, true
+	case "hole":
+		st.role, st.marked = Hole, true
+	case "ignore":
+		st.ignored = true
+	default:
+		return st, fmt.Errorf("invalid data-pathfind value %q", v)
+	}
+	if s := attr(el, "transform"); s != "" {
+		m, err := parseTransform(s)
+		if err != nil {
+			return st, err
+		}
+		st.transform = st.transform.mul(m)
+	}
+	return st, nil
+}
+
+// shape adds the polygons of a shape element.
+func (d *reader) shape(el xml.StartElement, st state) error {
+	// Flatten curves in local coordinates with a tolerance that keeps
+	// the deviation within d.tolerance after the transformation.
+	tol := d.tolerance
+	if s := st.transform.scale(); s > 0 {
+		tol /= s
+	}
+	var (
+		outlines [][]point
+		err      error
+	)
+	switch el.Name.Local {
+	case "polygon":
+		outlines, err = polygonOutline(el)
+	case "rect":
+		outlines, err = rectOutline(el, tol)
+	case "path":
+		outlines, err = parsePath(attr(el, "d"), tol)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, o := range outlines {
+		p := transformed(o, st.transform)
+		if len(p) < 3 {
+			continue
+		}
+		d.polygons = append(d.polygons, Polygon{ID: attr(el, "id"), Role: st.role, Points: p})
+		d.marked = append(d.marked, st.marked)
+	}
+	return nil
+}
+
+func polygonOutline(el xml.StartElement) ([][]point, error) {
+	nums, err := parseNumbers(attr(el, "points"))
+	if err != nil {
+		return nil, err
+	}
+	if len(nums)%2 != 0 {
+		return nil, fmt.Errorf("odd number of coordinates in points")
+	}
+	outline := make([]point, len(nums)/2)
+	for i := range outline {
+		outline[i] = point{nums[2*i], nums[2*i+1]}
+	}
+	return [][]point{outline}, nil
+}
+
+func rectOutline(el xml.StartElement, tol float64) ([][]point, error) {
+	var x, y, w, h, rx, ry float64
+	for _, a := range []struct {
+		name string
+		v    *float64
+	}{{"x", &x}, {"y", &y}, {"width", &w}, {"height", &h}, {"rx", &rx}, {"ry", &ry}} {
+		if s := attr(el, a.name); s != "" && s != "auto" {
+			f, err := parseLength(s)
+			if err != nil {
+				return nil, err
+			}
+			*a.v = f
+		}
+	}
+	if w <= 0 || h <= 0 {
+		// not rendered
+		return nil, nil
+	}
+	if attr(el, "rx") == "" || attr(el, "rx") == "auto" {
+		rx = ry
+	}
+	if attr(el, "ry") == "" || attr(el, "ry") == "auto" {
+		ry = rx
+	}
+	rx, ry = min(max(rx, 0), w/2), min(max(ry, 0), h/2)
+	if rx == 0 || ry == 0 {
+		return [][]point{{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}}, nil
+	}
+	corners := []struct{ from, to point }{
+		{point{x + w - rx, y}, point{x + w, y + ry}},
+		{point{x + w, y + h - ry}, point{x + w - rx, y + h}},
+		{point{x + rx, y + h}, point{x, y + h - ry}},
+		{point{x, y + ry}, point{x + rx, y}},
+	}
+	var outline []point
+	for _, c := range corners {
+		outline = append(outline, c.from)
+		outline = append(outline, flattenArc(c.from, c.to, rx, ry, 0, false, true, tol)...)
+	}
+	return [][]point{outline}, nil
+}
+
+// parseLength parses a length attribute. Only lengths in user units,
+// optionally with the unit "px", are supported.
+func parseLength(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "px"))
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unsupported length %q", s)
+	}
+	return f, nil
+}
+
+// transformed applies the transformation m to the outline and converts it
+// to a polygon with positive signed area without repeated vertices.
+func transformed(outline []point, m matrix) poly.Polygon {
+	p := make(poly.Polygon, 0, len(outline))
+	for _, pt := range outline {
+		pt = m.apply(pt)
+		v := geom.V2(float32(pt.x), float32(pt.y))
+		if len(p) > 0 && p[len(p)-1] == v {
+			continue
+		}
+		p = append(p, v)
+	}
+	for len(p) > 1 && p[0] == p[len(p)-1] {
+		p = p[:len(p)-1]
+	}
+	p.Normalize()
+	return p
+}
+
+func attr(el xml.StartElement, name string) string {
+	return attrNS(el, "", name)
+}
+
+func attrNS(el xml.StartElement, space, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name && a.Name.Space == space {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// describe returns a short description of an element for error messages.
+func describe(el xml.StartElement) string {
+	if id := attr(el, "id"); id != "" {
+		return fmt.Sprintf("<%s id=%q>", el.Name.Local, id)
+	}
+	return "<" + el.Name.Local + ">"
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/svg"
+)
+
+// A floor plan as saved by Inkscape: a floor with an obstacle on the
+// "Obstacles" layer, a pillar marked as hole by attribute, a decoration
+// that is ignored, and a definition that is not rendered.
+const floorPlan = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg"
+     xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape"
+     width="100" height="100">
+  <defs>
+    <rect id="unused" x="0" y="0" width="5" height="5"/>
+  </defs>
+  <g inkscape:groupmode="layer" inkscape:label="Floor">
+    <path id="floor" d="M 0,0 H 40 V 40 H 0 Z"/>
+  </g>
+  <g inkscape:groupmode="layer" inkscape:label="Obstacles" transform="translate(10 10)">
+    <polygon id="crate" points="0,0 0,10 10,10 10,0"/>
+  </g>
+  <rect id="pillar" data-pathfind="hole" x="25" y="25" width="5" height="5"/>
+  <circle id="lamp" cx="35" cy="5" r="2"/>
+  <rect id="label" data-pathfind="ignore" x="1" y="1" width="3" height="3"/>
+</svg>`
+
+func TestReadPolygons(t *testing.T) {
+	got, err := svg.ReadPolygons(strings.NewReader(floorPlan), 0.1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []svg.Polygon{
+		{ID: "floor", Role: svg.Area, Parent: -1, Points: []geom.Vec2{
+			geom.V2(0, 0), geom.V2(40, 0), geom.V2(40, 40), geom.V2(0, 40),
+		}},
+		{ID: "crate", Role: svg.Hole, Parent: 0, Points: []geom.Vec2{
+			geom.V2(20, 10), geom.V2(20, 20), geom.V2(10, 20), geom.V2(10, 10),
+		}},
+		{ID: "pillar", Role: svg.Hole, Parent: 0, Points: []geom.Vec2{
+			geom.V2(25, 25), geom.V2(30, 25), geom.V2(30, 30), geom.V2(25, 30),
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadPolygons\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestReadPolygonsImplicitRoles(t *testing.T) {
+	const input = `<svg xmlns="http://www.w3.org/2000/svg">
+  <path d="M0 0h30v30h-30z M10 10v10h10v-10z"/>
+</svg>`
+	got, err := svg.ReadPolygons(strings.NewReader(input), 0.1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d polygons, want 2", len(got))
+	}
+	if got[0].Role != svg.Area || got[1].Role != svg.Hole {
+		t.Errorf("got roles %v, %v, want: area, hole", got[0].Role, got[1].Role)
+	}
+}
+
+func TestReadPolygonsCurves(t *testing.T) {
+	const tolerance = 0.01
+	tests := []struct {
+		name   string
+		input  string
+		center geom.Vec2
+		radius float64
+	}{
+		{"arc", `<path d="M 10,0 A 10,10 0 0 1 -10,0 A 10,10 0 0 1 10,0 Z"/>`, geom.V2(0, 0), 10},
+		{"rounded rect", `<rect x="0" y="0" width="10" height="10" rx="5"/>`, geom.V2(5, 5), 5},
+		{"scaled arc", `<g transform="scale(4)"><path d="M 1,0 A 1,1 0 1 0 1,0.0001 Z"/></g>`, geom.V2(0, 0.0002), 4},
+	}
+	for _, tt := range tests {
+		input := `<svg xmlns="http://www.w3.org/2000/svg">` + tt.input + `</svg>`
+		got, err := svg.ReadPolygons(strings.NewReader(input), tolerance)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if len(got) != 1 {
+			t.Errorf("%s: got %d polygons, want 1", tt.name, len(got))
+			continue
+		}
+		ps := got[0].Points
+		if len(ps) < 8 {
+			t.Errorf("%s: got %d vertices, want a flattened curve", tt.name, len(ps))
+		}
+		for i, v := range ps {
+			// The vertices lie on the circle, the midpoints of the
+			// edges at most tolerance inside of it.
+			w := ps[(i+1)%len(ps)]
+			mid := v.Add(w).Div(2)
+			if d := dist(v, tt.center) - tt.radius; math.Abs(d) > 1e-4 {
+				t.Errorf("%s: vertex %v has distance %v from the circle", tt.name, v, d)
+			}
+			if d := tt.radius - dist(mid, tt.center); d > tolerance+1e-4 {
+				t.Errorf("%s: edge %v-%v deviates %v from the circle", tt.name, v, w, d)
+			}
+		}
+	}
+}
+
+func TestReadPolygonsErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{`<path d="M 0,0 L 10"/>`, "svg: <path>: offset 10: expected number, found end of data"},
+		{`<path id="p" d="0,0 10,0"/>`, `svg: <path id="p">: offset 0: expected command, found '0'`},
+		{`<polygon points="0,0 10,0 10"/>`, "svg: <polygon>: odd number of coordinates in points"},
+		{`<rect width="10%" height="10"/>`, `svg: <rect>: unsupported length "10%"`},
+		{`<g transform="shear(1)"><rect width="1" height="1"/></g>`, `svg: <g>: invalid transform "shear(1)": unknown transform function "shear"`},
+		{`<rect data-pathfind="wall" width="1" height="1"/>`, `svg: <rect>: invalid data-pathfind value "wall"`},
+		{`<rect id="r" data-pathfind="hole" width="1" height="1"/>`, `svg: polygon 0 (id "r") is marked as hole, but isn't inside of an area`},
+	}
+	for _, tt := range tests {
+		input := `<svg xmlns="http://www.w3.org/2000/svg">` + tt.input + `</svg>`
+		_, err := svg.ReadPolygons(strings.NewReader(input), 0.1)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("ReadPolygons(%s)\n got error: %v\nwant error: %s", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReadPolygonsMarkedRoles(t *testing.T) {
+	// By nesting, the table inside the rug would be an island, and the
+	// stage inside the unmarked hall a hole. The marked roles win.
+	const input = `<svg xmlns="http://www.w3.org/2000/svg">
+  <rect id="floor" width="40" height="40"/>
+  <rect id="rug" x="5" y="5" width="20" height="20"/>
+  <rect id="table" data-pathfind="hole" x="10" y="10" width="5" height="5"/>
+  <rect id="hall" x="50" width="40" height="40"/>
+  <rect id="stage" data-pathfind="area" x="60" y="10" width="10" height="10"/>
+</svg>`
+	polygons, err := svg.ReadPolygons(strings.NewReader(input), 0.1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var roles []svg.Role
+	var parents []int
+	for _, p := range polygons {
+		roles = append(roles, p.Role)
+		parents = append(parents, p.Parent)
+	}
+	if want := []svg.Role{svg.Area, svg.Hole, svg.Hole, svg.Area, svg.Area}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("roles\n got: %v\nwant: %v", roles, want)
+	}
+	if want := []int{-1, 0, 0, -1, -1}; !reflect.DeepEqual(parents, want) {
+		t.Errorf("parents\n got: %v\nwant: %v", parents, want)
+	}
+}
+
+func TestReadPolygonsRoleError(t *testing.T) {
+	const input = `<svg xmlns="http://www.w3.org/2000/svg">
+  <rect width="30" height="30"/>
+  <rect data-pathfind="hole" x="40" y="10" width="10" height="10"/>
+</svg>`
+	_, err := svg.ReadPolygons(strings.NewReader(input), 0.1)
+	var roleErr *svg.RoleError
+	if !errors.As(err, &roleErr) {
+		t.Fatalf("got error %v, want *RoleError", err)
+	}
+	if roleErr.Polygon != 1 || roleErr.Role != svg.Hole {
+		t.Errorf("got %+v, want polygon 1 marked as hole", roleErr)
+	}
+}
+
+func TestNewPathfinder(t *testing.T) {
+	pathfinder, err := svg.NewPathfinder(strings.NewReader(floorPlan), 0.1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := pathfinder.PathF(geom.V2(5, 15), geom.V2(25, 12))
+	want := []geom.Vec2{geom.V2(5, 15), geom.V2(10, 10), geom.V2(20, 10), geom.V2(25, 12)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathF\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestNewPathfinderTouchingHole(t *testing.T) {
+	// A wall marked as hole, flush with the top side of the floor, so
+	// that paths must pass it at its bottom end instead of along the
+	// shared edge, and an unmarked island in a second hole, which touches
+	// the outline of this hole.
+	const input = `<svg xmlns="http://www.w3.org/2000/svg">
+  <rect id="floor" width="40" height="40"/>
+  <rect id="wall" data-pathfind="hole" x="10" width="5" height="20"/>
+  <rect id="pond" x="25" y="25" width="10" height="10"/>
+  <rect id="rock" x="25" y="25" width="5" height="5"/>
+</svg>`
+	polygons, err := svg.ReadPolygons(strings.NewReader(input), 0.1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var roles []svg.Role
+	var parents []int
+	for _, p := range polygons {
+		roles = append(roles, p.Role)
+		parents = append(parents, p.Parent)
+	}
+	if want := []svg.Role{svg.Area, svg.Hole, svg.Hole, svg.Area}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("roles\n got: %v\nwant: %v", roles, want)
+	}
+	if want := []int{-1, 0, 0, 2}; !reflect.DeepEqual(parents, want) {
+		t.Errorf("parents\n got: %v\nwant: %v", parents, want)
+	}
+
+	pathfinder, err := svg.NewPathfinder(strings.NewReader(input), 0.1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := pathfinder.PathF(geom.V2(5, 5), geom.V2(20, 5))
+	want := []geom.Vec2{geom.V2(5, 5), geom.V2(10, 20), geom.V2(15, 20), geom.V2(20, 5)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathF\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func dist(a, b geom.Vec2) float64 {
+	return math.Hypot(float64(a.X)-float64(b.X), float64(a.Y)-float64(b.Y))
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A matrix is a 2D affine transformation matrix
+//
+//	| a c e |
+//	| b d f |
+//	| 0 0 1 |
+//
+// with the same component names as the SVG matrix(a b c d e f) transform.
+type matrix struct {
+	a, b, c, d, e, f float64
+}
+
+var identity = matrix{a: 1, d: 1}
+
+// mul returns the matrix product m·n, i.e. the transformation that applies
+// n first and then m.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		a: m.a*n.a + m.c*n.b,
+		b: m.b*n.a + m.d*n.b,
+		c: m.a*n.c + m.c*n.d,
+		d: m.b*n.c + m.d*n.d,
+		e: m.a*n.e + m.c*n.f + m.e,
+		f: m.b*n.e + m.d*n.f + m.f,
+	}
+}
+
+// apply transforms point p.
+func (m matrix) apply(p point) point {
+	return point{
+		x: m.a*p.x + m.c*p.y + m.e,
+		y: m.b*p.x + m.d*p.y + m.f,
+	}
+}
+
+// scale returns the largest factor by which m stretches a distance.
+func (m matrix) scale() float64 {
+	// The largest singular value of the linear part.
+	p := (m.a*m.a + m.b*m.b + m.c*m.c + m.d*m.d) / 2
+	q := m.a*m.d - m.b*m.c
+	return math.Sqrt(p + math.Sqrt(max(0, p*p-q*q)))
+}
+
+// parseTransform parses the value of an SVG transform attribute, a list of
+// transform functions like "translate(10 20) rotate(45)".
+func parseTransform(s string) (matrix, error) {
+	m := identity
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		open := strings.IndexByte(rest, '(')
+		closing := strings.IndexByte(rest, ')')
+		if open < 0 || closing < open {
+			return identity, fmt.Errorf("invalid transform %q", s)
+		}
+		name := strings.TrimSpace(rest[:open])
+		args, err := parseNumbers(rest[open+1 : closing])
+		if err != nil {
+			return identity, fmt.Errorf("invalid transform %q: %w", s, err)
+		}
+		t, err := transformFunc(name, args)
+		if err != nil {
+			return identity, fmt.Errorf("invalid transform %q: %w", s, err)
+		}
+		m = m.mul(t)
+		rest = strings.TrimLeft(rest[closing+1:], ", \t\r\n")
+	}
+	return m, nil
+}
+
+func transformFunc(name string, args []float64) (matrix, error) {
+	argc := func(counts ...int) error {
+		for _, n := range counts {
+			if len(args) == n {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s with %d arguments", name, len(args))
+	}
+	switch name {
+	case "matrix":
+		if err := argc(6); err != nil {
+			return identity, err
+		}
+		return matrix{args[0], args[1], args[2], args[3], args[4], args[5]}, nil
+	case "translate":
+		if err := argc(1, 2); err != nil {
+			return identity, err
+		}
+		args = append(args, 0)
+		return matrix{a: 1, d: 1, e: args[0], f: args[1]}, nil
+	case "scale":
+		if err := argc(1, 2); err != nil {
+			return identity, err
+		}
+		args = append(args, args[0])
+		return matrix{a: args[0], d: args[1]}, nil
+	case "rotate":
+		if err := argc(1, 3); err != nil {
+			return identity, err
+		}
+		sin, cos := math.Sincos(args[0] * math.Pi / 180)
+		r := matrix{a: cos, b: sin, c: -sin, d: cos}
+		if len(args) == 3 {
+			cx, cy := args[1], args[2]
+			r = matrix{a: 1, d: 1, e: cx, f: cy}.mul(r).mul(matrix{a: 1, d: 1, e: -cx, f: -cy})
+		}
+		return r, nil
+	case "skewX":
+		if err := argc(1); err != nil {
+			return identity, err
+		}
+		return matrix{a: 1, c: math.Tan(args[0] * math.Pi / 180), d: 1}, nil
+	case "skewY":
+		if err := argc(1); err != nil {
+			return identity, err
+		}
+		return matrix{a: 1, b: math.Tan(args[0] * math.Pi / 180), d: 1}, nil
+	}
+	return identity, fmt.Errorf("unknown transform function %q", name)
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseTransform(t *testing.T) {
+	tests := []struct {
+		s    string
+		p    point
+		want point
+	}{
+		{"", point{1, 2}, point{1, 2}},
+		{"translate(10)", point{1, 2}, point{11, 2}},
+		{"translate(10, 20)", point{1, 2}, point{11, 22}},
+		{"scale(2)", point{1, 2}, point{2, 4}},
+		{"scale(2 3)", point{1, 2}, point{2, 6}},
+		{"rotate(90)", point{1, 0}, point{0, 1}},
+		{"rotate(90 10 10)", point{11, 10}, point{10, 11}},
+		{"skewX(45)", point{0, 1}, point{1, 1}},
+		{"skewY(45)", point{1, 0}, point{1, 1}},
+		{"matrix(1 2 3 4 5 6)", point{1, 1}, point{9, 12}},
+		// The rightmost transform is applied first.
+		{"translate(10,0) scale(2)", point{1, 1}, point{12, 2}},
+		{"scale(2),translate(10,0)", point{1, 1}, point{22, 2}},
+	}
+	for _, tt := range tests {
+		m, err := parseTransform(tt.s)
+		if err != nil {
+			t.Errorf("parseTransform(%q): unexpected error: %v", tt.s, err)
+			continue
+		}
+		got := m.apply(tt.p)
+		if math.Abs(got.x-tt.want.x) > 1e-9 || math.Abs(got.y-tt.want.y) > 1e-9 {
+			t.Errorf("parseTransform(%q).apply(%v) = %v, want: %v", tt.s, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestMatrixScale(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+	}{
+		{"", 1},
+		{"scale(2 3)", 3},
+		{"rotate(30) scale(-4 1)", 4},
+		{"translate(100 100)", 1},
+	}
+	for _, tt := range tests {
+		m, err := parseTransform(tt.s)
+		if err != nil {
+			t.Errorf("parseTransform(%q): unexpected error: %v", tt.s, err)
+			continue
+		}
+		if got := m.scale(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("parseTransform(%q).scale() = %v, want: %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// Errors reported by NewCheckedPathfinder and NewCheckedPathfinderF.
+// Except for ErrNoPolygons, they are wrapped in a *PolygonError.
+var (
+	ErrNoPolygons        = errors.New("pathfind: empty polygon set")
+	ErrTooFewVertices    = errors.New("polygon has fewer than 3 vertices")
+	ErrDuplicateVertex   = errors.New("vertex repeats previous vertex")
+	ErrSelfIntersection  = errors.New("polygon intersects itself")
+	ErrInvalidCoordinate = errors.New("coordinate is NaN or infinite")
+	ErrCoordinateRange   = errors.New("integer coordinate exceeds ±2^24")
+	ErrOffsetOverlap     = errors.New("polygon offset by the radius intersects itself or another polygon")
+)
+
+// maxIntCoord is the largest magnitude of integer coordinates that are
+// represented exactly by float32 values, so that the geometric predicates
+// decide exactly for them.
+const maxIntCoord = 1 << 24
+
+// A PolygonError records an invalid polygon in a polygon set.
+type PolygonError struct {
+	Polygon int   // index of the polygon in the polygon set
+	Vertex  int   // index of the vertex in the polygon, or -1 if not specific to a vertex
+	Err     error // the problem, one of the Err... variables
+}
+
+func (e *PolygonError) Error() string {
+	if e.Vertex < 0 {
+		return fmt.Sprintf("pathfind: polygon %d: %v", e.Polygon, e.Err)
+	}
+	return fmt.Sprintf("pathfind: polygon %d, vertex %d: %v", e.Polygon, e.Vertex, e.Err)
+}
+
+func (e *PolygonError) Unwrap() error {
+	return e.Err
+}
+
+// NewCheckedPathfinder is like NewPathfinder, but it validates the polygon
+// set first. It returns an error if the polygon set is empty, or if any
+// polygon has fewer than 3 vertices, repeats a vertex consecutively, or
+// intersects itself. Otherwise the results of the path queries would be
+// unreliable. It also reports coordinates beyond ±2^24, which can't be
+// converted to floating-point coordinates exactly, and, with the WithRadius
+// option, polygons that intersect themselves or other polygons after the
+// offset by the radius. If the error concerns a specific polygon, it is a
+// *PolygonError.
+func NewCheckedPathfinder(polygons [][]image.Point, opts ...Option) (*Pathfinder, error) {
+	for i, p := range polygons {
+		for j, pt := range p {
+			if max(pt.X, -pt.X, pt.Y, -pt.Y) > maxIntCoord {
+				return nil, &PolygonError{Polygon: i, Vertex: j, Err: ErrCoordinateRange}
+			}
+		}
+	}
+	return newCheckedPathfinder(polygonSetFromPoints(polygons), opts)
+}
+
+// NewCheckedPathfinderF is like NewPathfinderF, but it validates the
+// polygon set first, see NewCheckedPathfinder. Additionally, it reports
+// coordinates that are NaN or infinite.
+func NewCheckedPathfinderF(polygons [][]geom.Vec2, opts ...Option) (*Pathfinder, error) {
+	return newCheckedPathfinder(polygonSetFromVecs(polygons), opts)
+}
+
+func newCheckedPathfinder(ps poly.PolygonSet, opts []Option) (*Pathfinder, error) {
+	if err := validate(ps); err != nil {
+		return nil, err
+	}
+	c := newConfig(opts)
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	nest := nestingOf(ps)
+	if err := validateOffset(ps, nest, c.radius, c.join); err != nil {
+		return nil, err
+	}
+	return newPathfinder(ps, nest, c), nil
+}
+
+// validate checks the polygon set for problems that lead to wrong results
+// of the polygon operations.
+func validate(ps poly.PolygonSet) error {
+	if len(ps) == 0 {
+		return ErrNoPolygons
+	}
+	for i, p := range ps {
+		if err := validatePolygon(p); err != nil {
+			err.Polygon = i
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePolygon(p poly.Polygon) *PolygonError {
+	for j, v := range p {
+		if !isFinite(v.X) || !isFinite(v.Y) {
+			return &PolygonError{Vertex: j, Err: ErrInvalidCoordinate}
+		}
+	}
+	if len(p) < 3 {
+		return &PolygonError{Vertex: -1, Err: ErrTooFewVertices}
+	}
+	for j, v := range p {
+		if prev := p[p.WrapIndex(j-1)]; v == prev {
+			return &PolygonError{Vertex: j, Err: ErrDuplicateVertex}
+		}
+	}
+	if j, _, found := p.SelfIntersection(); found {
+		return &PolygonError{Vertex: j, Err: ErrSelfIntersection}
+	}
+	return nil
+}
+
+func isFinite(x float32) bool {
+	return !math.IsNaN(float64(x)) && !math.IsInf(float64(x), 0)
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathfind_test
+
+import (
+	"errors"
+	"image"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+)
+
+func TestNewCheckedPathfinder(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygons [][]image.Point
+		opts     []pathfind.Option
+		wantErr  error
+	}{
+		{
+			name:     "Valid polygons",
+			polygons: polygonO,
+			wantErr:  nil,
+		},
+		{
+			name:     "Empty polygon set",
+			polygons: nil,
+			wantErr:  pathfind.ErrNoPolygons,
+		},
+		{
+			name: "Too few vertices",
+			polygons: [][]image.Point{
+				polygonO[0],
+				{image.Pt(20, 10), image.Pt(30, 20)},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: -1, Err: pathfind.ErrTooFewVertices},
+		},
+		{
+			name: "Repeated consecutive vertex",
+			polygons: [][]image.Point{
+				{image.Pt(0, 0), image.Pt(40, 0), image.Pt(40, 0), image.Pt(0, 40)},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 0, Vertex: 2, Err: pathfind.ErrDuplicateVertex},
+		},
+		{
+			name: "Repeated first vertex at the end",
+			polygons: [][]image.Point{
+				{image.Pt(0, 0), image.Pt(40, 0), image.Pt(0, 40), image.Pt(0, 0)},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 0, Vertex: 0, Err: pathfind.ErrDuplicateVertex},
+		},
+		{
+			name: "Self-intersection",
+			polygons: [][]image.Point{
+				polygonO[0],
+				{image.Pt(10, 10), image.Pt(30, 10), image.Pt(10, 30), image.Pt(30, 30)},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: 1, Err: pathfind.ErrSelfIntersection},
+		},
+		{
+			name: "Coordinate out of range",
+			polygons: [][]image.Point{
+				{image.Pt(0, 0), image.Pt(1<<24+1, 0), image.Pt(0, 40)},
+			},
+			wantErr: &pathfind.PolygonError{Polygon: 0, Vertex: 1, Err: pathfind.ErrCoordinateRange},
+		},
+		{
+			name:     "Negative radius",
+			polygons: polygonO,
+			opts:     []pathfind.Option{pathfind.WithRadius(-1, pathfind.RoundJoin)},
+			wantErr:  errors.New("pathfind: invalid radius -1"),
+		},
+		{
+			name:     "Radius",
+			polygons: polygonO,
+			opts:     []pathfind.Option{pathfind.WithRadius(5, pathfind.RoundJoin)},
+			wantErr:  nil,
+		},
+		{
+			name: "Hole closer than twice the radius to the outline",
+			polygons: [][]image.Point{
+				{image.Pt(0, 0), image.Pt(100, 0), image.Pt(100, 100), image.Pt(0, 100)},
+				{image.Pt(2, 30), image.Pt(60, 30), image.Pt(60, 40), image.Pt(2, 40)},
+			},
+			opts:    []pathfind.Option{pathfind.WithRadius(5, pathfind.MiterJoin)},
+			wantErr: &pathfind.PolygonError{Polygon: 1, Vertex: -1, Err: pathfind.ErrOffsetOverlap},
+		},
+		{
+			name: "Holes closer than twice the radius to each other",
+			polygons: [][]image.Point{
+				{image.Pt(0, 0), image.Pt(100, 0), image.Pt(100, 100), image.Pt(0, 100)},
+				{image.Pt(20, 20), image.Pt(40, 20), image.Pt(40, 40), image.Pt(20, 40)},
+				{image.Pt(45, 20), image.Pt(65, 20), image.Pt(65, 40), image.Pt(45, 40)},
+			},
+			opts:    []pathfind.Option{pathfind.WithRadius(5, pathfind.RoundJoin)},
+			wantErr: &pathfind.PolygonError{Polygon: 2, Vertex: -1, Err: pathfind.ErrOffsetOverlap},
+		},
+		{
+			name: "Holes twice the radius apart",
+			polygons: [][]image.Point{
+				{image.Pt(0, 0), image.Pt(100, 0), image.Pt(100, 100), image.Pt(0, 100)},
+				{image.Pt(20, 20), image.Pt(40, 20), image.Pt(40, 40), image.Pt(20, 40)},
+				{image.Pt(51, 20), image.Pt(71, 20), image.Pt(71, 40), image.Pt(51, 40)},
+			},
+			opts:    []pathfind.Option{pathfind.WithRadius(5, pathfind.RoundJoin)},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathfinder, err := pathfind.NewCheckedPathfinder(tt.polygons, tt.opts...)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("NewCheckedPathfinder(%v)\n got error: %v\nwant error: %v", tt.polygons, err, tt.wantErr)
+			}
+			if (pathfinder == nil) != (err != nil) {
+				t.Errorf("NewCheckedPathfinder(%v) = %v, %v; want either pathfinder or error", tt.polygons, pathfinder, err)
+			}
+		})
+	}
+}
+
+func TestNewCheckedPathfinderFInvalidCoordinate(t *testing.T) {
+	polygons := [][]geom.Vec2{
+		{geom.V2(0, 0), geom.V2(40, 0), geom.V2(40, float32(math.NaN())), geom.V2(0, 40)},
+	}
+	_, err := pathfind.NewCheckedPathfinderF(polygons)
+	if !errors.Is(err, pathfind.ErrInvalidCoordinate) {
+		t.Fatalf("NewCheckedPathfinderF(%v): got error %v, want %v", polygons, err, pathfind.ErrInvalidCoordinate)
+	}
+	want := "pathfind: polygon 0, vertex 2: coordinate is NaN or infinite"
+	if err.Error() != want {
+		t.Errorf("error message: got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestPathfinderPathEmptyPolygonSet(t *testing.T) {
+	pathfinder := pathfind.NewPathfinder(nil)
+	if got := pathfinder.Path(image.Pt(0, 0), image.Pt(10, 10)); got != nil {
+		t.Errorf("Path on empty polygon set: got %v, want nil", got)
+	}
+}
@@ -0,0 +1,491 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wkt reads and writes polygon sets and paths for package pathfind
+// in the Well-Known Text (WKT) representation of geometries, e.g.:
+//
+//	POLYGON ((0 0, 40 0, 40 40, 0 40, 0 0), (10 10, 10 30, 30 30, 30 10, 10 10))
+//	LINESTRING (5 15, 10 10, 30 10, 35 15)
+//
+// The supported geometry types are POLYGON, MULTIPOLYGON and LINESTRING.
+// Keywords are case-insensitive. Coordinates with Z and M values are
+// accepted, but only the x and y values are used.
+package wkt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/internal/nest"
+	"github.com/fzipp/pathfind/internal/poly"
+)
+
+// A SyntaxError describes malformed WKT input and its position.
+type SyntaxError struct {
+	Line   int // line number, starting at 1
+	Column int // column number in runes, starting at 1
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("wkt: %d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ReadPolygons reads a sequence of POLYGON and MULTIPOLYGON geometries from r
+// and returns the areas they describe.
+//
+// The exterior ring of a WKT polygon becomes the outline of an area, its
+// interior rings become the holes of this area, so a hole may touch the
+// exterior ring. A polygon inside of a hole of another polygon becomes an
+// island of the innermost such hole. The closing point of each ring is
+// removed, and rings are reoriented to the winding order expected by
+// pathfind.
+func ReadPolygons(r io.Reader) ([]pathfind.AreaF, error) {
+	p, err := newParser(r)
+	if err != nil {
+		return nil, err
+	}
+	var areas []pathfind.AreaF
+	for !p.atEOF() {
+		kw, err := p.keyword()
+		if err != nil {
+			return nil, err
+		}
+		switch kw.text {
+		case "POLYGON":
+			rings, err := p.polygonText()
+			if err != nil {
+				return nil, err
+			}
+			areas = appendArea(areas, rings)
+		case "MULTIPOLYGON":
+			if err := p.dimension(); err != nil {
+				return nil, err
+			}
+			err := p.list(func() error {
+				rings, err := p.polygonBody()
+				areas = appendArea(areas, rings)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, kw.errorf("unexpected geometry type %s, want POLYGON or MULTIPOLYGON", kw.text)
+		}
+	}
+	return nest.Islands(areas), nil
+}
+
+// appendArea appends the rings of a polygon as an area with the exterior
+// ring as outline and the interior rings as holes. An empty polygon is
+// ignored.
+func appendArea(areas []pathfind.AreaF, rings [][]geom.Vec2) []pathfind.AreaF {
+	if len(rings) == 0 {
+		return areas
+	}
+	a := pathfind.AreaF{Outline: rings[0]}
+	for _, ring := range rings[1:] {
+		a.Holes = append(a.Holes, pathfind.HoleF{Outline: ring})
+	}
+	return append(areas, a)
+}
+
+// ReadLineString reads a single LINESTRING geometry from r and returns its
+// points, e.g. the waypoints of a path.
+func ReadLineString(r io.Reader) ([]geom.Vec2, error) {
+	p, err := newParser(r)
+	if err != nil {
+		return nil, err
+	}
+	kw, err := p.keyword()
+	if err != nil {
+		return nil, err
+	}
+	if kw.text != "LINESTRING" {
+		return nil, kw.errorf("unexpected geometry type %s, want LINESTRING", kw.text)
+	}
+	if err := p.dimension(); err != nil {
+		return nil, err
+	}
+	points := []geom.Vec2{}
+	err = p.list(func() error {
+		pt, err := p.point()
+		points = append(points, pt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !p.atEOF() {
+		return nil, p.tok.errorf("unexpected %s after LINESTRING", p.tok)
+	}
+	return points, nil
+}
+
+// NewPathfinder reads areas from WKT via ReadPolygons and creates a
+// validated Pathfinder for them with pathfind.NewPathfinderFromAreasF.
+func NewPathfinder(r io.Reader, opts ...pathfind.Option) (*pathfind.Pathfinder, error) {
+	areas, err := ReadPolygons(r)
+	if err != nil {
+		return nil, err
+	}
+	return pathfind.NewPathfinderFromAreasF(areas, opts...)
+}
+
+// WritePolygons writes a polygon set, e.g. as returned by
+// pathfind.Pathfinder.Polygons, as a WKT MULTIPOLYGON to w. The hierarchy
+// describes the nesting of the polygons, as returned by
+// pathfind.Pathfinder.Hierarchy. Each area of the set is written as a
+// polygon with its outline as exterior ring and its holes as interior
+// rings, in the order of the polygon set, so that ReadPolygons restores
+// the nesting.
+func WritePolygons(w io.Writer, polygons [][]geom.Vec2, hierarchy []pathfind.PolygonNode) error {
+	if len(hierarchy) != len(polygons) {
+		return fmt.Errorf("wkt: hierarchy with %d nodes for %d polygons", len(hierarchy), len(polygons))
+	}
+	if len(polygons) == 0 {
+		_, err := io.WriteString(w, "MULTIPOLYGON EMPTY\n")
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString("MULTIPOLYGON (")
+	first := true
+	for i, node := range hierarchy {
+		if node.Depth%2 != 0 {
+			continue
+		}
+		if !first {
+			sb.WriteString(", ")
+		}
+		first = false
+		sb.WriteString("(")
+		writeRing(&sb, polygons[i])
+		for _, j := range node.Children {
+			sb.WriteString(", ")
+			writeRing(&sb, polygons[j])
+		}
+		sb.WriteString(")")
+	}
+	sb.WriteString(")\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeRing writes polygon p as a closed linear ring.
+func writeRing(sb *strings.Builder, p []geom.Vec2) {
+	sb.WriteString("(")
+	writePoints(sb, append(slices.Clip(p), p[0]))
+	sb.WriteString(")")
+}
+
+// WriteLineString writes the points of a path as a WKT LINESTRING to w.
+func WriteLineString(w io.Writer, path []geom.Vec2) error {
+	if len(path) == 0 {
+		_, err := io.WriteString(w, "LINESTRING EMPTY\n")
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString("LINESTRING (")
+	writePoints(&sb, path)
+	sb.WriteString(")\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writePoints(sb *strings.Builder, points []geom.Vec2) {
+	for i, pt := range points {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(formatFloat(pt.X))
+		sb.WriteByte(' ')
+		sb.WriteString(formatFloat(pt.Y))
+	}
+}
+
+// formatFloat formats f with the smallest number of digits necessary to
+// represent it exactly as a float32, without exponent.
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+// polygonText parses the dimension and body of a POLYGON.
+func (p *parser) polygonText() ([][]geom.Vec2, error) {
+	if err := p.dimension(); err != nil {
+		return nil, err
+	}
+	return p.polygonBody()
+}
+
+// polygonBody parses the list of rings of a polygon.
+func (p *parser) polygonBody() ([][]geom.Vec2, error) {
+	rings := [][]geom.Vec2{}
+	err := p.list(func() error {
+		ring, err := p.ring()
+		rings = append(rings, ring)
+		return err
+	})
+	return rings, err
+}
+
+// ring parses a closed linear ring and returns it as a polygon without the
+// closing point and with positive signed area.
+func (p *parser) ring() ([]geom.Vec2, error) {
+	start := p.tok
+	var ring poly.Polygon
+	err := p.list(func() error {
+		pt, err := p.point()
+		ring = append(ring, pt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ring) < 4 {
+		return nil, start.errorf("linear ring with %d points, want at least 4", len(ring))
+	}
+	if ring[0] != ring[len(ring)-1] {
+		return nil, start.errorf("linear ring is not closed: first point %v, last point %v", ring[0], ring[len(ring)-1])
+	}
+	ring = ring[:len(ring)-1]
+	ring.Normalize()
+	return ring, nil
+}
+
+// point parses the coordinates of a point.
+func (p *parser) point() (geom.Vec2, error) {
+	var coords []float32
+	for p.tok.kind == number {
+		f, err := strconv.ParseFloat(p.tok.text, 32)
+		if err != nil {
+			return geom.Vec2{}, p.tok.errorf("invalid number %s", p.tok.text)
+		}
+		coords = append(coords, float32(f))
+		if err := p.next(); err != nil {
+			return geom.Vec2{}, err
+		}
+	}
+	if len(coords) != p.dims {
+		return geom.Vec2{}, p.tok.errorf("point with %d coordinates, want %d", len(coords), p.dims)
+	}
+	return geom.V2(coords[0], coords[1]), nil
+}
+
+// list parses a parenthesized, comma-separated list, calling elem for each
+// element, or the keyword EMPTY for an empty list.
+func (p *parser) list(elem func() error) error {
+	if p.tok.kind == word && p.tok.text == "EMPTY" {
+		return p.next()
+	}
+	if err := p.expect(lparen); err != nil {
+		return err
+	}
+	for {
+		if err := elem(); err != nil {
+			return err
+		}
+		if p.tok.kind != comma {
+			break
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+	return p.expect(rparen)
+}
+
+// dimension parses the optional dimension keyword Z, M or ZM after the
+// geometry type and sets the number of coordinates per point.
+func (p *parser) dimension() error {
+	p.dims = 2
+	if p.tok.kind != word {
+		return nil
+	}
+	switch p.tok.text {
+	case "Z", "M":
+		p.dims = 3
+	case "ZM":
+		p.dims = 4
+	default:
+		return nil
+	}
+	return p.next()
+}
+
+// keyword parses a geometry type keyword.
+func (p *parser) keyword() (token, error) {
+	kw := p.tok
+	if kw.kind != word {
+		return kw, kw.errorf("unexpected %s, want geometry type", kw)
+	}
+	return kw, p.next()
+}
+
+func (p *parser) expect(kind tokenKind) error {
+	if p.tok.kind != kind {
+		return p.tok.errorf("unexpected %s, want %s", p.tok, kind)
+	}
+	return p.next()
+}
+
+func (p *parser) atEOF() bool {
+	return p.tok.kind == eof
+}
+
+// parser is a recursive descent parser for WKT. It holds the current token.
+type parser struct {
+	s    *scanner
+	tok  token
+	dims int
+}
+
+func newParser(r io.Reader) (*parser, error) {
+	p := &parser{s: &scanner{r: bufio.NewReader(r), line: 1, col: 1}}
+	return p, p.next()
+}
+
+func (p *parser) next() error {
+	tok, err := p.s.scan()
+	p.tok = tok
+	return err
+}
+
+type tokenKind int
+
+const (
+	eof tokenKind = iota
+	word
+	number
+	lparen
+	rparen
+	comma
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case eof:
+		return "end of input"
+	case word:
+		return "keyword"
+	case number:
+		return "number"
+	case lparen:
+		return "'('"
+	case rparen:
+		return "')'"
+	case comma:
+		return "','"
+	}
+	return "unknown token"
+}
+
+type token struct {
+	kind      tokenKind
+	text      string
+	line, col int
+}
+
+func (t token) String() string {
+	if t.kind == word || t.kind == number {
+		return strconv.Quote(t.text)
+	}
+	return t.kind.String()
+}
+
+func (t token) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: t.line, Column: t.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// scanner splits WKT input into tokens and keeps track of the position.
+type scanner struct {
+	r         *bufio.Reader
+	line, col int
+}
+
+func (s *scanner) scan() (token, error) {
+	r, err := s.skipSpace()
+	tok := token{line: s.line, col: s.col}
+	if err == io.EOF {
+		return tok, nil
+	}
+	if err != nil {
+		return tok, err
+	}
+	switch {
+	case r == '(':
+		tok.kind = lparen
+	case r == ')':
+		tok.kind = rparen
+	case r == ',':
+		tok.kind = comma
+	case unicode.IsLetter(r):
+		tok.kind = word
+		tok.text = strings.ToUpper(s.scanWhile(unicode.IsLetter))
+		return tok, nil
+	case r == '-' || r == '+' || r == '.' || ('0' <= r && r <= '9'):
+		tok.kind = number
+		tok.text = s.scanWhile(isNumberRune)
+		return tok, nil
+	default:
+		return tok, tok.errorf("unexpected character %q", r)
+	}
+	s.r.ReadRune()
+	s.advance(r)
+	return tok, nil
+}
+
+// skipSpace skips white space and returns the next rune without
+// consuming it.
+func (s *scanner) skipSpace() (rune, error) {
+	for {
+		r, _, err := s.r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(r) {
+			return r, s.r.UnreadRune()
+		}
+		s.advance(r)
+	}
+}
+
+// scanWhile consumes the following runes as long as f returns true for
+// them, and returns them.
+func (s *scanner) scanWhile(f func(rune) bool) string {
+	var sb strings.Builder
+	for {
+		r, _, err := s.r.ReadRune()
+		if err != nil {
+			break
+		}
+		if !f(r) {
+			s.r.UnreadRune()
+			break
+		}
+		sb.WriteRune(r)
+		s.advance(r)
+	}
+	return sb.String()
+}
+
+func (s *scanner) advance(r rune) {
+	if r == '\n' {
+		s.line++
+		s.col = 1
+		return
+	}
+	s.col++
+}
+
+func isNumberRune(r rune) bool {
+	return ('0' <= r && r <= '9') || strings.ContainsRune("+-.eE", r)
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 Frederik Zipp. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wkt_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fzipp/geom"
+	"github.com/fzipp/pathfind"
+	"github.com/fzipp/pathfind/wkt"
+)
+
+func TestReadPolygons(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []pathfind.AreaF
+	}{
+		{
+			name:  "Polygon with hole",
+			input: "POLYGON ((0 0, 40 0, 40 40, 0 40, 0 0), (10 10, 10 30, 30 30, 30 10, 10 10))",
+			want: []pathfind.AreaF{{
+				Outline: []geom.Vec2{geom.V2(0, 0), geom.V2(40, 0), geom.V2(40, 40), geom.V2(0, 40)},
+				Holes: []pathfind.HoleF{{
+					Outline: []geom.Vec2{geom.V2(30, 10), geom.V2(30, 30), geom.V2(10, 30), geom.V2(10, 10)},
+				}},
+			}},
+		},
+		{
+			name: "Multiple geometries, lower case, Z coordinates",
+			input: `polygon z ((0 0 1, 10 0 1, 10 10 1, 0 0 1))
+				MultiPolygon (((20 0, 30 0, 30 10.5, 20 0)), EMPTY, ((-1.5e1 0, -5 0, -5 10, -15 0)))`,
+			want: []pathfind.AreaF{
+				{Outline: []geom.Vec2{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10)}},
+				{Outline: []geom.Vec2{geom.V2(20, 0), geom.V2(30, 0), geom.V2(30, 10.5)}},
+				{Outline: []geom.Vec2{geom.V2(-15, 0), geom.V2(-5, 0), geom.V2(-5, 10)}},
+			},
+		},
+		{
+			name: "Island in a hole",
+			input: `MULTIPOLYGON (((2 2, 3 2, 3 3, 2 2)),
+				((0 0, 10 0, 10 10, 0 10, 0 0), (1 1, 9 1, 9 9, 1 9, 1 1)))`,
+			want: []pathfind.AreaF{{
+				Outline: []geom.Vec2{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10), geom.V2(0, 10)},
+				Holes: []pathfind.HoleF{{
+					Outline: []geom.Vec2{geom.V2(1, 1), geom.V2(9, 1), geom.V2(9, 9), geom.V2(1, 9)},
+					Islands: []pathfind.AreaF{
+						{Outline: []geom.Vec2{geom.V2(2, 2), geom.V2(3, 2), geom.V2(3, 3)}},
+					},
+				}},
+			}},
+		},
+		{
+			name:  "Empty",
+			input: "POLYGON EMPTY",
+			want:  []pathfind.AreaF{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := wkt.ReadPolygons(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadPolygons(%q)\n got: %v\nwant: %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPolygonsErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"POLYGON ((0 0, 10 0, 10 10, 0 0)", "wkt: 1:33: unexpected end of input, want ')'"},
+		{"POLYGON ((0 0, 10 0, 0 0))", "wkt: 1:10: linear ring with 3 points, want at least 4"},
+		{"POLYGON ((0 0, 10 0, 10 10, 0 10))", "wkt: 1:10: linear ring is not closed: first point (0, 0), last point (0, 10)"},
+		{"POLYGON ((0 0, 10 0, 10 10, 0 0)),\n(1 2)", "wkt: 1:34: unexpected ',', want geometry type"},
+		{"POLYGON ((0 0, 10 0, 10 10, 0 0))\nPOINT (1 2)", "wkt: 2:1: unexpected geometry type POINT, want POLYGON or MULTIPOLYGON"},
+		{"POLYGON ((0 0,\n  10 0 5, 10 10, 0 0))", "wkt: 2:9: point with 3 coordinates, want 2"},
+		{"POLYGON ((0 0, 1-0 0, 10 10, 0 0))", `wkt: 1:16: invalid number 1-0`},
+		{"POLYGON ((0 0, 10 0; 10 10, 0 0))", "wkt: 1:20: unexpected character ';'"},
+	}
+	for _, tt := range tests {
+		_, err := wkt.ReadPolygons(strings.NewReader(tt.input))
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("ReadPolygons(%q)\n got error: %v\nwant error: %s", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReadLineString(t *testing.T) {
+	got, err := wkt.ReadLineString(strings.NewReader("LINESTRING (5 15, 10 10, 30 10.25, 35 15)\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []geom.Vec2{geom.V2(5, 15), geom.V2(10, 10), geom.V2(30, 10.25), geom.V2(35, 15)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadLineString\n got: %v\nwant: %v", got, want)
+	}
+
+	_, err = wkt.ReadLineString(strings.NewReader("LINESTRING (5 15, 10 10) LINESTRING EMPTY"))
+	wantErr := `wkt: 1:26: unexpected "LINESTRING" after LINESTRING`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("ReadLineString with trailing geometry\n got error: %v\nwant error: %s", err, wantErr)
+	}
+}
+
+func TestNewPathfinderTouchingHole(t *testing.T) {
+	// The first vertex of the hole lies on the exterior ring.
+	const input = "POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (0 5, 6 4, 6 6, 0 5))"
+	pathfinder, err := wkt.NewPathfinder(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := pathfinder.PathF(geom.V2(4, 2), geom.V2(4, 8))
+	want := []geom.Vec2{geom.V2(4, 2), geom.V2(6, 4), geom.V2(6, 6), geom.V2(4, 8)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathF\n got: %v\nwant: %v", got, want)
+	}
+	// The path doesn't pass through the point where the hole touches the
+	// exterior ring.
+	got = pathfinder.PathF(geom.V2(1, 2), geom.V2(1, 8))
+	want = []geom.Vec2{geom.V2(1, 2), geom.V2(6, 4), geom.V2(6, 6), geom.V2(1, 8)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathF\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestWritePolygonsHierarchyMismatch(t *testing.T) {
+	polygons := [][]geom.Vec2{{geom.V2(0, 0), geom.V2(10, 0), geom.V2(10, 10)}}
+	err := wkt.WritePolygons(&bytes.Buffer{}, polygons, nil)
+	wantErr := "wkt: hierarchy with 0 nodes for 1 polygons"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("WritePolygons\n got error: %v\nwant error: %s", err, wantErr)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	const input = `POLYGON ((0.25 0, 40 0, 40 40.125, 0 40, 0.25 0), (10 10, 10 30, 30 30, 30 10, 10 10))
+		POLYGON ((15 15, 25 15, 25 25, 15 25, 15 15))`
+	pathfinder, err := wkt.NewPathfinder(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := wkt.WritePolygons(&buf, pathfinder.Polygons(), pathfinder.Hierarchy()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantWKT := "MULTIPOLYGON (((0.25 0, 40 0, 40 40.125, 0 40, 0.25 0), (30 10, 30 30, 10 30, 10 10, 30 10)), " +
+		"((15 15, 25 15, 25 25, 15 25, 15 15)))\n"
+	if got := buf.String(); got != wantWKT {
+		t.Errorf("WritePolygons\n got: %s\nwant: %s", got, wantWKT)
+	}
+	areas, err := wkt.ReadPolygons(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantAreas, err := wkt.ReadPolygons(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(areas, wantAreas) {
+		t.Errorf("areas after round trip\n got: %v\nwant: %v", areas, wantAreas)
+	}
+
+	path := pathfinder.PathF(geom.V2(5, 15), geom.V2(35, 15))
+	buf.Reset()
+	if err := wkt.WriteLineString(&buf, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantWKT = "LINESTRING (5 15, 10 10, 30 10, 35 15)\n"
+	if got := buf.String(); got != wantWKT {
+		t.Errorf("WriteLineString\n got: %s\nwant: %s", got, wantWKT)
+	}
+	got, err := wkt.ReadLineString(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, path) {
+		t.Errorf("path after round trip\n got: %v\nwant: %v", got, path)
+	}
+}